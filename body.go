@@ -0,0 +1,244 @@
+package rocco
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// bodyFields maps the object keys a type accepts when decoded from JSON, at any depth,
+// so WithStrictJSON can reject unknown fields as the body streams in. A nil *bodyFields
+// accepts anything (scalars, interfaces, custom unmarshalers).
+type bodyFields struct {
+	fields map[string]*bodyFields // Struct properties by JSON name (nil for maps and slices).
+	elem   *bodyFields            // Map values and slice or array elements.
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// bodyFieldsFor returns the accepted keys for T.
+func bodyFieldsFor[T any]() *bodyFields {
+	return buildBodyFields(reflect.TypeOf((*T)(nil)).Elem(), make(map[reflect.Type]*bodyFields))
+}
+
+// buildBodyFields walks t the way encoding/json decodes into it. seen holds structs
+// already visited, so recursive types terminate.
+func buildBodyFields(t reflect.Type, seen map[reflect.Type]*bodyFields) *bodyFields {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// Custom unmarshalers decide for themselves what they accept.
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if elem := buildBodyFields(t.Elem(), seen); elem != nil {
+			return &bodyFields{elem: elem}
+		}
+		return nil
+	case reflect.Struct:
+		if node, ok := seen[t]; ok {
+			return node
+		}
+		node := &bodyFields{fields: make(map[string]*bodyFields)}
+		seen[t] = node
+		addBodyFields(node, t, seen)
+		return node
+	default:
+		return nil
+	}
+}
+
+// addBodyFields adds t's fields to node, promoting those of untagged embedded structs.
+func addBodyFields(node *bodyFields, t reflect.Type, seen map[reflect.Type]*bodyFields) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embeddedType := field.Type
+			if embeddedType.Kind() == reflect.Pointer {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				if embedded := buildBodyFields(embeddedType, seen); embedded != nil {
+					for key, child := range embedded.fields {
+						if _, exists := node.fields[key]; !exists {
+							node.fields[key] = child
+						}
+					}
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		node.fields[name] = buildBodyFields(field.Type, seen)
+	}
+}
+
+// field returns the node for a property and whether the struct declares it, matching
+// names case-insensitively as encoding/json does.
+func (f *bodyFields) field(key string) (*bodyFields, bool) {
+	if child, ok := f.fields[key]; ok {
+		return child, true
+	}
+	for name, child := range f.fields {
+		if strings.EqualFold(name, key) {
+			return child, true
+		}
+	}
+	return nil, false
+}
+
+// bodyScanner passes a JSON body through to a decoder, recording its top-level keys
+// for Request.WasProvided and, when fields is set, the first key the input type does
+// not declare. Only the key being read is held, never the body.
+type bodyScanner struct {
+	r       io.Reader
+	fields  *bodyFields // Accepted keys (nil = any key is accepted).
+	readErr error       // First error from r other than io.EOF.
+
+	keys    map[string]json.RawMessage // Top-level keys, with nil values.
+	unknown string                     // Dotted path of the first undeclared key.
+
+	stack      []scanFrame
+	inString   bool
+	escaped    bool
+	expectKey  bool   // The next string in the current object is a key.
+	readingKey bool   // The current string is a key.
+	key        []byte // Quoted key being read.
+}
+
+// scanFrame is an object or array the scanner is inside.
+type scanFrame struct {
+	object    bool
+	node      *bodyFields
+	path      string
+	child     *bodyFields // Node for the value after the last key.
+	childPath string
+}
+
+// newBodyScanner returns a scanner wrapping r, checking keys against fields when it is
+// non-nil.
+func newBodyScanner(r io.Reader, fields *bodyFields) *bodyScanner {
+	return &bodyScanner{r: r, fields: fields}
+}
+
+// Read implements io.Reader.
+func (s *bodyScanner) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.scan(p[:n])
+	if err != nil && !errors.Is(err, io.EOF) && s.readErr == nil {
+		s.readErr = err
+	}
+	return n, err
+}
+
+// scan advances through data, tracking nesting and reading object keys.
+func (s *bodyScanner) scan(data []byte) {
+	for _, c := range data {
+		if s.inString {
+			if s.readingKey {
+				s.key = append(s.key, c)
+			}
+			switch {
+			case s.escaped:
+				s.escaped = false
+			case c == '\\':
+				s.escaped = true
+			case c == '"':
+				s.inString = false
+				if s.readingKey {
+					s.addKey()
+				}
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			s.inString = true
+			if s.expectKey {
+				s.key = append(s.key[:0], c)
+				s.readingKey, s.expectKey = true, false
+			}
+		case '{', '[':
+			s.push(c == '{')
+		case '}', ']':
+			if len(s.stack) > 0 {
+				s.stack = s.stack[:len(s.stack)-1]
+			}
+			s.expectKey = false
+		case ',':
+			s.expectKey = len(s.stack) > 0 && s.stack[len(s.stack)-1].object
+		}
+	}
+}
+
+// push enters an object or array, resolving its node from the enclosing frame.
+func (s *bodyScanner) push(object bool) {
+	frame := scanFrame{object: object}
+	if len(s.stack) == 0 {
+		frame.node = s.fields
+		if object && s.keys == nil {
+			s.keys = make(map[string]json.RawMessage)
+		}
+	} else {
+		parent := &s.stack[len(s.stack)-1]
+		if parent.object {
+			frame.node, frame.path = parent.child, parent.childPath
+		} else if parent.node != nil {
+			frame.node, frame.path = parent.node.elem, parent.path
+		}
+	}
+	s.stack = append(s.stack, frame)
+	s.expectKey = object
+}
+
+// addKey records the key just read and checks it against the current object's node.
+func (s *bodyScanner) addKey() {
+	s.readingKey = false
+	var name string
+	err := json.Unmarshal(s.key, &name)
+	if err != nil || len(s.stack) == 0 {
+		return
+	}
+
+	frame := &s.stack[len(s.stack)-1]
+	if len(s.stack) == 1 {
+		s.keys[name] = nil
+	}
+	frame.child, frame.childPath = nil, name
+	if frame.path != "" {
+		frame.childPath = frame.path + "." + name
+	}
+	if frame.node == nil {
+		return
+	}
+	if frame.node.fields == nil {
+		frame.child = frame.node.elem
+		return
+	}
+	child, ok := frame.node.field(name)
+	if !ok && s.unknown == "" {
+		s.unknown = frame.childPath
+	}
+	frame.child = child
+}
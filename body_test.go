@@ -0,0 +1,122 @@
+package rocco
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+type strictItem struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity"`
+}
+
+type strictOrder struct {
+	Customer string                     `json:"customer"`
+	Items    []strictItem               `json:"items"`
+	Labels   map[string]string          `json:"labels"`
+	Extra    json.RawMessage            `json:"extra"`
+	ByRegion map[string]strictItem      `json:"by_region"`
+	Notes    *strictItem                `json:"notes,omitempty"`
+	Skipped  string                     `json:"-"`
+	Any      any                        `json:"any"`
+	Nested   map[string]json.RawMessage `json:"nested"`
+}
+
+func scanBody(t *testing.T, body string, fields *bodyFields) *bodyScanner {
+	t.Helper()
+	// One byte per read splits every key across reads.
+	scanner := newBodyScanner(iotest.OneByteReader(strings.NewReader(body)), fields)
+	if _, err := io.Copy(io.Discard, scanner); err != nil {
+		t.Fatalf("unexpected read error: %v", err)
+	}
+	return scanner
+}
+
+func TestBodyScanner_Keys(t *testing.T) {
+	scanner := scanBody(t, `{"a":1,"b\"q":{"c":[{"d":"}"}]},"e":null}`, nil)
+	if len(scanner.keys) != 3 {
+		t.Fatalf("expected 3 top-level keys, got %v", scanner.keys)
+	}
+	for _, key := range []string{"a", `b"q`, "e"} {
+		if _, ok := scanner.keys[key]; !ok {
+			t.Errorf("expected key %q, got %v", key, scanner.keys)
+		}
+	}
+	if scanner.unknown != "" {
+		t.Errorf("expected no unknown fields without a type, got %q", scanner.unknown)
+	}
+
+	if keys := scanBody(t, `[{"a":1}]`, nil).keys; keys != nil {
+		t.Errorf("expected no keys for a non-object body, got %v", keys)
+	}
+}
+
+func TestBodyScanner_Unknown(t *testing.T) {
+	fields := bodyFieldsFor[strictOrder]()
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"known", `{"customer":"c","items":[{"sku":"a","quantity":1}],"notes":{"sku":"b"}}`, ""},
+		{"case insensitive", `{"Customer":"c","ITEMS":[{"Sku":"a"}]}`, ""},
+		{"open values", `{"labels":{"x":"y"},"extra":{"z":1},"any":{"w":2},"nested":{"v":{"u":3}}}`, ""},
+		{"top level", `{"customer":"c","cusotmer":"d"}`, "cusotmer"},
+		{"ignored field", `{"Skipped":"x"}`, "Skipped"},
+		{"slice element", `{"items":[{"sku":"a"},{"skuu":"b"}]}`, "items.skuu"},
+		{"map value", `{"by_region":{"eu":{"qty":1}}}`, "by_region.eu.qty"},
+		{"first wins", `{"a":1,"b":2}`, "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scanBody(t, tt.body, fields).unknown; got != tt.want {
+				t.Errorf("expected unknown %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHandler_Process_StreamsBody(t *testing.T) {
+	var provided []bool
+	handler := NewHandler[strictOrder, NoBody](
+		"order",
+		"POST",
+		"/orders",
+		func(req *Request[strictOrder]) (NoBody, error) {
+			provided = []bool{req.WasProvided("customer"), req.WasProvided("notes"), req.WasProvided("items")}
+			return NoBody{}, nil
+		},
+	).WithStrictJSON()
+
+	body := `{"customer":"c","notes":null}`
+	req := httptest.NewRequest("POST", "/orders", iotest.OneByteReader(strings.NewReader(body)))
+	w := httptest.NewRecorder()
+	if _, err := handler.Process(context.Background(), req, w); err != nil {
+		t.Fatalf("unexpected error: %v (%s)", err, w.Body.String())
+	}
+	if len(provided) != 3 || !provided[0] || !provided[1] || provided[2] {
+		t.Errorf("expected customer and notes to be provided, got %v", provided)
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("POST", "/orders", strings.NewReader(`{"items":[{"sku":"a","qty":1}]}`))
+	if _, err := handler.Process(context.Background(), req, w); err == nil || w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for a nested unknown field, got %d: %v", w.Code, err)
+	}
+	if !strings.Contains(w.Body.String(), `"field":"items.qty"`) {
+		t.Errorf("expected the error to name items.qty, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("POST", "/orders", iotest.ErrReader(io.ErrUnexpectedEOF))
+	if _, err := handler.Process(context.Background(), req, w); err == nil || w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a failed read, got %d: %v", w.Code, err)
+	}
+}
@@ -6,21 +6,23 @@ import "time"
 // EngineConfig holds configuration for the Engine.
 type EngineConfig struct {
 	// Server settings
-	Host         string        // Host to bind to (e.g., "localhost", "0.0.0.0", or empty for all interfaces)
-	Port         int           // Port to listen on (e.g., 8080)
-	ReadTimeout  time.Duration // Maximum duration for reading entire request
-	WriteTimeout time.Duration // Maximum duration for writing response
-	IdleTimeout  time.Duration // Maximum time to wait for next request on keep-alive
+	Host              string        // Host to bind to (e.g., "localhost", "0.0.0.0", or empty for all interfaces)
+	Port              int           // Port to listen on (e.g., 8080)
+	ReadTimeout       time.Duration // Maximum duration for reading entire request
+	ReadHeaderTimeout time.Duration // Maximum duration for reading request headers
+	WriteTimeout      time.Duration // Maximum duration for writing response
+	IdleTimeout       time.Duration // Maximum time to wait for next request on keep-alive
 }
 
 // DefaultConfig returns an EngineConfig with sensible defaults.
 func DefaultConfig() *EngineConfig {
 	return &EngineConfig{
-		Host:         "", // Empty string binds to all interfaces
-		Port:         8080,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  120 * time.Second,
+		Host:              "", // Empty string binds to all interfaces
+		Port:              8080,
+		ReadTimeout:       10 * time.Second,
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       120 * time.Second,
 	}
 }
 
@@ -17,6 +17,9 @@ func TestDefaultConfig(t *testing.T) {
 	if config.ReadTimeout != 10*time.Second {
 		t.Errorf("expected read timeout 10s, got %v", config.ReadTimeout)
 	}
+	if config.ReadHeaderTimeout != 5*time.Second {
+		t.Errorf("expected read header timeout 5s, got %v", config.ReadHeaderTimeout)
+	}
 	if config.WriteTimeout != 10*time.Second {
 		t.Errorf("expected write timeout 10s, got %v", config.WriteTimeout)
 	}
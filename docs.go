@@ -1,10 +1,20 @@
 package rocco
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"unicode"
 
+	"github.com/zoobzio/capitan"
 	"github.com/zoobzio/openapi"
 	"github.com/zoobzio/sentinel"
 )
@@ -15,7 +25,14 @@ func init() {
 	sentinel.Tag("validate")
 	// Documentation-only tags
 	sentinel.Tag("example")
+	sentinel.Tag("default")
 	sentinel.Tag("description")
+	sentinel.Tag("title")
+	sentinel.Tag("pattern")
+	sentinel.Tag("timeformat")
+	sentinel.Tag("deprecated")
+	// query: binds query parameters into WithQueryStruct types
+	sentinel.Tag("query")
 }
 
 // parseFloat64 parses a string to *float64
@@ -54,6 +71,39 @@ func parseBool(s string) *bool {
 	return &v
 }
 
+// marshalExample normalizes an example value to its JSON form, so it is documented with the
+// field names and encodings clients actually see, in both the JSON and YAML spec.
+func marshalExample(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var example any
+	if err := json.Unmarshal(data, &example); err != nil {
+		return nil, err
+	}
+	return example, nil
+}
+
+// withExample adds a whole-body example to mt. OpenAPI forbids example alongside examples,
+// so next to named examples it becomes the one named "example".
+func withExample(mt openapi.MediaType, example any) openapi.MediaType {
+	if example == nil {
+		return mt
+	}
+	if len(mt.Examples) == 0 {
+		mt.Example = example
+		return mt
+	}
+	examples := make(map[string]*openapi.Example, len(mt.Examples)+1)
+	for name, ex := range mt.Examples {
+		examples[name] = ex
+	}
+	examples["example"] = &openapi.Example{Value: example}
+	mt.Examples = examples
+	return mt
+}
+
 // parseExample parses an example value based on the schema type
 func parseExample(value string, schemaType string) any {
 	if value == "" {
@@ -205,6 +255,11 @@ func parseValidateTag(validateTag string, goType string) map[string]any {
 				// OpenAPI 3.1.0: exclusiveMaximum is the actual bound value
 				constraints["exclusiveMaximum"] = parseFloat64(param)
 			}
+		case "multipleof":
+			// Not a built-in go-playground rule; enforced by a custom validator if registered
+			if isNumeric {
+				constraints["multipleOf"] = parseFloat64(param)
+			}
 
 		// String format validations
 		case "email":
@@ -261,7 +316,21 @@ func parseValidateTag(validateTag string, goType string) map[string]any {
 		case "required":
 			// No-op: required is determined by json tag
 
-		// Pattern matching
+		// Conditional requirements can't be expressed in OpenAPI; document them instead
+		case "required_if", "required_unless", "required_with", "required_with_all",
+			"required_without", "required_without_all":
+			note := conditionalRequirement(tag, param)
+			if existing, ok := constraints["requiredWhen"].(string); ok {
+				note = existing + " " + note
+			}
+			constraints["requiredWhen"] = note
+
+		// Pattern matching via a custom regexp validator; commas and pipes are written
+		// as 0x2C and 0x7C in validate tags, as go-playground requires
+		case "regexp":
+			if param != "" {
+				constraints["pattern"] = strings.NewReplacer("0x2C", ",", "0x7C", "|").Replace(param)
+			}
 		case "contains", "startswith", "endswith":
 			// These could be mapped to pattern if we construct regex
 			// For now, skip as they're not direct OpenAPI mappings
@@ -271,6 +340,86 @@ func parseValidateTag(validateTag string, goType string) map[string]any {
 	return constraints
 }
 
+// DocTagFunc applies the value of a custom struct tag to a field's OpenAPI schema.
+type DocTagFunc func(schema *openapi.Schema, value string)
+
+// Custom documentation tags registered via RegisterDocTag.
+var (
+	docTagsMu sync.RWMutex
+	docTags   = make(map[string]DocTagFunc)
+)
+
+// RegisterDocTag registers a custom struct tag for OpenAPI generation.
+// The tag is extracted by sentinel, and apply (if non-nil) is called for every field
+// carrying it after the built-in tags are applied, so it can also override them.
+// Register tags before constructing handlers: sentinel extracts tags when types are scanned.
+func RegisterDocTag(name string, apply DocTagFunc) {
+	sentinel.Tag(name)
+
+	docTagsMu.Lock()
+	defer docTagsMu.Unlock()
+	docTags[name] = apply
+}
+
+// applyCustomDocTags runs registered DocTagFuncs for the field's tags in name order.
+func applyCustomDocTags(schema *openapi.Schema, field sentinel.FieldMetadata) {
+	docTagsMu.RLock()
+	defer docTagsMu.RUnlock()
+
+	names := make([]string, 0, len(docTags))
+	for name := range docTags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		apply := docTags[name]
+		if value := field.Tags[name]; value != "" && apply != nil {
+			apply(schema, value)
+		}
+	}
+}
+
+// conditionalRequirement describes a conditional validator rule in plain English.
+// Field names are the Go struct field names used by the validator.
+func conditionalRequirement(tag, param string) string {
+	fields := strings.Fields(param)
+	switch tag {
+	case "required_if", "required_unless":
+		conditions := make([]string, 0, len(fields)/2)
+		for i := 0; i+1 < len(fields); i += 2 {
+			conditions = append(conditions, fields[i]+" is "+fields[i+1])
+		}
+		verb := "when"
+		if tag == "required_unless" {
+			verb = "unless"
+		}
+		return "Required " + verb + " " + strings.Join(conditions, " and ") + "."
+	case "required_with", "required_without":
+		state := "present"
+		if tag == "required_without" {
+			state = "absent"
+		}
+		if len(fields) == 1 {
+			return "Required when " + fields[0] + " is " + state + "."
+		}
+		return "Required when any of " + strings.Join(fields, ", ") + " is " + state + "."
+	case "required_with_all", "required_without_all":
+		state := "present"
+		if tag == "required_without_all" {
+			state = "absent"
+		}
+		return "Required when all of " + strings.Join(fields, ", ") + " are " + state + "."
+	}
+	return ""
+}
+
+// isConditionallyRequired reports whether a validate tag makes the field required only under conditions.
+func isConditionallyRequired(validateTag string) bool {
+	_, ok := parseValidateTag(validateTag, "")["requiredWhen"]
+	return ok
+}
+
 // applyOpenAPITags extracts OpenAPI tags from field metadata and applies them to the schema
 func applyOpenAPITags(schema *openapi.Schema, field sentinel.FieldMetadata) {
 	// First, parse validate tag to extract constraints
@@ -294,6 +443,10 @@ func applyOpenAPITags(schema *openapi.Schema, field sentinel.FieldMetadata) {
 				if v, ok := value.(*float64); ok {
 					schema.ExclusiveMaximum = v
 				}
+			case "multipleOf":
+				if v, ok := value.(*float64); ok {
+					schema.MultipleOf = v
+				}
 			case "minLength":
 				if v, ok := value.(*int); ok {
 					schema.MinLength = v
@@ -318,6 +471,10 @@ func applyOpenAPITags(schema *openapi.Schema, field sentinel.FieldMetadata) {
 				if v, ok := value.(string); ok {
 					schema.Format = v
 				}
+			case "pattern":
+				if v, ok := value.(string); ok {
+					schema.Pattern = v
+				}
 			case "enum":
 				if v, ok := value.([]any); ok {
 					schema.Enum = v
@@ -326,11 +483,42 @@ func applyOpenAPITags(schema *openapi.Schema, field sentinel.FieldMetadata) {
 		}
 	}
 
+	// Reflect custom time serialization formats
+	if layout := field.Tags["timeformat"]; layout != "" && isTimeType(field.Type) {
+		schemaType, format, example := timeFormatSchema(layout)
+		schema.Type = openapi.NewSchemaType(schemaType)
+		schema.Format = format
+		schema.Example = example
+	}
+
 	// Then, apply documentation-only tags (can override validate-derived values)
 	if desc := field.Tags["description"]; desc != "" {
 		schema.Description = desc
 	}
 
+	// Document conditional requirements alongside any description
+	if validateTag := field.Tags["validate"]; validateTag != "" {
+		if note, ok := parseValidateTag(validateTag, field.Type)["requiredWhen"].(string); ok {
+			if schema.Description != "" {
+				schema.Description += " " + note
+			} else {
+				schema.Description = note
+			}
+		}
+	}
+
+	if title := field.Tags["title"]; title != "" {
+		schema.Title = title
+	}
+
+	if pattern := field.Tags["pattern"]; pattern != "" {
+		schema.Pattern = pattern
+	}
+
+	if deprecated, err := strconv.ParseBool(field.Tags["deprecated"]); err == nil && deprecated {
+		schema.Deprecated = &deprecated
+	}
+
 	if example := field.Tags["example"]; example != "" {
 		schemaType := ""
 		if schema.Type != nil {
@@ -338,13 +526,81 @@ func applyOpenAPITags(schema *openapi.Schema, field sentinel.FieldMetadata) {
 		}
 		schema.Example = parseExample(example, schemaType)
 	}
+
+	if def := field.Tags["default"]; def != "" {
+		schemaType := ""
+		if schema.Type != nil {
+			schemaType = schema.Type.String()
+		}
+		schema.Default = parseExample(def, schemaType)
+	}
+
+	// Finally, apply user-registered documentation tags
+	applyCustomDocTags(schema, field)
+}
+
+// typeDoc holds type-level documentation registered for a schema.
+type typeDoc struct {
+	title       string
+	description string
+}
+
+// Type-level documentation keyed by FQDN (matching sentinel.Metadata.FQDN).
+var (
+	typeDocsMu sync.RWMutex
+	typeDocs   = make(map[string]typeDoc)
+)
+
+// typeFQDN returns the sentinel-compatible fully qualified name for T.
+func typeFQDN[T any]() string {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if pkgPath := t.PkgPath(); pkgPath != "" {
+		return pkgPath + "." + t.Name()
+	}
+	return t.Name()
+}
+
+// RegisterSchemaDescription sets the description of T's object schema in generated OpenAPI specs.
+// Go doc comments are not available at runtime, so type-level descriptions are registered explicitly.
+func RegisterSchemaDescription[T any](description string) {
+	fqdn := typeFQDN[T]()
+
+	typeDocsMu.Lock()
+	defer typeDocsMu.Unlock()
+	doc := typeDocs[fqdn]
+	doc.description = description
+	typeDocs[fqdn] = doc
+}
+
+// RegisterSchemaTitle sets the title of T's object schema in generated OpenAPI specs.
+func RegisterSchemaTitle[T any](title string) {
+	fqdn := typeFQDN[T]()
+
+	typeDocsMu.Lock()
+	defer typeDocsMu.Unlock()
+	doc := typeDocs[fqdn]
+	doc.title = title
+	typeDocs[fqdn] = doc
+}
+
+// lookupTypeDoc returns the registered type-level documentation for a type.
+func lookupTypeDoc(fqdn string) typeDoc {
+	typeDocsMu.RLock()
+	defer typeDocsMu.RUnlock()
+	return typeDocs[fqdn]
 }
 
 // metadataToSchema converts sentinel Metadata to OpenAPI Schema
 func metadataToSchema(meta sentinel.Metadata) *openapi.Schema {
+	doc := lookupTypeDoc(meta.FQDN)
 	schema := &openapi.Schema{
-		Type:       openapi.NewSchemaType("object"),
-		Properties: make(map[string]*openapi.Schema),
+		Type:        openapi.NewSchemaType("object"),
+		Title:       doc.title,
+		Description: doc.description,
+		Properties:  make(map[string]*openapi.Schema),
 	}
 
 	var required []string
@@ -363,9 +619,15 @@ func metadataToSchema(meta sentinel.Metadata) *openapi.Schema {
 		// Apply OpenAPI tags to field schema
 		applyOpenAPITags(fieldSchema, field)
 
+		// Pointer fields may be null and are never required
+		isPointer := strings.HasPrefix(field.Type, "*")
+		if isPointer {
+			makeNullable(fieldSchema)
+		}
+
 		schema.Properties[propName] = fieldSchema
 
-		if isRequired {
+		if isRequired && !isPointer && !isConditionallyRequired(field.Tags["validate"]) {
 			required = append(required, propName)
 		}
 	}
@@ -374,6 +636,17 @@ func metadataToSchema(meta sentinel.Metadata) *openapi.Schema {
 		schema.Required = required
 	}
 
+	// Assemble a composite example from field examples so renderers show a full sample payload
+	example := make(map[string]any)
+	for propName, propSchema := range schema.Properties {
+		if propSchema.Example != nil {
+			example[propName] = propSchema.Example
+		}
+	}
+	if len(example) > 0 {
+		schema.Example = example
+	}
+
 	return schema
 }
 
@@ -405,6 +678,65 @@ func parseJSONTag(field sentinel.FieldMetadata) (name string, required bool) {
 	return name, required
 }
 
+// makeNullable allows null for schema the OpenAPI 3.1 way: "null" joins the type list,
+// and a $ref moves into anyOf alongside a null schema since $ref cannot carry a type.
+func makeNullable(schema *openapi.Schema) {
+	if schema.Ref != "" {
+		schema.AnyOf = []*openapi.Schema{
+			{Ref: schema.Ref},
+			{Type: openapi.NewSchemaType("null")},
+		}
+		schema.Ref = ""
+		return
+	}
+	if schema.Type == nil || schema.Type.IsEmpty() || schema.Type.IsNullable() {
+		return
+	}
+	schema.Type = openapi.NewSchemaTypes(append(schema.Type.Strings(), "null"))
+}
+
+// mapValueType returns the value type of a "map[K]V" type string, matching brackets so
+// composite keys such as map[[2]int]V are skipped correctly. It returns "" if malformed.
+func mapValueType(goType string) string {
+	depth := 0
+	for i := len("map"); i < len(goType); i++ {
+		switch goType[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return goType[i+1:]
+			}
+		}
+	}
+	return ""
+}
+
+// responseHeaders documents a handler's static and described success response headers.
+// Static header values are shown as examples. It returns nil when there are none.
+func responseHeaders(handlerSpec HandlerSpec) map[string]*openapi.Header {
+	if len(handlerSpec.ResponseHeaders) == 0 && len(handlerSpec.ResponseHeaderDocs) == 0 {
+		return nil
+	}
+	headers := make(map[string]*openapi.Header)
+	for name, value := range handlerSpec.ResponseHeaders {
+		headers[name] = &openapi.Header{
+			Schema:  &openapi.Schema{Type: openapi.NewSchemaType("string")},
+			Example: value,
+		}
+	}
+	for name, description := range handlerSpec.ResponseHeaderDocs {
+		header, ok := headers[name]
+		if !ok {
+			header = &openapi.Header{Schema: &openapi.Schema{Type: openapi.NewSchemaType("string")}}
+			headers[name] = header
+		}
+		header.Description = description
+	}
+	return headers
+}
+
 // goTypeToSchema converts a Go type string to an OpenAPI Schema
 func goTypeToSchema(goType string) *openapi.Schema {
 	// Handle pointers
@@ -419,12 +751,16 @@ func goTypeToSchema(goType string) *openapi.Schema {
 		}
 	}
 
-	// Handle maps
+	// Handle maps, describing the value type when it can be parsed
 	if strings.HasPrefix(goType, "map[") {
-		return &openapi.Schema{
+		schema := &openapi.Schema{
 			Type:                 openapi.NewSchemaType("object"),
 			AdditionalProperties: true,
 		}
+		if valueType := mapValueType(goType); valueType != "" {
+			schema.AdditionalProperties = goTypeToSchema(valueType)
+		}
+		return schema
 	}
 
 	// Basic type mapping
@@ -440,6 +776,8 @@ func goTypeToSchema(goType string) *openapi.Schema {
 		return &openapi.Schema{Type: openapi.NewSchemaType("boolean")}
 	case "time.Time":
 		return &openapi.Schema{Type: openapi.NewSchemaType("string"), Format: "date-time"}
+	case "rocco.FileUpload":
+		return &openapi.Schema{Type: openapi.NewSchemaType("string"), Format: "binary"}
 	default:
 		// Complex type - reference to component schema
 		// Extract just the type name (remove package prefix)
@@ -468,14 +806,22 @@ func statusCodeToResponseName(code int) string {
 		return "Forbidden"
 	case 404:
 		return "NotFound"
+	case 405:
+		return "MethodNotAllowed"
 	case 409:
 		return "Conflict"
+	case 415:
+		return "UnsupportedMediaType"
+	case 416:
+		return "RangeNotSatisfiable"
 	case 422:
 		return "UnprocessableEntity"
 	case 429:
 		return "TooManyRequests"
 	case 500:
 		return "InternalServerError"
+	case 504:
+		return "GatewayTimeout"
 	default:
 		return "InternalServerError"
 	}
@@ -494,9 +840,116 @@ func errorCodeToSchemaName(code string) string {
 	return result.String()
 }
 
+// schemaRefPrefix is the JSON pointer prefix for component schema references.
+const schemaRefPrefix = "#/components/schemas/"
+
+// schemaWarning describes a problem found while generating OpenAPI schemas.
+type schemaWarning struct {
+	handler  string // Handler name, empty for spec-wide issues
+	typeName string // Type or schema name involved
+	reason   string // Human-readable description of the problem
+}
+
+// lookupMetadata resolves cached sentinel metadata by simple type name.
+// Sentinel caches by FQDN, so the name is matched against every cached type.
+// matches reports how many cached types share the name (0 = unresolved, >1 = collision).
+func lookupMetadata(typeName string) (meta sentinel.Metadata, matches int) {
+	if found, ok := sentinel.Lookup(typeName); ok {
+		return found, 1
+	}
+
+	keys := sentinel.Browse()
+	sort.Strings(keys)
+	for _, key := range keys {
+		candidate, ok := sentinel.Lookup(key)
+		if !ok || candidate.TypeName != typeName {
+			continue
+		}
+		if matches == 0 {
+			meta = candidate
+		}
+		matches++
+	}
+	return meta, matches
+}
+
+// lookupRelationship resolves metadata for the target of a type relationship.
+func lookupRelationship(rel sentinel.TypeRelationship) (sentinel.Metadata, bool) {
+	if rel.ToPackage != "" {
+		if meta, ok := sentinel.Lookup(rel.ToPackage + "." + rel.To); ok {
+			return meta, true
+		}
+	}
+	meta, matches := lookupMetadata(rel.To)
+	return meta, matches > 0
+}
+
+// fieldStructFQDN returns the FQDN of the named struct a field refers to, looking
+// through pointers, slices, arrays and map values. It returns "" for other types and
+// for time.Time, which is documented inline.
+func fieldStructFQDN(t reflect.Type) string {
+	for t != nil {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		case reflect.Struct:
+			fqdn := t.PkgPath() + "." + t.Name()
+			if t.PkgPath() == "" || fqdn == "time.Time" {
+				return ""
+			}
+			return fqdn
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// danglingSchemaRefs returns the sorted schema names referenced in spec
+// that have no corresponding components.schemas entry.
+func danglingSchemaRefs(spec *openapi.OpenAPI) []string {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil
+	}
+
+	missing := make(map[string]bool)
+	var walk func(v any)
+	walk = func(v any) {
+		switch node := v.(type) {
+		case map[string]any:
+			if ref, ok := node["$ref"].(string); ok && strings.HasPrefix(ref, schemaRefPrefix) {
+				name := strings.TrimPrefix(ref, schemaRefPrefix)
+				if _, exists := spec.Components.Schemas[name]; !exists {
+					missing[name] = true
+				}
+			}
+			for _, child := range node {
+				walk(child)
+			}
+		case []any:
+			for _, child := range node {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+
+	names := make([]string, 0, len(missing))
+	for name := range missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // setOperationForMethod sets the operation on the correct method field of PathItem
 func setOperationForMethod(pathItem *openapi.PathItem, method string, operation *openapi.Operation) {
-	switch method {
+	switch strings.ToUpper(method) {
 	case "GET":
 		pathItem.Get = operation
 	case "POST":
@@ -556,7 +1009,336 @@ func isHandlerAccessible(handler Endpoint, identity Identity) bool {
 
 // GenerateOpenAPI creates an OpenAPI specification from registered handlers.
 // If identity is provided, only handlers accessible to that identity will be included.
-func (e *Engine) GenerateOpenAPI(identity Identity) *openapi.OpenAPI {
+//
+// Output is deterministic: operations are assigned to fixed PathItem method fields
+// (get, post, put, delete, patch, options, head - always serialized in that order)
+// and paths/components are maps, which encoding/json emits in sorted key order.
+// Handler registration order therefore does not affect the generated document.
+//
+// Problems that leave the document incomplete (unresolvable types, schema name
+// collisions, dangling $refs) are reported via SchemaGenerationWarning events.
+func (e *Engine) GenerateOpenAPI(identity Identity) *OpenAPIDocument {
+	spec, warnings := e.generateOpenAPI(identity)
+	for _, w := range warnings {
+		capitan.Warn(context.Background(), SchemaGenerationWarning,
+			HandlerNameKey.Field(w.handler),
+			TypeNameKey.Field(w.typeName),
+			ReasonKey.Field(w.reason),
+		)
+	}
+	return spec
+}
+
+// DeclaredErrors returns every error declared across the registered handlers via
+// WithErrors (and those declared implicitly, such as by WithTimeout), one per error
+// code, sorted by code. When handlers declare different errors with the same code,
+// the one from the last registered handler is returned, matching GenerateOpenAPI.
+func (e *Engine) DeclaredErrors() []ErrorDefinition {
+	byCode := make(map[string]ErrorDefinition)
+	for _, handler := range e.endpoints() {
+		for _, errDef := range handler.ErrorDefs() {
+			byCode[errDef.Code()] = errDef
+		}
+	}
+
+	codes := make([]string, 0, len(byCode))
+	for code := range byCode {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	errs := make([]ErrorDefinition, 0, len(codes))
+	for _, code := range codes {
+		errs = append(errs, byCode[code])
+	}
+	return errs
+}
+
+// OpenAPIDocument is the specification built by GenerateOpenAPI: the openapi.OpenAPI
+// document plus the vendor extensions openapi.OpenAPI has no fields for. Encoding it as
+// JSON or YAML writes each extension in place.
+type OpenAPIDocument struct {
+	*openapi.OpenAPI
+
+	// TagGroups are the engine's WithTagGroup groups, written as x-tagGroups.
+	TagGroups []TagGroup
+
+	// MaxBodySizes holds each request body's WithMaxBodySize limit in bytes, written as
+	// the body's x-max-body-size extension. Unlimited bodies have no entry.
+	MaxBodySizes map[*openapi.RequestBody]int64
+}
+
+// documentEncoding mirrors openapi.OpenAPI with the document's extensions in place.
+type documentEncoding struct {
+	Paths        map[string]pathItemEncoding    `json:"paths" yaml:"paths"`
+	Webhooks     map[string]*openapi.PathItem   `json:"webhooks,omitempty" yaml:"webhooks,omitempty"`
+	Components   *openapi.Components            `json:"components,omitempty" yaml:"components,omitempty"`
+	ExternalDocs *openapi.ExternalDocumentation `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
+	Info         openapi.Info                   `json:"info" yaml:"info"`
+	OpenAPI      string                         `json:"openapi" yaml:"openapi"`
+	Servers      []openapi.Server               `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Security     []openapi.SecurityRequirement  `json:"security,omitempty" yaml:"security,omitempty"`
+	Tags         []openapi.Tag                  `json:"tags,omitempty" yaml:"tags,omitempty"`
+	TagGroups    []TagGroup                     `json:"x-tagGroups,omitempty" yaml:"x-tagGroups,omitempty"`
+}
+
+// pathItemEncoding mirrors openapi.PathItem with operations that carry extensions.
+type pathItemEncoding struct {
+	Ref         string              `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Summary     string              `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string              `json:"description,omitempty" yaml:"description,omitempty"`
+	Get         *operationEncoding  `json:"get,omitempty" yaml:"get,omitempty"`
+	Post        *operationEncoding  `json:"post,omitempty" yaml:"post,omitempty"`
+	Put         *operationEncoding  `json:"put,omitempty" yaml:"put,omitempty"`
+	Delete      *operationEncoding  `json:"delete,omitempty" yaml:"delete,omitempty"`
+	Patch       *operationEncoding  `json:"patch,omitempty" yaml:"patch,omitempty"`
+	Options     *operationEncoding  `json:"options,omitempty" yaml:"options,omitempty"`
+	Head        *operationEncoding  `json:"head,omitempty" yaml:"head,omitempty"`
+	Servers     []openapi.Server    `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Parameters  []openapi.Parameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+}
+
+// operationEncoding mirrors openapi.Operation with a request body that carries extensions.
+type operationEncoding struct {
+	ExternalDocs *openapi.ExternalDocumentation `json:"externalDocs,omitempty" yaml:"externalDocs,omitempty"`
+	RequestBody  *requestBodyEncoding           `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses    map[string]openapi.Response    `json:"responses" yaml:"responses"`
+	Callbacks    map[string]openapi.Callback    `json:"callbacks,omitempty" yaml:"callbacks,omitempty"`
+	Summary      string                         `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description  string                         `json:"description,omitempty" yaml:"description,omitempty"`
+	OperationID  string                         `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Tags         []string                       `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Parameters   []openapi.Parameter            `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	Security     []openapi.SecurityRequirement  `json:"security,omitempty" yaml:"security,omitempty"`
+	Servers      []openapi.Server               `json:"servers,omitempty" yaml:"servers,omitempty"`
+	Deprecated   bool                           `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+}
+
+// requestBodyEncoding is an openapi.RequestBody followed by its extensions.
+type requestBodyEncoding struct {
+	*openapi.RequestBody `yaml:",inline"`
+	MaxBodySize          int64 `json:"x-max-body-size,omitempty" yaml:"x-max-body-size,omitempty"`
+}
+
+// maxBodySizeExtension is the request body extension recording WithMaxBodySize.
+const maxBodySizeExtension = "x-max-body-size"
+
+// MarshalJSON implements json.Marshaler, writing the document's extensions in place.
+func (d OpenAPIDocument) MarshalJSON() ([]byte, error) {
+	if d.OpenAPI == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.encoding())
+}
+
+// MarshalYAML implements yaml.Marshaler, writing the document's extensions in place.
+func (d OpenAPIDocument) MarshalYAML() (any, error) {
+	if d.OpenAPI == nil {
+		return nil, nil
+	}
+	return d.encoding(), nil
+}
+
+// encoding lays the document out for encoding.
+func (d OpenAPIDocument) encoding() documentEncoding {
+	spec := d.OpenAPI
+	paths := make(map[string]pathItemEncoding, len(spec.Paths))
+	for path, item := range spec.Paths {
+		paths[path] = pathItemEncoding{
+			Ref:         item.Ref,
+			Summary:     item.Summary,
+			Description: item.Description,
+			Get:         d.operationEncoding(item.Get),
+			Post:        d.operationEncoding(item.Post),
+			Put:         d.operationEncoding(item.Put),
+			Delete:      d.operationEncoding(item.Delete),
+			Patch:       d.operationEncoding(item.Patch),
+			Options:     d.operationEncoding(item.Options),
+			Head:        d.operationEncoding(item.Head),
+			Servers:     item.Servers,
+			Parameters:  item.Parameters,
+		}
+	}
+	return documentEncoding{
+		Paths:        paths,
+		Webhooks:     spec.Webhooks,
+		Components:   spec.Components,
+		ExternalDocs: spec.ExternalDocs,
+		Info:         spec.Info,
+		OpenAPI:      spec.OpenAPI,
+		Servers:      spec.Servers,
+		Security:     spec.Security,
+		Tags:         spec.Tags,
+		TagGroups:    d.TagGroups,
+	}
+}
+
+// operationEncoding lays out op with its request body's extensions.
+func (d OpenAPIDocument) operationEncoding(op *openapi.Operation) *operationEncoding {
+	if op == nil {
+		return nil
+	}
+	var body *requestBodyEncoding
+	if op.RequestBody != nil {
+		body = &requestBodyEncoding{RequestBody: op.RequestBody, MaxBodySize: d.MaxBodySizes[op.RequestBody]}
+	}
+	return &operationEncoding{
+		ExternalDocs: op.ExternalDocs,
+		RequestBody:  body,
+		Responses:    op.Responses,
+		Callbacks:    op.Callbacks,
+		Summary:      op.Summary,
+		Description:  op.Description,
+		OperationID:  op.OperationID,
+		Tags:         op.Tags,
+		Parameters:   op.Parameters,
+		Security:     op.Security,
+		Servers:      op.Servers,
+		Deprecated:   op.Deprecated,
+	}
+}
+
+// operationSummary returns the handler's summary, derived from its name when unset
+// and WithDerivedSummaries is enabled.
+func (e *Engine) operationSummary(handlerSpec HandlerSpec) string {
+	summary := handlerSpec.Summary
+	if summary == "" && e.derivedSummaries {
+		summary = summaryFromName(handlerSpec.Name)
+	}
+	if e.sanitizeDescs {
+		summary = sanitizeDescription(summary)
+	}
+	return summary
+}
+
+// operationDescription returns the handler's description, escaped if WithSanitizedDescriptions is enabled.
+func (e *Engine) operationDescription(handlerSpec HandlerSpec) string {
+	if e.sanitizeDescs {
+		return sanitizeDescription(handlerSpec.Description)
+	}
+	return handlerSpec.Description
+}
+
+// summaryFromName turns an operation name into a sentence-case summary by splitting
+// on '-', '_', and '.' and capitalizing the first word: "list-user_orders" → "List user orders".
+func summaryFromName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '-' || r == '_' || r == '.' || unicode.IsSpace(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	first := []rune(words[0])
+	first[0] = unicode.ToUpper(first[0])
+	words[0] = string(first)
+	return strings.Join(words, " ")
+}
+
+// operationTags returns the handler's tags, or the engine's default tags if it declares none.
+func (e *Engine) operationTags(handlerSpec HandlerSpec) []string {
+	if len(handlerSpec.Tags) > 0 {
+		return handlerSpec.Tags
+	}
+	return e.spec.DefaultTags
+}
+
+// ValidateSpec generates the OpenAPI specification and checks it for consistency.
+// It returns an error listing every handler type that could not be introspected or resolved,
+// every schema name collision, every $ref with no components.schemas entry, every response
+// example for an undocumented status, every operation tag not declared via WithTag, and
+// every operation documented twice, as host-scoped routes sharing a method and path are.
+// Returns nil if the spec is clean.
+func (e *Engine) ValidateSpec() error {
+	spec, warnings := e.generateOpenAPI(nil)
+
+	var problems []error
+	for _, w := range warnings {
+		if w.handler != "" {
+			problems = append(problems, fmt.Errorf("handler %q: %s: %s", w.handler, w.typeName, w.reason))
+		} else {
+			problems = append(problems, fmt.Errorf("%s: %s", w.typeName, w.reason))
+		}
+	}
+
+	declared := make(map[string]bool, len(spec.Tags))
+	for _, tag := range spec.Tags {
+		declared[tag.Name] = true
+	}
+	operations := make(map[string]string)
+	for _, handler := range e.endpoints() {
+		handlerSpec := handler.Spec()
+		// Routes for the same method and path on different hosts share one operation slot.
+		operation := handlerSpec.Method + " " + openAPIPath(handlerSpec.Path)
+		if existing, ok := operations[operation]; ok {
+			problems = append(problems, fmt.Errorf("handler %q: operation %q is already documented by handler %q", handlerSpec.Name, operation, existing))
+		} else {
+			operations[operation] = handlerSpec.Name
+		}
+		if reporter, ok := handler.(scanErrorReporter); ok {
+			for _, scanErr := range reporter.ScanErrors() {
+				problems = append(problems, fmt.Errorf("handler %q: %w", handlerSpec.Name, scanErr))
+			}
+		}
+		for _, tag := range e.operationTags(handlerSpec) {
+			if !declared[tag] {
+				problems = append(problems, fmt.Errorf("handler %q: tag %q is not documented", handlerSpec.Name, tag))
+			}
+		}
+		if name := securitySchemeName(handlerSpec); name != DefaultSecurityScheme && e.spec.SecuritySchemes[name] == nil {
+			problems = append(problems, fmt.Errorf("handler %q: security scheme %q is not registered", handlerSpec.Name, name))
+		}
+	}
+
+	for _, group := range e.spec.TagGroups {
+		for _, tag := range group.Tags {
+			if !declared[tag] {
+				problems = append(problems, fmt.Errorf("tag group %q: tag %q is not documented", group.Name, tag))
+			}
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid OpenAPI spec: %w", errors.Join(problems...))
+}
+
+// documentedSpec fills in what a custom Endpoint may leave unset, so minimal metadata
+// still documents an operation: no input type means no request body, no output type
+// means a success response without content, and the success status defaults to 200.
+func documentedSpec(spec HandlerSpec) HandlerSpec {
+	if spec.InputTypeName == "" {
+		spec.InputTypeName = noBodyTypeName
+	}
+	if spec.SuccessStatus == 0 {
+		spec.SuccessStatus = http.StatusOK
+	}
+	return spec
+}
+
+// operationServers returns the servers documented for a handler: its own, or for a
+// host-scoped route the host it answers on.
+func operationServers(spec HandlerSpec) []openapi.Server {
+	if len(spec.Servers) > 0 || spec.Host == "" {
+		return spec.Servers
+	}
+	return []openapi.Server{{URL: "https://" + spec.Host}}
+}
+
+// securitySchemeName returns the security scheme documented for a handler.
+func securitySchemeName(spec HandlerSpec) string {
+	if spec.SecurityScheme != "" {
+		return spec.SecurityScheme
+	}
+	return DefaultSecurityScheme
+}
+
+// generateOpenAPI builds the specification and collects any schema generation warnings.
+func (e *Engine) generateOpenAPI(identity Identity) (*OpenAPIDocument, []schemaWarning) {
+	var warnings []schemaWarning
+	handlers := e.endpoints()
+	maxBodySizes := make(map[*openapi.RequestBody]int64)
+
 	spec := &openapi.OpenAPI{
 		OpenAPI: "3.1.0",
 		Info:    e.spec.Info,
@@ -577,32 +1359,35 @@ func (e *Engine) GenerateOpenAPI(identity Identity) *openapi.OpenAPI {
 		spec.Security = e.spec.Security
 	}
 
-	// Check if any handlers require authentication
-	hasAuth := false
-	for _, handler := range e.handlers {
-		if handler.Spec().RequiresAuth {
-			hasAuth = true
-			break
+	// Add configured security schemes, plus the bearer default if any authenticated
+	// handler relies on it
+	if len(e.spec.SecuritySchemes) > 0 {
+		spec.Components.SecuritySchemes = make(map[string]*openapi.SecurityScheme, len(e.spec.SecuritySchemes))
+		for name, scheme := range e.spec.SecuritySchemes {
+			spec.Components.SecuritySchemes[name] = scheme
 		}
 	}
-
-	// Add bearer token security scheme if any handler requires auth
-	if hasAuth {
-		spec.Components.SecuritySchemes = make(map[string]*openapi.SecurityScheme)
-		spec.Components.SecuritySchemes["bearerAuth"] = &openapi.SecurityScheme{
+	for _, handler := range handlers {
+		handlerSpec := handler.Spec()
+		if !handlerSpec.RequiresAuth && !handlerSpec.OptionalAuth {
+			continue
+		}
+		if securitySchemeName(handlerSpec) != DefaultSecurityScheme || e.spec.SecuritySchemes[DefaultSecurityScheme] != nil {
+			continue
+		}
+		if spec.Components.SecuritySchemes == nil {
+			spec.Components.SecuritySchemes = make(map[string]*openapi.SecurityScheme)
+		}
+		spec.Components.SecuritySchemes[DefaultSecurityScheme] = &openapi.SecurityScheme{
 			Type:        "http",
 			Scheme:      "bearer",
 			Description: "Bearer token authentication",
 		}
+		break
 	}
 
 	// Collect all unique error definitions from handlers for schema generation
-	errorDefs := make(map[string]ErrorDefinition) // keyed by error code
-	for _, handler := range e.handlers {
-		for _, errDef := range handler.ErrorDefs() {
-			errorDefs[errDef.Code()] = errDef
-		}
-	}
+	errorDefs := e.DeclaredErrors()
 
 	// Add base ErrorResponse schema (used for untyped errors like 500)
 	spec.Components.Schemas["ErrorResponse"] = &openapi.Schema{
@@ -617,16 +1402,26 @@ func (e *Engine) GenerateOpenAPI(identity Identity) *openapi.OpenAPI {
 
 	// Track unique schemas to add to components
 	schemas := make(map[string]*openapi.Schema)
-	processedTypes := make(map[string]bool) // Prevent infinite recursion
+	processedTypes := make(map[string]string) // Schema name -> FQDN; prevents infinite recursion
 
 	// Helper to recursively collect schemas for a type and its relationships
 	var collectSchemas func(meta sentinel.Metadata)
 	collectSchemas = func(meta sentinel.Metadata) {
+		// Uploads are documented inline as binary strings, not as a component.
+		if meta.FQDN == fileUploadFQDN {
+			return
+		}
 		typeName := meta.TypeName
-		if processedTypes[typeName] {
+		if fqdn, seen := processedTypes[typeName]; seen {
+			if fqdn != meta.FQDN {
+				warnings = append(warnings, schemaWarning{
+					typeName: typeName,
+					reason:   fmt.Sprintf("schema name collision between %s and %s", fqdn, meta.FQDN),
+				})
+			}
 			return
 		}
-		processedTypes[typeName] = true
+		processedTypes[typeName] = meta.FQDN
 
 		// Add this type's schema
 		schemas[typeName] = metadataToSchema(meta)
@@ -634,14 +1429,46 @@ func (e *Engine) GenerateOpenAPI(identity Identity) *openapi.OpenAPI {
 		// Process all related types
 		for _, rel := range meta.Relationships {
 			// Lookup related type metadata
-			if relMeta, found := sentinel.Lookup(rel.To); found {
+			if relMeta, found := lookupRelationship(rel); found {
 				collectSchemas(relMeta)
 			}
 		}
+
+		// Sentinel only records relationships within a package, so also follow each
+		// field's struct type to define every schema the properties $ref
+		for _, field := range meta.Fields {
+			if fqdn := fieldStructFQDN(field.ReflectType); fqdn != "" {
+				if fieldMeta, found := sentinel.Lookup(fqdn); found {
+					collectSchemas(fieldMeta)
+				}
+			}
+		}
+	}
+
+	// Helper to resolve a handler's input/output type by name and collect its schemas
+	collectHandlerType := func(handlerName, typeName string) {
+		meta, matches := lookupMetadata(typeName)
+		switch {
+		case matches == 0:
+			warnings = append(warnings, schemaWarning{
+				handler:  handlerName,
+				typeName: typeName,
+				reason:   "type metadata not found",
+			})
+			return
+		case matches > 1:
+			warnings = append(warnings, schemaWarning{
+				handler:  handlerName,
+				typeName: typeName,
+				reason:   fmt.Sprintf("type name matches %d registered types", matches),
+			})
+		}
+		collectSchemas(meta)
 	}
 
 	// Generate typed error response schemas from collected error definitions
-	for code, errDef := range errorDefs {
+	for _, errDef := range errorDefs {
+		code := errDef.Code()
 		detailsMeta := errDef.DetailsMeta()
 		schemaName := errorCodeToSchemaName(code) + "ErrorResponse"
 
@@ -669,16 +1496,17 @@ func (e *Engine) GenerateOpenAPI(identity Identity) *openapi.OpenAPI {
 	}
 
 	// Iterate over registered handlers
-	for _, handler := range e.handlers {
+	for _, handler := range handlers {
 		// Filter handlers based on identity permissions if provided
 		if identity != nil && !isHandlerAccessible(handler, identity) {
 			continue
 		}
 
-		handlerSpec := handler.Spec()
+		handlerSpec := documentedSpec(handler.Spec())
 
 		// Get or create PathItem
-		pathItem, exists := spec.Paths[handlerSpec.Path]
+		path := openAPIPath(handlerSpec.Path)
+		pathItem, exists := spec.Paths[path]
 		if !exists {
 			pathItem = openapi.PathItem{}
 		}
@@ -686,78 +1514,219 @@ func (e *Engine) GenerateOpenAPI(identity Identity) *openapi.OpenAPI {
 		// Build operation
 		operation := &openapi.Operation{
 			OperationID: handlerSpec.Name,
-			Summary:     handlerSpec.Summary,
-			Description: handlerSpec.Description,
-			Tags:        handlerSpec.Tags,
+			Summary:     e.operationSummary(handlerSpec),
+			Description: e.operationDescription(handlerSpec),
+			Tags:        e.operationTags(handlerSpec),
+			Servers:     operationServers(handlerSpec),
+			Deprecated:  handlerSpec.Deprecated,
 			Responses:   make(map[string]openapi.Response),
 		}
 
 		// Add path parameters
+		catchAll := catchAllParam(handlerSpec.Path)
 		for _, paramName := range handlerSpec.PathParams {
+			var description string
+			if paramName == catchAll {
+				description = "Remainder of the path; may be empty or contain slashes"
+			}
+			var explode *bool
+			if value, ok := handlerSpec.PathParamExplode[paramName]; ok {
+				explode = &value
+			}
 			operation.Parameters = append(operation.Parameters, openapi.Parameter{
-				Name:     paramName,
-				In:       "path",
-				Required: true,
-				Schema:   &openapi.Schema{Type: openapi.NewSchemaType("string")},
+				Name:        paramName,
+				In:          "path",
+				Description: description,
+				Required:    true,
+				Style:       handlerSpec.PathParamStyles[paramName],
+				Explode:     explode,
+				Schema: &openapi.Schema{
+					Type:    openapi.NewSchemaType("string"),
+					Pattern: handlerSpec.PathParamPatterns[paramName],
+				},
 			})
 		}
 
 		// Add query parameters
 		for _, paramName := range handlerSpec.QueryParams {
+			// Repeatable parameters are documented as arrays below
+			if slices.Contains(handlerSpec.MultiQueryParams, paramName) {
+				continue
+			}
+			// Parameters bound by WithQueryStruct carry typed schemas
+			schema := handlerSpec.QueryParamSchemas[paramName]
+			if schema == nil {
+				schema = &openapi.Schema{Type: openapi.NewSchemaType("string")}
+			}
+			operation.Parameters = append(operation.Parameters, openapi.Parameter{
+				Name:        paramName,
+				In:          "query",
+				Description: schema.Description,
+				Required:    slices.Contains(handlerSpec.RequiredQueryParams, paramName),
+				Schema:      schema,
+			})
+		}
+
+		// Add repeatable query parameters as exploded arrays (?id=1&id=2)
+		for _, paramName := range handlerSpec.MultiQueryParams {
+			explode := true
+			operation.Parameters = append(operation.Parameters, openapi.Parameter{
+				Name:    paramName,
+				In:      "query",
+				Style:   "form",
+				Explode: &explode,
+				Schema: &openapi.Schema{
+					Type:  openapi.NewSchemaType("array"),
+					Items: &openapi.Schema{Type: openapi.NewSchemaType("string")},
+				},
+			})
+		}
+
+		// Add header parameters
+		for _, headerName := range handlerSpec.HeaderParams {
 			operation.Parameters = append(operation.Parameters, openapi.Parameter{
-				Name:     paramName,
-				In:       "query",
-				Required: false,
+				Name:     headerName,
+				In:       "header",
+				Required: slices.Contains(handlerSpec.RequiredHeaderParams, headerName),
 				Schema:   &openapi.Schema{Type: openapi.NewSchemaType("string")},
 			})
 		}
 
+		// Add cookie parameters
+		for _, cookieName := range handlerSpec.CookieParams {
+			operation.Parameters = append(operation.Parameters, openapi.Parameter{
+				Name:   cookieName,
+				In:     "cookie",
+				Schema: &openapi.Schema{Type: openapi.NewSchemaType("string")},
+			})
+		}
+
+		// Add sparse fieldset parameter
+		if handlerSpec.SparseFields {
+			operation.Parameters = append(operation.Parameters, openapi.Parameter{
+				Name:        sparseFieldsParam,
+				In:          "query",
+				Required:    false,
+				Description: "Comma-separated list of top-level fields to include in the response",
+				Schema:      &openapi.Schema{Type: openapi.NewSchemaType("string")},
+			})
+		}
+
 		// Add request body if not NoBody
 		if handlerSpec.InputTypeName != "NoBody" {
 			// Recursively collect input type and all nested types
-			if inputMeta, found := sentinel.Lookup(handlerSpec.InputTypeName); found {
-				collectSchemas(inputMeta)
+			collectHandlerType(handlerSpec.Name, handlerSpec.InputTypeName)
+
+			mediaType := handlerSpec.RequestMediaType
+			if mediaType == "" {
+				mediaType = "application/json"
+			}
+
+			// A JSON Schema supplied via WithJSONSchema is the contract clients are validated against.
+			bodySchema := &openapi.Schema{Ref: "#/components/schemas/" + handlerSpec.InputTypeName}
+			if handlerSpec.RequestSchema != nil {
+				bodySchema = handlerSpec.RequestSchema
 			}
 
 			operation.RequestBody = &openapi.RequestBody{
 				Required: true,
 				Content: map[string]openapi.MediaType{
-					"application/json": {
-						Schema: &openapi.Schema{Ref: "#/components/schemas/" + handlerSpec.InputTypeName},
-					},
+					mediaType: withExample(openapi.MediaType{
+						Schema:   bodySchema,
+						Examples: handlerSpec.RequestExamples,
+					}, handlerSpec.RequestExample),
 				},
 			}
+			// Keyed by the body itself, so the limit always belongs to the documented handler.
+			if handlerSpec.MaxBodySize > 0 {
+				maxBodySizes[operation.RequestBody] = handlerSpec.MaxBodySize
+			}
 		}
 
 		// Add success response
 		// Recursively collect output type and all nested types
-		if outputMeta, found := sentinel.Lookup(handlerSpec.OutputTypeName); found {
-			collectSchemas(outputMeta)
+		if handlerSpec.OutputTypeName != "" {
+			collectHandlerType(handlerSpec.Name, handlerSpec.OutputTypeName)
 		}
 
-		if handlerSpec.IsStream {
-			// SSE stream response
+		if handlerSpec.IsStream && slices.Contains(handlerSpec.ResponseContentTypes, ndjsonContentType) {
+			// NDJSON stream response: each line is one output record
+			var recordSchema *openapi.Schema
+			if handlerSpec.OutputTypeName != "" {
+				recordSchema = &openapi.Schema{Ref: "#/components/schemas/" + handlerSpec.OutputTypeName}
+			}
+			operation.Responses[fmt.Sprintf("%d", handlerSpec.SuccessStatus)] = openapi.Response{
+				Description: "Newline-delimited JSON stream, one record per line",
+				Content: map[string]openapi.MediaType{
+					ndjsonContentType: {Schema: recordSchema},
+				},
+			}
+		} else if handlerSpec.IsStream {
+			// SSE stream response; text-encoded streams have no output schema type
+			eventsDescription := fmt.Sprintf("SSE stream emitting %s events as JSON", handlerSpec.OutputTypeName)
+			if handlerSpec.OutputTypeName == "" {
+				eventsDescription = "SSE stream emitting text events"
+			}
 			operation.Responses[fmt.Sprintf("%d", handlerSpec.SuccessStatus)] = openapi.Response{
 				Description: "Server-Sent Events stream",
 				Content: map[string]openapi.MediaType{
 					"text/event-stream": {
 						Schema: &openapi.Schema{
 							Type:        openapi.NewSchemaType("string"),
-							Description: fmt.Sprintf("SSE stream emitting %s events as JSON", handlerSpec.OutputTypeName),
+							Description: eventsDescription,
 						},
 					},
 				},
 			}
 		} else {
-			// Standard JSON response
-			operation.Responses[fmt.Sprintf("%d", handlerSpec.SuccessStatus)] = openapi.Response{
+			success := openapi.Response{
 				Description: "Success",
-				Content: map[string]openapi.MediaType{
+				Headers:     responseHeaders(handlerSpec),
+			}
+			// Non-JSON content, or the standard JSON response plus any negotiable encodings
+			if len(handlerSpec.ResponseContentTypes) > 0 {
+				success.Content = make(map[string]openapi.MediaType, len(handlerSpec.ResponseContentTypes))
+				for _, mediaType := range handlerSpec.ResponseContentTypes {
+					success.Content[mediaType] = openapi.MediaType{
+						Schema: &openapi.Schema{Type: openapi.NewSchemaType("string"), Format: "binary"},
+					}
+				}
+			} else if handlerSpec.OutputTypeName != "" {
+				outputSchema := &openapi.Schema{Ref: "#/components/schemas/" + handlerSpec.OutputTypeName}
+				if e.responseEnvelope != nil {
+					outputSchema = &openapi.Schema{
+						Type:       openapi.NewSchemaType("object"),
+						Properties: map[string]*openapi.Schema{"data": outputSchema},
+						Required:   []string{"data"},
+					}
+				}
+				success.Content = map[string]openapi.MediaType{
 					"application/json": {
-						Schema: &openapi.Schema{Ref: "#/components/schemas/" + handlerSpec.OutputTypeName},
+						Schema:   outputSchema,
+						Examples: handlerSpec.ResponseExamples,
 					},
-				},
+				}
+				for _, enc := range e.encoders {
+					if _, exists := success.Content[enc.mediaType]; !exists {
+						success.Content[enc.mediaType] = openapi.MediaType{Schema: outputSchema}
+					}
+				}
+			}
+			if handlerSpec.LastModified {
+				if success.Headers == nil {
+					success.Headers = make(map[string]*openapi.Header)
+				}
+				success.Headers["Last-Modified"] = &openapi.Header{
+					Description: "Time the resource was last modified",
+					Schema:      &openapi.Schema{Type: openapi.NewSchemaType("string")},
+				}
+				if handlerSpec.Method == "GET" || handlerSpec.Method == "HEAD" {
+					operation.Responses["304"] = openapi.Response{
+						Description: "Not Modified",
+					}
+				}
 			}
+			operation.Responses[fmt.Sprintf("%d", handlerSpec.SuccessStatus)] = success
 		}
 
 		// Add error responses from handler's declared error definitions
@@ -776,13 +1745,13 @@ func (e *Engine) GenerateOpenAPI(identity Identity) *openapi.OpenAPI {
 		// Add security requirements if handler requires authentication
 		if handlerSpec.RequiresAuth {
 			// Collect all required scopes (flattened from all groups)
-			var allScopes []string
+			allScopes := []string{}
 			for _, scopeGroup := range handlerSpec.ScopeGroups {
 				allScopes = append(allScopes, scopeGroup...)
 			}
 
 			operation.Security = append(operation.Security, openapi.SecurityRequirement{
-				"bearerAuth": allScopes, // Scopes for OAuth2/bearer tokens
+				securitySchemeName(handlerSpec): allScopes, // Scopes for OAuth2/bearer tokens
 			})
 
 			// Add 401 Unauthorized error response
@@ -808,11 +1777,34 @@ func (e *Engine) GenerateOpenAPI(identity Identity) *openapi.OpenAPI {
 			}
 		}
 
+		// Optional authentication: bearer token or anonymous
+		if !handlerSpec.RequiresAuth && handlerSpec.OptionalAuth {
+			operation.Security = []openapi.SecurityRequirement{
+				{securitySchemeName(handlerSpec): []string{}},
+				{},
+			}
+		}
+
+		// Attach whole-body response examples to the JSON content of their status only
+		for status, example := range handlerSpec.ResponseExamplesByStatus {
+			response, ok := operation.Responses[fmt.Sprintf("%d", status)]
+			if !ok || response.Content["application/json"].Schema == nil {
+				warnings = append(warnings, schemaWarning{
+					handler:  handlerSpec.Name,
+					typeName: "response example",
+					reason:   fmt.Sprintf("status %d has no documented JSON response", status),
+				})
+				continue
+			}
+			response.Content["application/json"] = withExample(response.Content["application/json"], example)
+			operation.Responses[fmt.Sprintf("%d", status)] = response
+		}
+
 		// Set operation on path item
 		setOperationForMethod(&pathItem, handlerSpec.Method, operation)
 
 		// Update paths
-		spec.Paths[handlerSpec.Path] = pathItem
+		spec.Paths[path] = pathItem
 	}
 
 	// Add collected schemas to components
@@ -820,5 +1812,12 @@ func (e *Engine) GenerateOpenAPI(identity Identity) *openapi.OpenAPI {
 		spec.Components.Schemas[name] = schema
 	}
 
-	return spec
+	for _, name := range danglingSchemaRefs(spec) {
+		warnings = append(warnings, schemaWarning{
+			typeName: name,
+			reason:   "dangling $ref: schema not found in components",
+		})
+	}
+
+	return &OpenAPIDocument{OpenAPI: spec, TagGroups: e.spec.TagGroups, MaxBodySizes: maxBodySizes}, warnings
 }
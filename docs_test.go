@@ -1,10 +1,17 @@
 package rocco
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/zoobzio/openapi"
 	"github.com/zoobzio/sentinel"
+	"gopkg.in/yaml.v3"
 )
 
 func TestMetadataToSchema(t *testing.T) {
@@ -48,6 +55,48 @@ func TestMetadataToSchema(t *testing.T) {
 	}
 }
 
+func TestMetadataToSchema_PointerFields(t *testing.T) {
+	meta := sentinel.Metadata{
+		TypeName: "TestModel",
+		Fields: []sentinel.FieldMetadata{
+			{Name: "Name", Type: "string", Tags: map[string]string{"json": "name"}},
+			{Name: "Nickname", Type: "*string", Tags: map[string]string{"json": "nickname"}},
+			{Name: "Age", Type: "*int", Tags: map[string]string{"json": "age", "description": "Age in years"}},
+			{Name: "Address", Type: "*rocco.Address", Tags: map[string]string{"json": "address"}},
+		},
+	}
+
+	schema := metadataToSchema(meta)
+
+	if got := schema.Properties["name"].Type.Strings(); len(got) != 1 || got[0] != "string" {
+		t.Errorf("expected name type 'string', got %v", got)
+	}
+	if got := schema.Properties["nickname"].Type.Strings(); len(got) != 2 || got[0] != "string" || got[1] != "null" {
+		t.Errorf("expected nickname type [string null], got %v", got)
+	}
+	age := schema.Properties["age"]
+	if got := age.Type.Strings(); len(got) != 2 || got[0] != "integer" || got[1] != "null" {
+		t.Errorf("expected age type [integer null], got %v", got)
+	}
+	if age.Description != "Age in years" {
+		t.Errorf("expected age description to survive, got %q", age.Description)
+	}
+
+	address := schema.Properties["address"]
+	if address.Ref != "" {
+		t.Errorf("expected address $ref to move into anyOf, got %q", address.Ref)
+	}
+	if len(address.AnyOf) != 2 ||
+		address.AnyOf[0].Ref != "#/components/schemas/Address" ||
+		address.AnyOf[1].Type == nil || address.AnyOf[1].Type.String() != "null" {
+		t.Errorf("expected anyOf [$ref Address, null], got %+v", address.AnyOf)
+	}
+
+	if len(schema.Required) != 1 || schema.Required[0] != "name" {
+		t.Errorf("expected required fields ['name'], got %v", schema.Required)
+	}
+}
+
 func TestParseJSONTag(t *testing.T) {
 	tests := []struct {
 		field    sentinel.FieldMetadata
@@ -140,6 +189,77 @@ func TestGoTypeToSchema(t *testing.T) {
 	}
 }
 
+func TestGoTypeToSchema_MapValues(t *testing.T) {
+	tests := []struct {
+		goType   string
+		wantType string
+		wantRef  string
+	}{
+		{"map[string]string", "string", ""},
+		{"map[string]int", "integer", ""},
+		{"map[string]rocco.Money", "", "#/components/schemas/Money"},
+		{"map[string]*rocco.Money", "", "#/components/schemas/Money"},
+		{"map[int]bool", "boolean", ""},
+		{"map[[2]int]string", "string", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goType, func(t *testing.T) {
+			schema := goTypeToSchema(tt.goType)
+			if schema.Type == nil || schema.Type.String() != "object" {
+				t.Fatalf("expected type 'object', got %v", schema.Type)
+			}
+			value, ok := schema.AdditionalProperties.(*openapi.Schema)
+			if !ok {
+				t.Fatalf("expected additionalProperties schema, got %#v", schema.AdditionalProperties)
+			}
+			valueType := ""
+			if value.Type != nil {
+				valueType = value.Type.String()
+			}
+			if valueType != tt.wantType || value.Ref != tt.wantRef {
+				t.Errorf("expected value type %q ref %q, got type %q ref %q", tt.wantType, tt.wantRef, valueType, value.Ref)
+			}
+		})
+	}
+
+	nested := goTypeToSchema("map[string]map[string]int")
+	inner, ok := nested.AdditionalProperties.(*openapi.Schema)
+	if !ok {
+		t.Fatalf("expected nested map value schema, got %#v", nested.AdditionalProperties)
+	}
+	if leaf, ok := inner.AdditionalProperties.(*openapi.Schema); !ok || leaf.Type.String() != "integer" {
+		t.Errorf("expected nested map of integers, got %#v", inner.AdditionalProperties)
+	}
+}
+
+func TestFieldStructFQDN(t *testing.T) {
+	type nested struct{ Name string }
+	fqdn := "github.com/zoobzio/rocco.nested"
+
+	tests := []struct {
+		name string
+		typ  reflect.Type
+		want string
+	}{
+		{"struct", reflect.TypeOf(nested{}), fqdn},
+		{"pointer", reflect.TypeOf(&nested{}), fqdn},
+		{"slice of pointers", reflect.TypeOf([]*nested{}), fqdn},
+		{"map values", reflect.TypeOf(map[string][]nested{}), fqdn},
+		{"scalar", reflect.TypeOf(""), ""},
+		{"time", reflect.TypeOf(time.Time{}), ""},
+		{"anonymous struct", reflect.TypeOf(struct{ A int }{}), ""},
+		{"nil", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fieldStructFQDN(tt.typ); got != tt.want {
+				t.Errorf("fieldStructFQDN() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGoTypeToSchema_ComplexType(t *testing.T) {
 	schema := goTypeToSchema("github.com/user/pkg.CustomType")
 
@@ -248,8 +368,8 @@ func TestGenerateOpenAPI(t *testing.T) {
 	spec := engine.GenerateOpenAPI(nil)
 
 	// Check spec structure
-	if spec.OpenAPI != "3.1.0" {
-		t.Errorf("expected OpenAPI version '3.1.0', got %q", spec.OpenAPI)
+	if spec.OpenAPI.OpenAPI != "3.1.0" {
+		t.Errorf("expected OpenAPI version '3.1.0', got %q", spec.OpenAPI.OpenAPI)
 	}
 	if spec.Info.Title != "Test API" {
 		t.Errorf("expected title 'Test API', got %q", spec.Info.Title)
@@ -356,6 +476,244 @@ func TestGenerateOpenAPI_PathParams(t *testing.T) {
 	}
 }
 
+func TestGenerateOpenAPI_PathParamStyle(t *testing.T) {
+	engine := newTestEngine()
+
+	handler := NewHandler[NoBody, testOutput](
+		"get-cells",
+		"GET",
+		"/grid/{coords}/{id}",
+		func(*Request[NoBody]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	).WithPathParams("coords", "id").
+		WithPathParamStyle("coords", "matrix").
+		WithPathParamExplode("coords", true).
+		WithPathParamStyle("id", "form")
+
+	if errs := handler.ScanErrors(); len(errs) != 1 || !strings.Contains(errs[0].Error(), `"form"`) {
+		t.Errorf("expected one unsupported style error, got %v", errs)
+	}
+
+	engine.WithHandlers(handler)
+	styles := make(map[string]string)
+	explode := make(map[string]*bool)
+	for _, param := range engine.GenerateOpenAPI(nil).Paths["/grid/{coords}/{id}"].Get.Parameters {
+		styles[param.Name] = param.Style
+		explode[param.Name] = param.Explode
+	}
+	if styles["coords"] != "matrix" {
+		t.Errorf("expected coords style 'matrix', got %q", styles["coords"])
+	}
+	if styles["id"] != "" {
+		t.Errorf("expected id to keep the default style, got %q", styles["id"])
+	}
+	if explode["coords"] == nil || !*explode["coords"] {
+		t.Errorf("expected coords to be exploded, got %v", explode["coords"])
+	}
+	if explode["id"] != nil {
+		t.Errorf("expected id to omit explode, got %v", *explode["id"])
+	}
+}
+
+func TestGenerateOpenAPI_PathParamPattern(t *testing.T) {
+	engine := newTestEngine()
+
+	handler := newRouteHandler("get-user", "GET", "/users/{id}").
+		WithPathParams("id").
+		WithPathParamPattern("id", "[0-9]+").
+		WithPathParamPattern("slug", "[a-z")
+
+	if errs := handler.ScanErrors(); len(errs) != 1 || !strings.Contains(errs[0].Error(), "slug") {
+		t.Errorf("expected one invalid pattern error, got %v", errs)
+	}
+
+	engine.WithHandlers(handler)
+	params := engine.GenerateOpenAPI(nil).Paths["/users/{id}"].Get.Parameters
+	if len(params) != 1 || params[0].Schema.Pattern != "^(?:[0-9]+)$" {
+		t.Errorf("expected the anchored pattern on the id schema, got %+v", params)
+	}
+}
+
+func TestGenerateOpenAPI_MultiQueryParams(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(newRouteHandler("list-items", "GET", "/items").
+		WithQueryParams("page", "id").
+		WithQueryParamsMulti("id"))
+
+	params := make(map[string]openapi.Parameter)
+	for _, param := range engine.GenerateOpenAPI(nil).Paths["/items"].Get.Parameters {
+		if _, dup := params[param.Name]; dup {
+			t.Errorf("parameter %q documented twice", param.Name)
+		}
+		params[param.Name] = param
+	}
+	id := params["id"]
+	if id.Schema == nil || !id.Schema.Type.Contains("array") || id.Schema.Items == nil {
+		t.Fatalf("expected id documented as an array, got %+v", id.Schema)
+	}
+	if id.Explode == nil || !*id.Explode || id.Style != "form" {
+		t.Errorf("expected exploded form style, got style %q explode %v", id.Style, id.Explode)
+	}
+	if page := params["page"]; page.Schema == nil || !page.Schema.Type.Contains("string") {
+		t.Errorf("expected page to stay a string, got %+v", page.Schema)
+	}
+}
+
+func TestGenerateOpenAPI_ResponseHeaders(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(NewHandler[NoBody, testOutput]("get-item", "GET", "/item", func(*Request[NoBody]) (testOutput, error) {
+		return testOutput{}, nil
+	}).
+		WithResponseHeaders(map[string]string{"Cache-Control": "no-store"}).
+		WithResponseHeaderDocs("Cache-Control", "Responses must not be cached").
+		WithResponseHeaderDocs("X-RateLimit-Remaining", "Requests left in the current window").
+		WithLastModified(func(testOutput) time.Time { return time.Time{} }))
+
+	headers := engine.GenerateOpenAPI(nil).Paths["/item"].Get.Responses["200"].Headers
+	if len(headers) != 3 {
+		t.Fatalf("expected 3 documented headers, got %d", len(headers))
+	}
+	cache := headers["Cache-Control"]
+	if cache == nil || cache.Example != "no-store" || cache.Description != "Responses must not be cached" {
+		t.Errorf("expected described static Cache-Control header, got %+v", cache)
+	}
+	limit := headers["X-RateLimit-Remaining"]
+	if limit == nil || limit.Description != "Requests left in the current window" || limit.Schema.Type.String() != "string" {
+		t.Errorf("expected documented dynamic header, got %+v", limit)
+	}
+	if headers["Last-Modified"] == nil {
+		t.Error("expected Last-Modified to be kept alongside declared headers")
+	}
+}
+
+func TestEngine_DeclaredErrors(t *testing.T) {
+	engine := newTestEngine()
+	if errs := engine.DeclaredErrors(); len(errs) != 0 {
+		t.Fatalf("expected no declared errors, got %d", len(errs))
+	}
+
+	engine.WithHandlers(
+		NewHandler[NoBody, testOutput]("get-user", "GET", "/users/{id}", func(*Request[NoBody]) (testOutput, error) {
+			return testOutput{}, nil
+		}).WithErrors(ErrNotFound, ErrForbidden),
+		NewHandler[testInput, testOutput]("create-user", "POST", "/users", func(*Request[testInput]) (testOutput, error) {
+			return testOutput{}, nil
+		}).WithErrors(ErrConflict, ErrNotFound).WithTimeout(time.Second),
+	)
+
+	var codes []string
+	for _, errDef := range engine.DeclaredErrors() {
+		codes = append(codes, errDef.Code())
+	}
+	want := []string{"CONFLICT", "FORBIDDEN", "GATEWAY_TIMEOUT", "NOT_FOUND"}
+	if strings.Join(codes, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, codes)
+	}
+}
+
+func TestOpenAPIDocument_MaxBodySize(t *testing.T) {
+	engine := newTestEngine()
+	newCreate := func(name, path string) *Handler[testInput, testOutput] {
+		return NewHandler[testInput, testOutput](name, "POST", path, func(*Request[testInput]) (testOutput, error) {
+			return testOutput{}, nil
+		})
+	}
+	engine.WithHandlers(
+		newCreate("default-limit", "/default"),
+		newCreate("small-limit", "/small").WithMaxBodySize(1024),
+		newCreate("no-limit", "/unlimited").WithMaxBodySize(0),
+	)
+	jsonSpec, yamlSpec := engine.cachedOpenAPI(context.Background())
+
+	type document struct {
+		Paths map[string]struct {
+			Post struct {
+				RequestBody map[string]any `json:"requestBody" yaml:"requestBody"`
+			} `json:"post" yaml:"post"`
+		} `json:"paths" yaml:"paths"`
+	}
+	var fromJSON, fromYAML document
+	if err := json.Unmarshal(jsonSpec, &fromJSON); err != nil {
+		t.Fatalf("invalid JSON spec: %v", err)
+	}
+	if err := yaml.Unmarshal(yamlSpec, &fromYAML); err != nil {
+		t.Fatalf("invalid YAML spec: %v", err)
+	}
+
+	tests := map[string]any{
+		"/default":   10485760,
+		"/small":     1024,
+		"/unlimited": nil,
+	}
+	for path, want := range tests {
+		jsonWant := want
+		if size, ok := want.(int); ok {
+			jsonWant = float64(size)
+		}
+		if got := fromJSON.Paths[path].Post.RequestBody[maxBodySizeExtension]; got != jsonWant {
+			t.Errorf("%s: expected JSON %s %v, got %v", path, maxBodySizeExtension, want, got)
+		}
+		if got := fromYAML.Paths[path].Post.RequestBody[maxBodySizeExtension]; got != want {
+			t.Errorf("%s: expected YAML %s %v, got %v", path, maxBodySizeExtension, want, got)
+		}
+		if fromJSON.Paths[path].Post.RequestBody["content"] == nil {
+			t.Errorf("%s: expected the rest of the request body to be kept", path)
+		}
+	}
+
+	// The extension is added after the generated members, which keep their order.
+	if !bytes.Contains(jsonSpec, []byte(`"required": true,
+          "x-max-body-size": 1024`)) {
+		t.Errorf("expected x-max-body-size at the end of the request body, got %s", jsonSpec)
+	}
+}
+
+func TestGenerateOpenAPI_MaxBodySize(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(
+		NewHandler[testInput, testOutput]("create-item", "POST", "/items", func(*Request[testInput]) (testOutput, error) {
+			return testOutput{}, nil
+		}).WithMaxBodySize(1024),
+	)
+
+	spec := engine.GenerateOpenAPI(nil)
+	body := spec.Paths["/items"].Post.RequestBody
+	if got := spec.MaxBodySizes[body]; got != 1024 {
+		t.Errorf("expected a 1024 byte limit on the request body, got %d", got)
+	}
+
+	// The returned document encodes the extension itself.
+	data, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte(`"required":true,"x-max-body-size":1024}`)) {
+		t.Errorf("expected x-max-body-size in the encoded document, got %s", data)
+	}
+}
+
+func TestGenerateOpenAPI_MaxBodySize_HostScoped(t *testing.T) {
+	engine := newTestEngine()
+	newCreate := func(name, host string, size int64) *Handler[testInput, testOutput] {
+		return NewHandler[testInput, testOutput](name, "POST", "/items", func(*Request[testInput]) (testOutput, error) {
+			return testOutput{}, nil
+		}).WithHost(host).WithMaxBodySize(size)
+	}
+	engine.WithHandlers(
+		newCreate("create-item-a", "a.example.com", 1024),
+		newCreate("create-item-b", "b.example.com", 2048),
+	)
+
+	// Both routes share one operation slot; its limit must be the documented handler's.
+	spec := engine.GenerateOpenAPI(nil)
+	operation := spec.Paths["/items"].Post
+	want := map[string]int64{"create-item-a": 1024, "create-item-b": 2048}[operation.OperationID]
+	if got := spec.MaxBodySizes[operation.RequestBody]; got != want {
+		t.Errorf("expected %s's limit %d, got %d", operation.OperationID, want, got)
+	}
+}
+
 func TestGenerateOpenAPI_QueryParams(t *testing.T) {
 	engine := newTestEngine()
 
@@ -485,10 +843,62 @@ func TestApplyOpenAPITags_Example(t *testing.T) {
 	}
 }
 
+func TestApplyOpenAPITags_Default(t *testing.T) {
+	tests := []struct {
+		name       string
+		schemaType string
+		value      string
+		want       any
+	}{
+		{"string", "string", "asc", "asc"},
+		{"integer", "integer", "20", 20},
+		{"number", "number", "0.5", 0.5},
+		{"boolean", "boolean", "false", false},
+		{"array", "array", "a, b", []any{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := sentinel.FieldMetadata{
+				Name: "Field",
+				Type: tt.schemaType,
+				Tags: map[string]string{"default": tt.value},
+			}
+
+			schema := &openapi.Schema{Type: openapi.NewSchemaType(tt.schemaType)}
+			applyOpenAPITags(schema, field)
+
+			if !reflect.DeepEqual(schema.Default, tt.want) {
+				t.Errorf("expected default %#v, got %#v", tt.want, schema.Default)
+			}
+		})
+	}
+}
+
 func TestApplyOpenAPITags_Pattern(t *testing.T) {
-	// Note: pattern validation is not supported via validate tags
-	// This test is kept for backward compatibility with custom tags if needed
-	t.Skip("Pattern validation is not extracted from validate tags")
+	tests := []struct {
+		name string
+		tags map[string]string
+		want string
+	}{
+		{"regexp validator", map[string]string{"validate": "required,regexp=^[A-Z]{3}$"}, "^[A-Z]{3}$"},
+		{"escaped comma and pipe", map[string]string{"validate": "regexp=^(a0x7Cb){10x2C3}$"}, "^(a|b){1,3}$"},
+		{"pattern tag", map[string]string{"pattern": "^[a-z]+$"}, "^[a-z]+$"},
+		{"pattern tag overrides validate", map[string]string{"validate": "regexp=^x$", "pattern": "^y$"}, "^y$"},
+		{"no pattern", map[string]string{"validate": "required"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := sentinel.FieldMetadata{Name: "Code", Type: "string", Tags: tt.tags}
+			schema := &openapi.Schema{Type: openapi.NewSchemaType("string")}
+			applyOpenAPITags(schema, field)
+
+			if schema.Pattern != tt.want {
+				t.Errorf("expected pattern %q, got %q", tt.want, schema.Pattern)
+			}
+		})
+	}
 }
 
 func TestApplyOpenAPITags_Enum(t *testing.T) {
@@ -587,10 +997,28 @@ func TestApplyOpenAPITags_ArrayValidations(t *testing.T) {
 	}
 }
 
-func TestApplyOpenAPITags_BooleanFlags(t *testing.T) {
-	// Note: readOnly, nullable, deprecated are not supported via validate tags
-	// These would need custom sentinel tags if needed
-	t.Skip("Boolean flags are not extracted from validate tags")
+func TestApplyOpenAPITags_Deprecated(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want bool
+	}{
+		{"true", true},
+		{"1", true},
+		{"false", false},
+		{"", false},
+		{"soon", false},
+	}
+	for _, tt := range tests {
+		schema := &openapi.Schema{Type: openapi.NewSchemaType("string")}
+		applyOpenAPITags(schema, sentinel.FieldMetadata{
+			Name: "Query",
+			Type: "string",
+			Tags: map[string]string{"deprecated": tt.tag},
+		})
+		if got := schema.Deprecated != nil && *schema.Deprecated; got != tt.want {
+			t.Errorf("deprecated:%q: expected deprecated %v, got %v", tt.tag, tt.want, got)
+		}
+	}
 }
 
 func TestApplyOpenAPITags_MultipleTagsCombined(t *testing.T) {
@@ -1168,6 +1596,7 @@ func TestParseValidateTag_NumericConstraints(t *testing.T) {
 		wantMax     *float64
 		wantExclMin *float64
 		wantExclMax *float64
+		wantMultOf  *float64
 	}{
 		{
 			name:        "min constraint on int",
@@ -1188,6 +1617,25 @@ func TestParseValidateTag_NumericConstraints(t *testing.T) {
 			wantMin:     float64Ptr(0.5),
 			wantMax:     float64Ptr(99.5),
 		},
+		{
+			name:        "multipleof on int",
+			validateTag: "multipleof=5",
+			goType:      "int",
+			wantMultOf:  float64Ptr(5),
+		},
+		{
+			name:        "multipleof with bounds on float64",
+			validateTag: "gt=0,max=1000,multipleof=0.01",
+			goType:      "float64",
+			wantMax:     float64Ptr(1000),
+			wantExclMin: float64Ptr(0),
+			wantMultOf:  float64Ptr(0.01),
+		},
+		{
+			name:        "multipleof ignored on string",
+			validateTag: "multipleof=5",
+			goType:      "string",
+		},
 	}
 
 	for _, tt := range tests {
@@ -1211,6 +1659,22 @@ func TestParseValidateTag_NumericConstraints(t *testing.T) {
 			} else if _, exists := constraints["maximum"]; exists {
 				t.Error("unexpected maximum constraint")
 			}
+
+			if tt.wantExclMin != nil {
+				exclMin := constraints["exclusiveMinimum"].(*float64)
+				if *exclMin != *tt.wantExclMin {
+					t.Errorf("exclusiveMinimum = %v, want %v", *exclMin, *tt.wantExclMin)
+				}
+			}
+
+			if tt.wantMultOf != nil {
+				multOf := constraints["multipleOf"].(*float64)
+				if *multOf != *tt.wantMultOf {
+					t.Errorf("multipleOf = %v, want %v", *multOf, *tt.wantMultOf)
+				}
+			} else if _, exists := constraints["multipleOf"]; exists {
+				t.Error("unexpected multipleOf constraint")
+			}
 		})
 	}
 }
@@ -1410,3 +1874,762 @@ func TestParseValidateTag_Combined(t *testing.T) {
 		t.Errorf("maximum = %v, want 100", *maxVal)
 	}
 }
+
+func TestGenerateOpenAPI_SparseFields(t *testing.T) {
+	engine := newTestEngine()
+
+	handler := NewHandler[NoBody, testOutput](
+		"list-users",
+		"GET",
+		"/users",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	).WithSparseFields()
+
+	engine.WithHandlers(handler)
+	spec := engine.GenerateOpenAPI(nil)
+
+	op := spec.Paths["/users"].Get
+	if op == nil {
+		t.Fatal("expected GET operation")
+	}
+	found := false
+	for _, param := range op.Parameters {
+		if param.Name == "fields" && param.In == "query" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected 'fields' query parameter to be documented")
+	}
+}
+
+func TestGenerateOpenAPI_AuthVariants(t *testing.T) {
+	engine := newTestEngine()
+
+	handler := NewHandler[NoBody, testOutput](
+		"greet",
+		"GET",
+		"/greet",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	).
+		WithOptionalAuthentication().
+		WithAuthVariants(
+			testOutput{Message: "Hello user-123", Result: 1},
+			testOutput{Message: "Hello stranger"},
+		)
+
+	engine.WithHandlers(handler)
+	spec := engine.GenerateOpenAPI(nil)
+
+	op := spec.Paths["/greet"].Get
+	if op == nil {
+		t.Fatal("expected GET operation")
+	}
+
+	examples := op.Responses["200"].Content["application/json"].Examples
+	if examples["authenticated"] == nil || examples["anonymous"] == nil {
+		t.Fatalf("expected authenticated and anonymous examples, got %v", examples)
+	}
+
+	if len(op.Security) != 2 {
+		t.Fatalf("expected 2 security alternatives, got %d", len(op.Security))
+	}
+	if len(op.Security[1]) != 0 {
+		t.Error("expected empty security requirement for anonymous access")
+	}
+	if spec.Components.SecuritySchemes["bearerAuth"] == nil {
+		t.Error("expected bearerAuth security scheme")
+	}
+	if _, ok := op.Responses["401"]; ok {
+		t.Error("expected no 401 response for optional authentication")
+	}
+}
+
+func TestGenerateOpenAPI_OperationServers(t *testing.T) {
+	engine := newTestEngine()
+	engine.spec.Servers = []openapi.Server{{URL: "https://api.example.com"}}
+	engine.WithHandlers(
+		newRouteHandler("list-users", "GET", "/users"),
+		newRouteHandler("upload", "POST", "/upload").
+			WithServers(openapi.Server{URL: "https://uploads.example.com", Description: "Upload gateway"}),
+	)
+
+	spec := engine.GenerateOpenAPI(nil)
+	if len(spec.Servers) != 1 || spec.Servers[0].URL != "https://api.example.com" {
+		t.Errorf("expected global servers to be kept, got %v", spec.Servers)
+	}
+	if servers := spec.Paths["/users"].Get.Servers; servers != nil {
+		t.Errorf("expected no operation servers without an override, got %v", servers)
+	}
+	servers := spec.Paths["/upload"].Post.Servers
+	if len(servers) != 1 || servers[0].URL != "https://uploads.example.com" {
+		t.Errorf("expected operation-level upload server, got %v", servers)
+	}
+}
+
+func TestGenerateOpenAPI_SecuritySchemes(t *testing.T) {
+	engine := newTestEngine()
+	engine.
+		WithAPIKeySecurityScheme("apiKey", "X-API-Key").
+		WithOAuth2SecurityScheme("oauth", openapi.OAuthFlows{
+			AuthorizationCode: &openapi.OAuthFlow{
+				AuthorizationURL: "https://auth.example.com/authorize",
+				TokenURL:         "https://auth.example.com/token",
+				Scopes:           map[string]string{"users:read": "Read users"},
+			},
+		})
+	engine.WithHandlers(
+		newRouteHandler("internal", "GET", "/internal").WithAuthentication().WithSecurityScheme("apiKey"),
+		newRouteHandler("users", "GET", "/users").WithScopes("users:read").WithSecurityScheme("oauth"),
+	)
+
+	spec := engine.GenerateOpenAPI(nil)
+	schemes := spec.Components.SecuritySchemes
+	if key := schemes["apiKey"]; key == nil || key.Type != "apiKey" || key.In != "header" || key.Name != "X-API-Key" {
+		t.Errorf("expected apiKey header scheme, got %+v", key)
+	}
+	if oauth := schemes["oauth"]; oauth == nil || oauth.Type != "oauth2" || oauth.Flows.AuthorizationCode == nil {
+		t.Errorf("expected oauth2 authorization code scheme, got %+v", oauth)
+	}
+	if _, ok := schemes[DefaultSecurityScheme]; ok {
+		t.Error("expected no bearer scheme when no handler uses it")
+	}
+
+	if sec := spec.Paths["/internal"].Get.Security; len(sec) != 1 || sec[0]["apiKey"] == nil {
+		t.Errorf("expected apiKey requirement, got %v", sec)
+	}
+	if sec := spec.Paths["/users"].Get.Security; len(sec) != 1 || !reflect.DeepEqual(sec[0]["oauth"], []string{"users:read"}) {
+		t.Errorf("expected oauth requirement with scopes, got %v", sec)
+	}
+	if err := engine.ValidateSpec(); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+
+	engine.WithHandlers(newRouteHandler("legacy", "GET", "/legacy").WithAuthentication())
+	if newTestEngine().GenerateOpenAPI(nil).Components.SecuritySchemes != nil {
+		t.Error("expected no security schemes without authenticated handlers")
+	}
+	spec = engine.GenerateOpenAPI(nil)
+	if bearer := spec.Components.SecuritySchemes[DefaultSecurityScheme]; bearer == nil || bearer.Scheme != "bearer" {
+		t.Errorf("expected default bearer scheme, got %+v", bearer)
+	}
+
+	engine.WithHandlers(newRouteHandler("typo", "GET", "/typo").WithSecurityScheme("apikey"))
+	err := engine.ValidateSpec()
+	if err == nil || !strings.Contains(err.Error(), `security scheme "apikey" is not registered`) {
+		t.Errorf("expected unregistered scheme error, got %v", err)
+	}
+}
+
+func TestGenerateOpenAPI_DeterministicMethodOrder(t *testing.T) {
+	newHandlers := func() []Endpoint {
+		return []Endpoint{
+			NewHandler[NoBody, testOutput]("list", "GET", "/items", func(_ *Request[NoBody]) (testOutput, error) {
+				return testOutput{}, nil
+			}),
+			NewHandler[testInput, testOutput]("create", "POST", "/items", func(_ *Request[testInput]) (testOutput, error) {
+				return testOutput{}, nil
+			}),
+			NewHandler[NoBody, testOutput]("remove", "DELETE", "/items", func(_ *Request[NoBody]) (testOutput, error) {
+				return testOutput{}, nil
+			}),
+		}
+	}
+
+	forward := newTestEngine()
+	forward.WithHandlers(newHandlers()...)
+
+	reversed := newTestEngine()
+	handlers := newHandlers()
+	for i := len(handlers) - 1; i >= 0; i-- {
+		reversed.WithHandlers(handlers[i])
+	}
+
+	a, err := json.Marshal(forward.GenerateOpenAPI(nil))
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	b, err := json.Marshal(reversed.GenerateOpenAPI(nil))
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if string(a) != string(b) {
+		t.Error("expected identical specs regardless of registration order")
+	}
+
+	// Methods are serialized in fixed PathItem field order.
+	s := string(a)
+	getIdx := strings.Index(s, `"get":`)
+	postIdx := strings.Index(s, `"post":`)
+	deleteIdx := strings.Index(s, `"delete":`)
+	if getIdx >= postIdx || postIdx >= deleteIdx {
+		t.Errorf("expected get < post < delete ordering, got %d, %d, %d", getIdx, postIdx, deleteIdx)
+	}
+}
+
+func TestGenerateOpenAPI_RequestMediaType(t *testing.T) {
+	engine := newTestEngine()
+
+	handler := NewHandler[testInput, testOutput](
+		"patch-item",
+		"PATCH",
+		"/items",
+		func(_ *Request[testInput]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	).WithRequestMediaType("application/merge-patch+json")
+
+	engine.WithHandlers(handler)
+	spec := engine.GenerateOpenAPI(nil)
+
+	op := spec.Paths["/items"].Patch
+	if op == nil || op.RequestBody == nil {
+		t.Fatal("expected PATCH operation with request body")
+	}
+	if _, ok := op.RequestBody.Content["application/merge-patch+json"]; !ok {
+		t.Errorf("expected merge-patch media type, got %v", op.RequestBody.Content)
+	}
+	if _, ok := op.RequestBody.Content["application/json"]; ok {
+		t.Error("expected application/json to be replaced")
+	}
+}
+
+func TestGenerateOpenAPI_RequestJSONSchema(t *testing.T) {
+	engine := newTestEngine()
+
+	handler := NewHandler[testInput, testOutput](
+		"create-item",
+		"POST",
+		"/items",
+		func(_ *Request[testInput]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	).WithJSONSchema([]byte(`{"type":"object","required":["name"]}`))
+
+	engine.WithHandlers(handler)
+	spec := engine.GenerateOpenAPI(nil)
+
+	op := spec.Paths["/items"].Post
+	if op == nil || op.RequestBody == nil {
+		t.Fatal("expected POST operation with request body")
+	}
+	schema := op.RequestBody.Content["application/json"].Schema
+	if schema == nil || schema.Ref != "" {
+		t.Fatalf("expected inline schema, got %+v", schema)
+	}
+	if len(schema.Required) != 1 || schema.Required[0] != "name" {
+		t.Errorf("expected required [name], got %v", schema.Required)
+	}
+}
+
+func TestGenerateOpenAPI_DefaultTags(t *testing.T) {
+	engine := newTestEngine().WithDefaultTags("general")
+
+	untagged := NewHandler[NoBody, testOutput](
+		"list-things",
+		"GET",
+		"/things",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	)
+	tagged := NewHandler[NoBody, testOutput](
+		"list-items",
+		"GET",
+		"/items",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	).WithTags("items")
+
+	engine.WithHandlers(untagged, tagged)
+	spec := engine.GenerateOpenAPI(nil)
+
+	if tags := spec.Paths["/things"].Get.Tags; len(tags) != 1 || tags[0] != "general" {
+		t.Errorf("expected default tag on untagged operation, got %v", tags)
+	}
+	if tags := spec.Paths["/items"].Get.Tags; len(tags) != 1 || tags[0] != "items" {
+		t.Errorf("expected own tags to take precedence, got %v", tags)
+	}
+	if len(untagged.Spec().Tags) != 0 {
+		t.Error("default tags should not be written back to the handler spec")
+	}
+
+	// Default tags are checked like any other operation tag.
+	engine.WithTag("items", "Item operations")
+	if err := engine.ValidateSpec(); err == nil || !strings.Contains(err.Error(), `tag "general"`) {
+		t.Errorf("expected undocumented default tag to be reported, got %v", err)
+	}
+	engine.WithTag("general", "Everything else")
+	if err := engine.ValidateSpec(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSummaryFromName(t *testing.T) {
+	tests := map[string]string{
+		"get-user":         "Get user",
+		"list-user_orders": "List user orders",
+		"users.create":     "Users create",
+		"list-API-keys":    "List API keys",
+		"health":           "Health",
+		"--":               "",
+		"":                 "",
+	}
+	for name, want := range tests {
+		if got := summaryFromName(name); got != want {
+			t.Errorf("summaryFromName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestGenerateOpenAPI_DerivedSummaries(t *testing.T) {
+	newEngine := func() *Engine {
+		engine := newTestEngine()
+		engine.WithHandlers(
+			NewHandler[NoBody, testOutput]("get-user", "GET", "/users", func(_ *Request[NoBody]) (testOutput, error) {
+				return testOutput{}, nil
+			}),
+			NewHandler[NoBody, testOutput]("list-items", "GET", "/items", func(_ *Request[NoBody]) (testOutput, error) {
+				return testOutput{}, nil
+			}).WithSummary("Explicit summary"),
+		)
+		return engine
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		spec := newEngine().GenerateOpenAPI(nil)
+		if summary := spec.Paths["/users"].Get.Summary; summary != "" {
+			t.Errorf("expected empty summary, got %q", summary)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		spec := newEngine().WithDerivedSummaries().GenerateOpenAPI(nil)
+		if summary := spec.Paths["/users"].Get.Summary; summary != "Get user" {
+			t.Errorf("expected derived summary, got %q", summary)
+		}
+		if summary := spec.Paths["/items"].Get.Summary; summary != "Explicit summary" {
+			t.Errorf("expected explicit summary to win, got %q", summary)
+		}
+	})
+}
+
+func TestGenerateOpenAPI_HeaderParams(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(NewHandler[NoBody, testOutput](
+		"create-order",
+		"POST",
+		"/orders",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	).WithHeaderParams("X-Request-ID").WithRequiredHeaderParams("X-Idempotency-Key"))
+
+	op := engine.GenerateOpenAPI(nil).Paths["/orders"].Post
+	required := make(map[string]bool)
+	for _, param := range op.Parameters {
+		if param.In != "header" {
+			continue
+		}
+		required[param.Name] = param.Required
+	}
+
+	if r, ok := required["X-Request-ID"]; !ok || r {
+		t.Errorf("expected optional X-Request-ID header parameter, got %v", required)
+	}
+	if r, ok := required["X-Idempotency-Key"]; !ok || !r {
+		t.Errorf("expected required X-Idempotency-Key header parameter, got %v", required)
+	}
+}
+
+func TestGenerateOpenAPI_CookieParams(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(NewHandler[NoBody, testOutput](
+		"get-profile",
+		"GET",
+		"/profile",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	).WithCookieParams("session"))
+
+	op := engine.GenerateOpenAPI(nil).Paths["/profile"].Get
+	found := false
+	for _, param := range op.Parameters {
+		if param.Name == "session" {
+			found = param.In == "cookie" && !param.Required
+		}
+	}
+	if !found {
+		t.Errorf("expected optional session cookie parameter, got %+v", op.Parameters)
+	}
+}
+
+func TestGenerateOpenAPI_RequestExamplesNamed(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(NewHandler[testInput, testOutput](
+		"create-item",
+		"POST",
+		"/items",
+		func(_ *Request[testInput]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	).
+		WithRequestExampleNamed("minimal", "Only required fields", testInput{Name: "a"}).
+		WithRequestExampleNamed("full", "Every field", testInput{Name: "a", Count: 3}))
+
+	media := engine.GenerateOpenAPI(nil).Paths["/items"].Post.RequestBody.Content["application/json"]
+	if len(media.Examples) != 2 {
+		t.Fatalf("expected 2 named examples, got %v", media.Examples)
+	}
+	full := media.Examples["full"]
+	if full == nil || full.Summary != "Every field" {
+		t.Fatalf("unexpected full example: %+v", full)
+	}
+	if value, ok := full.Value.(testInput); !ok || value.Count != 3 {
+		t.Errorf("unexpected example value: %+v", full.Value)
+	}
+}
+
+func TestGenerateOpenAPI_ObjectExamples(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(NewHandler[testInput, testOutput](
+		"create-item",
+		"POST",
+		"/items",
+		func(_ *Request[testInput]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	).
+		WithSuccessStatus(201).
+		WithErrors(ErrConflict).
+		WithRequestExample(testInput{Name: "widget", Count: 3}).
+		WithResponseExample(201, testOutput{Message: "created", Result: 7}).
+		WithResponseExample(409, map[string]any{"code": "CONFLICT", "message": "item exists"}))
+	engine.WithEncoder("text/csv", csvEncoder{})
+
+	op := engine.GenerateOpenAPI(nil).Paths["/items"].Post
+	request := op.RequestBody.Content["application/json"].Example
+	if want := map[string]any{"name": "widget", "count": float64(3)}; !reflect.DeepEqual(request, want) {
+		t.Errorf("expected request example %v, got %v", want, request)
+	}
+
+	created := op.Responses["201"].Content
+	if want := map[string]any{"message": "created", "result": float64(7)}; !reflect.DeepEqual(created["application/json"].Example, want) {
+		t.Errorf("expected 201 example %v, got %v", want, created["application/json"].Example)
+	}
+	if created["text/csv"].Example != nil {
+		t.Error("expected the example on the JSON content only")
+	}
+	if op.Responses["409"].Content["application/json"].Example == nil {
+		t.Error("expected the 409 example on the conflict response")
+	}
+
+	if err := engine.ValidateSpec(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestGenerateOpenAPI_ObjectExamples_Invalid(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(newRouteHandler("get-item", "GET", "/items").
+		WithResponseExample(404, testOutput{}).
+		WithResponseExample(200, map[string]any{"bad": make(chan int)}))
+
+	if op := engine.GenerateOpenAPI(nil).Paths["/items"].Get; op.Responses["404"].Content != nil {
+		t.Error("expected no response for an undeclared status")
+	}
+	err := engine.ValidateSpec()
+	if err == nil {
+		t.Fatal("expected ValidateSpec to report the examples")
+	}
+	for _, want := range []string{"status 404 has no documented JSON response", "response example for status 200"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in error, got %v", want, err)
+		}
+	}
+}
+
+func TestEngine_ValidateSpec(t *testing.T) {
+	newEngine := func() (*Engine, *Handler[testInput, testOutput]) {
+		engine := newTestEngine().WithTag("items", "Item operations")
+		handler := NewHandler[testInput, testOutput](
+			"create-item",
+			"POST",
+			"/items",
+			func(_ *Request[testInput]) (testOutput, error) {
+				return testOutput{}, nil
+			},
+		).WithTags("items")
+		engine.WithHandlers(handler)
+		return engine, handler
+	}
+
+	t.Run("clean", func(t *testing.T) {
+		engine, _ := newEngine()
+		if err := engine.ValidateSpec(); err != nil {
+			t.Errorf("expected valid spec, got %v", err)
+		}
+	})
+
+	t.Run("unresolved type", func(t *testing.T) {
+		engine, handler := newEngine()
+		handler.spec.InputTypeName = "MissingInput"
+
+		err := engine.ValidateSpec()
+		if err == nil {
+			t.Fatal("expected error for unresolved type")
+		}
+		msg := err.Error()
+		if !strings.Contains(msg, `handler "create-item": MissingInput: type metadata not found`) {
+			t.Errorf("expected lookup failure in error, got %q", msg)
+		}
+		if !strings.Contains(msg, "MissingInput: dangling $ref") {
+			t.Errorf("expected dangling ref in error, got %q", msg)
+		}
+	})
+
+	t.Run("undocumented tag", func(t *testing.T) {
+		engine, handler := newEngine()
+		handler.WithTags("items", "admin")
+
+		err := engine.ValidateSpec()
+		if err == nil {
+			t.Fatal("expected error for undocumented tag")
+		}
+		if !strings.Contains(err.Error(), `handler "create-item": tag "admin" is not documented`) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestEngine_WithStrictSpec_StartFails(t *testing.T) {
+	engine := NewEngine("localhost", 0, nil).WithStrictSpec()
+	handler := NewHandler[testInput, testOutput](
+		"create-item",
+		"POST",
+		"/items",
+		func(_ *Request[testInput]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	).WithTags("undeclared")
+	engine.WithHandlers(handler)
+
+	err := engine.Start()
+	if err == nil {
+		t.Fatal("expected Start to fail with invalid spec")
+	}
+	if !strings.Contains(err.Error(), "invalid OpenAPI spec") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+type docTagTestInput struct {
+	Code string `json:"code" pattern:"^[A-Z]{3}$" description:"Original" summary:"Override"`
+}
+
+func TestRegisterDocTag(t *testing.T) {
+	RegisterDocTag("pattern", func(schema *openapi.Schema, value string) {
+		schema.Pattern = value
+	})
+	RegisterDocTag("summary", func(schema *openapi.Schema, value string) {
+		schema.Description = value
+	})
+	RegisterDocTag("noop", nil)
+
+	engine := newTestEngine()
+	handler := NewHandler[docTagTestInput, testOutput](
+		"doc-tags",
+		"POST",
+		"/doc-tags",
+		func(_ *Request[docTagTestInput]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	)
+	engine.WithHandlers(handler)
+
+	spec := engine.GenerateOpenAPI(nil)
+	schema, ok := spec.Components.Schemas["docTagTestInput"]
+	if !ok {
+		t.Fatal("expected docTagTestInput schema")
+	}
+	code := schema.Properties["code"]
+	if code.Pattern != "^[A-Z]{3}$" {
+		t.Errorf("expected pattern from custom tag, got %q", code.Pattern)
+	}
+	if code.Description != "Override" {
+		t.Errorf("expected custom tag to override description, got %q", code.Description)
+	}
+}
+
+type describedOutput struct {
+	ID string `json:"id"`
+}
+
+func TestRegisterSchemaDescription(t *testing.T) {
+	RegisterSchemaDescription[describedOutput]("A resource with a type-level description")
+
+	engine := newTestEngine()
+	handler := NewHandler[NoBody, *describedOutput](
+		"described",
+		"GET",
+		"/described",
+		func(_ *Request[NoBody]) (*describedOutput, error) {
+			return &describedOutput{}, nil
+		},
+	)
+	engine.WithHandlers(handler)
+
+	spec := engine.GenerateOpenAPI(nil)
+	schema, ok := spec.Components.Schemas["describedOutput"]
+	if !ok {
+		t.Fatal("expected describedOutput schema")
+	}
+	if schema.Description != "A resource with a type-level description" {
+		t.Errorf("unexpected schema description: %q", schema.Description)
+	}
+	if other := spec.Components.Schemas["ErrorResponse"]; other.Description != "" {
+		t.Errorf("expected unregistered schema to have no description, got %q", other.Description)
+	}
+}
+
+type titledOutput struct {
+	Status string `json:"status" title:"Order Status" validate:"oneof=pending shipped"`
+}
+
+func TestSchemaTitle(t *testing.T) {
+	RegisterSchemaTitle[titledOutput]("Order")
+
+	engine := newTestEngine()
+	handler := NewHandler[NoBody, titledOutput](
+		"titled",
+		"GET",
+		"/titled",
+		func(_ *Request[NoBody]) (titledOutput, error) {
+			return titledOutput{}, nil
+		},
+	)
+	engine.WithHandlers(handler)
+
+	spec := engine.GenerateOpenAPI(nil)
+	schema, ok := spec.Components.Schemas["titledOutput"]
+	if !ok {
+		t.Fatal("expected titledOutput schema")
+	}
+	if schema.Title != "Order" {
+		t.Errorf("expected schema title 'Order', got %q", schema.Title)
+	}
+	status := schema.Properties["status"]
+	if status.Title != "Order Status" {
+		t.Errorf("expected field title 'Order Status', got %q", status.Title)
+	}
+	if len(status.Enum) != 2 {
+		t.Errorf("expected enum to be preserved alongside title, got %v", status.Enum)
+	}
+}
+
+func TestMetadataToSchema_CompositeExample(t *testing.T) {
+	type exampleInput struct {
+		Name  string   `json:"name" example:"Alice"`
+		Age   int      `json:"age" example:"30"`
+		Tags  []string `json:"tags" example:"a,b"`
+		Notes string   `json:"notes"`
+	}
+
+	schema := metadataToSchema(sentinel.Scan[exampleInput]())
+
+	example, ok := schema.Example.(map[string]any)
+	if !ok {
+		t.Fatalf("expected composite example object, got %T", schema.Example)
+	}
+	if example["name"] != "Alice" {
+		t.Errorf("expected name example 'Alice', got %v", example["name"])
+	}
+	if example["age"] != 30 {
+		t.Errorf("expected age example 30, got %v (%T)", example["age"], example["age"])
+	}
+	if _, ok := example["notes"]; ok {
+		t.Error("expected fields without examples to be omitted")
+	}
+	if len(example) != 3 {
+		t.Errorf("expected 3 example fields, got %d", len(example))
+	}
+}
+
+func TestMetadataToSchema_NoExamples(t *testing.T) {
+	schema := metadataToSchema(sentinel.Scan[testOutput]())
+	if schema.Example != nil {
+		t.Errorf("expected no composite example, got %v", schema.Example)
+	}
+}
+
+func TestParseValidateTag_ConditionalRequired(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"required_if=Kind card", "Required when Kind is card."},
+		{"required_if=Kind card Country US", "Required when Kind is card and Country is US."},
+		{"required_unless=Kind cash", "Required unless Kind is cash."},
+		{"required_with=Phone", "Required when Phone is present."},
+		{"required_with=Phone Email", "Required when any of Phone, Email is present."},
+		{"required_with_all=Street City", "Required when all of Street, City are present."},
+		{"required_without=Email", "Required when Email is absent."},
+		{"required_without_all=Email Phone", "Required when all of Email, Phone are absent."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, _ := parseValidateTag(tt.tag, "string")["requiredWhen"].(string)
+			if got != tt.want {
+				t.Errorf("requiredWhen = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetadataToSchema_ConditionalRequired(t *testing.T) {
+	type paymentInput struct {
+		Kind       string `json:"kind" validate:"required,oneof=card cash"`
+		CardNumber string `json:"card_number" validate:"required_if=Kind card" description:"Card PAN."`
+		Email      string `json:"email" validate:"required_without=Phone"`
+		Phone      string `json:"phone,omitempty"`
+	}
+
+	schema := metadataToSchema(sentinel.Scan[paymentInput]())
+
+	if len(schema.Required) != 1 || schema.Required[0] != "kind" {
+		t.Errorf("expected only 'kind' to be unconditionally required, got %v", schema.Required)
+	}
+	if got := schema.Properties["card_number"].Description; got != "Card PAN. Required when Kind is card." {
+		t.Errorf("unexpected card_number description: %q", got)
+	}
+	if got := schema.Properties["email"].Description; got != "Required when Phone is absent." {
+		t.Errorf("unexpected email description: %q", got)
+	}
+}
+
+func TestGenerateOpenAPI_LastModified(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(NewHandler[NoBody, testOutput](
+		"get-article",
+		"GET",
+		"/articles",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	).WithLastModified(func(testOutput) time.Time { return time.Now() }))
+
+	responses := engine.GenerateOpenAPI(nil).Paths["/articles"].Get.Responses
+	if _, ok := responses["200"].Headers["Last-Modified"]; !ok {
+		t.Error("expected Last-Modified header on success response")
+	}
+	if _, ok := responses["304"]; !ok {
+		t.Error("expected 304 Not Modified response")
+	}
+}
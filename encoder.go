@@ -0,0 +1,99 @@
+package rocco
+
+import (
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// Encoder serializes handler output for a non-JSON response media type.
+// Register encoders with Engine.WithEncoder; JSON remains the default.
+type Encoder interface {
+	// ContentType returns the Content-Type header value for encoded responses
+	// (e.g., "text/csv; charset=utf-8").
+	ContentType() string
+	// Encode writes v to w.
+	Encode(w io.Writer, v any) error
+}
+
+// registeredEncoder pairs an Encoder with the media type it is negotiated by.
+type registeredEncoder struct {
+	mediaType string
+	encoder   Encoder
+}
+
+// acceptRange is a single media range from an Accept header.
+type acceptRange struct {
+	typ, subtype string
+	q            float64
+}
+
+// parseAccept parses an Accept header into media ranges. Malformed entries are skipped.
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(raw, 64); err == nil && parsed >= 0 && parsed <= 1 {
+				q = parsed
+			}
+		}
+		ranges = append(ranges, acceptRange{typ: typ, subtype: subtype, q: q})
+	}
+	return ranges
+}
+
+// acceptQuality returns the quality the ranges assign to mediaType and the specificity
+// of the range that matched (2 exact, 1 type wildcard, 0 full wildcard, -1 no match).
+func acceptQuality(ranges []acceptRange, mediaType string) (float64, int) {
+	typ, subtype, _ := strings.Cut(strings.ToLower(mediaType), "/")
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		var s int
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			s = 2
+		case r.typ == typ && r.subtype == "*":
+			s = 1
+		case r.typ == "*" && r.subtype == "*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q, specificity
+}
+
+// negotiateEncoder selects the encoder for the request's Accept header.
+// It returns nil when the built-in JSON encoding should be used: when there is no
+// Accept header, JSON is preferred or tied, or nothing registered is acceptable.
+func negotiateEncoder(accept string, encoders []registeredEncoder) *registeredEncoder {
+	if accept == "" || len(encoders) == 0 {
+		return nil
+	}
+	ranges := parseAccept(accept)
+	bestQ, bestSpecificity := acceptQuality(ranges, "application/json")
+	var best *registeredEncoder
+	for i := range encoders {
+		q, specificity := acceptQuality(ranges, encoders[i].mediaType)
+		if specificity < 0 || q == 0 {
+			continue
+		}
+		if q > bestQ || (q == bestQ && specificity > bestSpecificity) {
+			best, bestQ, bestSpecificity = &encoders[i], q, specificity
+		}
+	}
+	return best
+}
@@ -0,0 +1,174 @@
+package rocco
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+type csvEncoder struct{}
+
+func (csvEncoder) ContentType() string { return "text/csv; charset=utf-8" }
+
+func (csvEncoder) Encode(w io.Writer, v any) error {
+	out, ok := v.(testOutput)
+	if !ok {
+		return fmt.Errorf("unsupported type %T", v)
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"message", "result"}); err != nil {
+		return err
+	}
+	if err := cw.Write([]string{out.Message, strconv.Itoa(out.Result)}); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type failingEncoder struct{}
+
+func (failingEncoder) ContentType() string         { return "application/xml" }
+func (failingEncoder) Encode(io.Writer, any) error { return errors.New("boom") }
+
+func newEncoderEngine() *Engine {
+	engine := newTestEngine().
+		WithEncoder("text/csv", csvEncoder{}).
+		WithEncoder("application/xml", failingEncoder{})
+	engine.WithHandlers(NewHandler[NoBody, testOutput](
+		"report",
+		"GET",
+		"/report",
+		func(*Request[NoBody]) (testOutput, error) {
+			return testOutput{Message: "hello", Result: 7}, nil
+		},
+	))
+	return engine
+}
+
+func TestEngine_WithEncoder_Negotiation(t *testing.T) {
+	engine := newEncoderEngine()
+
+	tests := []struct {
+		accept      string
+		contentType string
+	}{
+		{"", "application/json"},
+		{"text/csv", "text/csv; charset=utf-8"},
+		{"application/json, text/csv", "application/json"},
+		{"text/csv, */*", "text/csv; charset=utf-8"},
+		{"text/*", "text/csv; charset=utf-8"},
+		{"application/json;q=0.5, text/csv", "text/csv; charset=utf-8"},
+		{"text/csv;q=0.2, application/json;q=0.8", "application/json"},
+		{"image/png", "application/json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/report", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			engine.mux.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("expected Content-Type %q, got %q", tt.contentType, got)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("expected Vary: Accept, got %q", got)
+			}
+		})
+	}
+}
+
+func TestEngine_WithEncoder_Body(t *testing.T) {
+	engine := newEncoderEngine()
+
+	req := httptest.NewRequest("GET", "/report", nil)
+	req.Header.Set("Accept", "text/csv")
+	w := httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, req)
+
+	want := "message,result\nhello,7\n"
+	if w.Body.String() != want {
+		t.Errorf("expected %q, got %q", want, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Length"); got != fmt.Sprint(len(want)) {
+		t.Errorf("expected Content-Length %d, got %q", len(want), got)
+	}
+}
+
+func TestEngine_WithEncoder_EncodeError(t *testing.T) {
+	engine := newEncoderEngine()
+
+	req := httptest.NewRequest("GET", "/report", nil)
+	req.Header.Set("Accept", "application/xml")
+	w := httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", w.Code)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("expected JSON error response, got %q", got)
+	}
+}
+
+func TestEngine_WithEncoder_Replace(t *testing.T) {
+	engine := newTestEngine().
+		WithEncoder("text/csv", failingEncoder{}).
+		WithEncoder("TEXT/CSV", csvEncoder{})
+
+	if len(engine.encoders) != 1 {
+		t.Fatalf("expected 1 encoder, got %d", len(engine.encoders))
+	}
+	if _, ok := engine.encoders[0].encoder.(csvEncoder); !ok {
+		t.Error("expected re-registration to replace the encoder")
+	}
+}
+
+func TestHandler_NoEncoders_NoVary(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(NewHandler[NoBody, testOutput](
+		"report",
+		"GET",
+		"/report",
+		func(*Request[NoBody]) (testOutput, error) { return testOutput{}, nil },
+	))
+
+	req := httptest.NewRequest("GET", "/report", nil)
+	req.Header.Set("Accept", "text/csv")
+	w := httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("expected application/json, got %q", got)
+	}
+	if got := w.Header().Get("Vary"); got != "" {
+		t.Errorf("expected no Vary header, got %q", got)
+	}
+}
+
+func TestGenerateOpenAPI_Encoders(t *testing.T) {
+	engine := newEncoderEngine()
+
+	content := engine.GenerateOpenAPI(nil).Paths["/report"].Get.Responses["200"].Content
+	for _, mediaType := range []string{"application/json", "text/csv", "application/xml"} {
+		media, ok := content[mediaType]
+		if !ok {
+			t.Errorf("expected %s in response content", mediaType)
+			continue
+		}
+		if media.Schema == nil || media.Schema.Ref != "#/components/schemas/testOutput" {
+			t.Errorf("expected %s to reference testOutput, got %+v", mediaType, media.Schema)
+		}
+	}
+}
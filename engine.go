@@ -3,14 +3,19 @@ package rocco
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/zoobzio/capitan"
 	"github.com/zoobzio/openapi"
+	"gopkg.in/yaml.v3"
 )
 
 // chain wraps a handler with middleware (applied in reverse order).
@@ -29,12 +34,37 @@ type Engine struct {
 	globalMiddleware    []func(http.Handler) http.Handler
 	handlers            []Endpoint // Registered handlers for OpenAPI generation
 	extractIdentity     func(context.Context, *http.Request) (Identity, error)
+	loggerFactory       func(context.Context, *http.Request) Logger
 	ctx                 context.Context
 	cancel              context.CancelFunc
+	streamsCtx          context.Context    // Cancelled when Shutdown begins, ending active streams
+	stopStreams         context.CancelFunc // Cancels streamsCtx
 	defaultHandlersOnce sync.Once
-	spec                *EngineSpec // OpenAPI specification configuration
-	cachedOpenAPISpec   []byte      // Cached JSON-encoded OpenAPI spec
-	openAPIOnce         sync.Once   // Ensures OpenAPI spec is generated only once
+	spec                *EngineSpec  // OpenAPI specification configuration
+	cachedOpenAPISpec   []byte       // Cached JSON-encoded OpenAPI spec
+	cachedOpenAPIYAML   []byte       // Cached YAML-encoded OpenAPI spec
+	openAPICached       bool         // Whether the cached encodings are current
+	openAPIMu           sync.Mutex   // Guards the cached OpenAPI spec
+	strictSpec          bool         // Refuse to start if the OpenAPI spec fails validation
+	derivedSummaries    bool         // Derive missing operation summaries from handler names
+	sanitizeDescs       bool         // Escape raw HTML in operation summaries and descriptions
+	trustedProxies      []*net.IPNet // Peers whose X-Forwarded-* headers are honoured
+	panicMode           PanicMode    // How handler panics are handled (default: recover to 500)
+
+	validationErrorFormatter ValidationErrorFormatter      // Custom validation error rendering (nil = default)
+	shutdownHooks            []func(context.Context) error // Cleanup run by Shutdown in reverse order
+	encoders                 []registeredEncoder           // Additional response encoders, negotiated via Accept
+	onListening              func(addr string)             // Called once the listener is bound (nil = disabled)
+	boundAddr                atomic.Pointer[string]        // Actual listen address once bound
+	routes                   map[string]string             // Normalized route pattern -> handler name
+	routeErrors              []error                       // Conflicting registrations, reported by Validate
+	errorDetailMode          ErrorDetailMode               // How much of an error responses expose (default: DetailsDev)
+	responseEnvelope         func(any) any                 // Wraps every success response body (nil = unwrapped)
+	maxRequestDuration       time.Duration                 // Ceiling on every non-stream request (0 = none)
+	interceptors             []RequestInterceptor          // Run before middleware; may reject requests
+	protectedDocs            bool                          // Require authentication for /openapi, /openapi.yaml and /docs
+	handlersMu               sync.RWMutex                  // Guards handlers, routes, routeErrors and router for AddHandler
+	router                   Router                        // Dispatches requests (default: wraps mux)
 }
 
 // NewEngine creates a new Engine with identity extraction.
@@ -46,11 +76,12 @@ func NewEngine(
 	extractIdentity func(context.Context, *http.Request) (Identity, error),
 ) *Engine {
 	config := &EngineConfig{
-		Host:         host,
-		Port:         port,
-		ReadTimeout:  120 * time.Second,
-		WriteTimeout: 120 * time.Second,
-		IdleTimeout:  120 * time.Second,
+		Host:              host,
+		Port:              port,
+		ReadTimeout:       120 * time.Second,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      120 * time.Second,
+		IdleTimeout:       120 * time.Second,
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -67,15 +98,18 @@ func NewEngine(
 		cancel:           cancel,
 		spec:             DefaultEngineSpec(),
 	}
+	e.router = serveMuxRouter{ServeMux: mux}
+	e.streamsCtx, e.stopStreams = context.WithCancel(ctx)
 
 	// Create HTTP server
 	addr := fmt.Sprintf("%s:%d", config.Host, config.Port)
 	e.server = &http.Server{
-		Addr:         addr,
-		Handler:      e.mux,
-		ReadTimeout:  config.ReadTimeout,
-		WriteTimeout: config.WriteTimeout,
-		IdleTimeout:  config.IdleTimeout,
+		Addr:              addr,
+		Handler:           e.router,
+		ReadTimeout:       config.ReadTimeout,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
 	}
 
 	// Emit engine created event
@@ -93,6 +127,145 @@ func (e *Engine) WithMiddleware(middleware ...func(http.Handler) http.Handler) *
 	return e
 }
 
+// WithLoggerFactory sets a factory that builds a request-scoped Logger for every request.
+// Handlers and middleware retrieve it via LoggerFromContext.
+func (e *Engine) WithLoggerFactory(factory func(context.Context, *http.Request) Logger) *Engine {
+	e.loggerFactory = factory
+	return e
+}
+
+// WithSanitizedDescriptions escapes raw HTML in operation summaries and descriptions
+// when generating the OpenAPI spec. Descriptions render as markdown in the docs UI,
+// so enable this when they are built from dynamic or user-provided content.
+func (e *Engine) WithSanitizedDescriptions() *Engine {
+	e.sanitizeDescs = true
+	return e
+}
+
+// WithDerivedSummaries fills in the OpenAPI summary of operations without one by
+// deriving it from the handler name ("get-user" becomes "Get user").
+// Summaries set with WithSummary are always used as-is.
+func (e *Engine) WithDerivedSummaries() *Engine {
+	e.derivedSummaries = true
+	return e
+}
+
+// WithShutdownHook registers cleanup to run during Shutdown, after the server has
+// stopped accepting requests and drained active ones but before the engine context
+// is cancelled. Use it to flush metrics, close connection pools, or drain queues.
+// Hooks run in reverse registration order; their errors are joined into the error
+// returned by Shutdown.
+func (e *Engine) WithShutdownHook(hook func(context.Context) error) *Engine {
+	e.shutdownHooks = append(e.shutdownHooks, hook)
+	return e
+}
+
+// WithStrictSpec makes Start fail if ValidateSpec reports any problems with the
+// generated OpenAPI specification, instead of serving an incomplete spec.
+func (e *Engine) WithStrictSpec() *Engine {
+	e.strictSpec = true
+	return e
+}
+
+// WithTrustedProxies sets the proxies (CIDRs or bare IPs) whose X-Forwarded-For and
+// X-Forwarded-Proto headers are honoured. For requests from these peers, RemoteAddr is
+// rewritten to the real client IP (see ClientIP) and URL.Scheme to the forwarded protocol.
+// Invalid entries are skipped and reported via a TrustedProxyInvalid event.
+func (e *Engine) WithTrustedProxies(cidrs ...string) *Engine {
+	for _, cidr := range cidrs {
+		network, err := parseTrustedProxy(cidr)
+		if err != nil {
+			capitan.Error(e.ctx, TrustedProxyInvalid,
+				AddressKey.Field(cidr),
+				ErrorKey.Field(err.Error()),
+			)
+			continue
+		}
+		e.trustedProxies = append(e.trustedProxies, network)
+	}
+	return e
+}
+
+// WithValidationErrorFormatter sets a function that renders request validation failures.
+// It receives the raw validator.ValidationErrors and returns the error to write, allowing
+// custom shapes (e.g. field-keyed maps). Returning nil falls back to ErrValidationFailed.
+// Declare the returned error via WithErrors so it appears in the OpenAPI spec.
+func (e *Engine) WithValidationErrorFormatter(formatter func(validator.ValidationErrors) ErrorDefinition) *Engine {
+	e.validationErrorFormatter = formatter
+	return e
+}
+
+// WithPanicHandling sets how panics in handlers and their middleware are handled.
+// With PanicRecover (the default) a panic is reported through the HandlerPanicked event,
+// with its value and stack trace, and the client receives a generic ErrInternalServer
+// response; if the response had already started, the connection is aborted instead.
+// With PanicPropagate panics are left to net/http or an external recovery layer.
+// Applies to handlers registered after this call.
+func (e *Engine) WithPanicHandling(mode PanicMode) *Engine {
+	e.panicMode = mode
+	return e
+}
+
+// WithRecovery is shorthand for WithPanicHandling(PanicRecover).
+func (e *Engine) WithRecovery() *Engine {
+	return e.WithPanicHandling(PanicRecover)
+}
+
+// WithOnListening sets a callback invoked by Start and Serve once the listener is bound,
+// before requests are served. It receives the actual address, which includes the
+// assigned port when the engine is configured with port 0. The callback runs on the
+// serving goroutine and should return promptly.
+func (e *Engine) WithOnListening(fn func(addr string)) *Engine {
+	e.onListening = fn
+	return e
+}
+
+// WithEncoder registers an encoder for responses in mediaType (e.g., "text/csv").
+// Handlers negotiate the response encoding from the Accept header, using JSON when
+// the client does not ask for a registered type. Encoded responses bypass JSON-only
+// features (time formats, sparse fieldsets); errors are always written as JSON.
+// Registering a media type again replaces its encoder; application/json cannot be replaced.
+func (e *Engine) WithEncoder(mediaType string, enc Encoder) *Engine {
+	mediaType = strings.ToLower(mediaType)
+	for i := range e.encoders {
+		if e.encoders[i].mediaType == mediaType {
+			e.encoders[i].encoder = enc
+			return e
+		}
+	}
+	e.encoders = append(e.encoders, registeredEncoder{mediaType: mediaType, encoder: enc})
+	return e
+}
+
+// WithErrorDetailMode sets how much of a handler's error is written to the client.
+// DetailsDev (the default) writes every error in full, including its WithCause chain;
+// DetailsProd reduces undeclared 5xx errors to their code and a generic message.
+// Production deployments should set DetailsProd.
+func (e *Engine) WithErrorDetailMode(mode ErrorDetailMode) *Engine {
+	e.errorDetailMode = mode
+	return e
+}
+
+// WithResponseEnvelope wraps every successful handler response in the value returned by
+// envelope, e.g. func(out any) any { return map[string]any{"data": out} }. out is always
+// the handler's output value, whichever encoder writes the response. Time formats and
+// sparse fieldsets still apply to the output when the envelope embeds it unchanged.
+// Error responses and streams are not wrapped. GenerateOpenAPI documents success
+// responses as the output schema under a "data" property.
+func (e *Engine) WithResponseEnvelope(envelope func(out any) any) *Engine {
+	e.responseEnvelope = envelope
+	return e
+}
+
+// WithProtectedDocs requires authentication for the built-in /openapi, /openapi.yaml and /docs endpoints.
+// Requests run through the engine's identity extractor and get 401 when it fails; with no
+// extractor configured every request is rejected. The docs page fetches /openapi from the
+// browser, so its credentials must travel automatically (e.g. a cookie) for it to load.
+func (e *Engine) WithProtectedDocs() *Engine {
+	e.protectedDocs = true
+	return e
+}
+
 // WithSpec sets the engine specification for OpenAPI generation.
 func (e *Engine) WithSpec(spec *EngineSpec) *Engine {
 	e.spec = spec
@@ -123,68 +296,284 @@ func (e *Engine) WithTag(name, description string) *Engine {
 	return e
 }
 
+// WithTagGroup groups tags under a top-level navigation section, emitted as x-tagGroups
+// on the document GenerateOpenAPI returns and those served at /openapi and /openapi.yaml.
+// Calling it again with the same
+// name replaces the group's tags. Every grouped tag should be declared via WithTag;
+// ValidateSpec reports those that are not.
+func (e *Engine) WithTagGroup(name string, tags ...string) *Engine {
+	for i, group := range e.spec.TagGroups {
+		if group.Name == name {
+			e.spec.TagGroups[i].Tags = tags
+			return e
+		}
+	}
+	e.spec.TagGroups = append(e.spec.TagGroups, TagGroup{Name: name, Tags: tags})
+	return e
+}
+
+// WithAPIKeySecurityScheme registers a named OpenAPI security scheme for an API key sent
+// in the given request header. Handlers select it with WithSecurityScheme.
+// It only affects documentation; the identity extractor must read the header itself.
+func (e *Engine) WithAPIKeySecurityScheme(name, headerName string) *Engine {
+	return e.withSecurityScheme(name, &openapi.SecurityScheme{
+		Type:        "apiKey",
+		Name:        headerName,
+		In:          "header",
+		Description: "API key authentication",
+	})
+}
+
+// WithOAuth2SecurityScheme registers a named OpenAPI OAuth2 security scheme with the given
+// flows. Handlers select it with WithSecurityScheme; their scopes become the operation's
+// required OAuth2 scopes.
+func (e *Engine) WithOAuth2SecurityScheme(name string, flows openapi.OAuthFlows) *Engine {
+	return e.withSecurityScheme(name, &openapi.SecurityScheme{
+		Type:        "oauth2",
+		Flows:       &flows,
+		Description: "OAuth2 authentication",
+	})
+}
+
+// withSecurityScheme registers scheme under name, replacing any scheme of the same name.
+func (e *Engine) withSecurityScheme(name string, scheme *openapi.SecurityScheme) *Engine {
+	if e.spec.SecuritySchemes == nil {
+		e.spec.SecuritySchemes = make(map[string]*openapi.SecurityScheme)
+	}
+	e.spec.SecuritySchemes[name] = scheme
+	return e
+}
+
+// WithDefaultTags sets tags applied in the OpenAPI spec to operations that declare none,
+// so every operation is grouped in the documentation.
+// Declare them with WithTag to give the groups descriptions.
+func (e *Engine) WithDefaultTags(tags ...string) *Engine {
+	e.spec.DefaultTags = tags
+	return e
+}
+
 // Router returns the underlying http.ServeMux for advanced use cases.
 // This allows power users to register custom routes that won't appear in OpenAPI documentation.
+// After WithRouter the mux no longer serves requests; register custom routes on your router.
 func (e *Engine) Router() *http.ServeMux {
 	return e.mux
 }
 
+// Handler returns the http.Handler that serves the engine's routes: the configured Router,
+// or the default http.ServeMux. Use it to mount the engine in another server or in tests.
+func (e *Engine) Handler() http.Handler {
+	e.handlersMu.RLock()
+	defer e.handlersMu.RUnlock()
+	return e.router
+}
+
+// WithRouter routes requests with router instead of the default http.ServeMux, e.g. to
+// use chi or httprouter. Call it before WithHandlers: routes already registered stay on
+// the previous router, so a late call is ignored and reported by Validate.
+func (e *Engine) WithRouter(router Router) *Engine {
+	e.handlersMu.Lock()
+	defer e.handlersMu.Unlock()
+	if len(e.routes) > 0 {
+		e.routeErrors = append(e.routeErrors, errors.New("WithRouter must be called before WithHandlers; the router was not changed"))
+		return e
+	}
+	e.router = router
+	e.server.Handler = router
+	return e
+}
+
+// WithMaxRequestDuration bounds every request except streams by d, a safety net against
+// runaway handlers independent of each handler's WithTimeout; the shorter of the two wins.
+// The request context carries the deadline, including through middleware, so handlers should
+// pass it on to stop work early. A handler that returns after it passes answers
+// ErrGatewayTimeout (504) and emits HandlerTimeout instead of sending its result; only
+// handlers with WithTimeout or WithAbortOnDisconnect are abandoned while still running.
+// Other endpoints see the deadline on their context. A zero duration means no ceiling, the default.
+func (e *Engine) WithMaxRequestDuration(d time.Duration) *Engine {
+	e.maxRequestDuration = d
+	return e
+}
+
+// limitRequestDuration applies the engine's WithMaxRequestDuration ceiling to next.
+func (e *Engine) limitRequestDuration(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if e.maxRequestDuration <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), e.maxRequestDuration)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// WithNotFoundHandler answers requests that match no route with handler instead of
+// http.ServeMux's plain-text 404. A nil handler writes ErrNotFound, so unmatched routes
+// share the API's error format. Global middleware does not run for these requests.
+// Only the default router supports it; with WithRouter, configure your router instead.
+func (e *Engine) WithNotFoundHandler(handler http.Handler) *Engine {
+	if handler == nil {
+		handler = http.HandlerFunc(writeNotFound)
+	}
+	return e.setRouteFallback(func(m *serveMuxRouter) { m.notFound = handler })
+}
+
+// WithMethodNotAllowedHandler answers requests whose path matches a route but whose
+// method does not with handler instead of http.ServeMux's plain-text 405. The Allow
+// header is already set when handler runs. A nil handler writes ErrMethodNotAllowed
+// listing the allowed methods. Like WithNotFoundHandler, it needs the default router.
+func (e *Engine) WithMethodNotAllowedHandler(handler http.Handler) *Engine {
+	if handler == nil {
+		handler = http.HandlerFunc(writeMethodNotAllowed)
+	}
+	return e.setRouteFallback(func(m *serveMuxRouter) { m.methodNotAllowed = handler })
+}
+
+// ServeFiles serves files from fs under urlPrefix through a FileHandler, documented in the
+// OpenAPI spec as a catch-all route and wrapped in the engine's global middleware. Register
+// a NewFileHandler with WithHandlers instead to add authentication or handler middleware.
+func (e *Engine) ServeFiles(urlPrefix string, fs http.FileSystem) *Engine {
+	name := "serve-files"
+	if trimmed := strings.Trim(urlPrefix, "/"); trimmed != "" {
+		name += "-" + strings.ReplaceAll(trimmed, "/", "-")
+	}
+	return e.WithHandlers(NewFileHandler(name, urlPrefix, fs))
+}
+
 // WithHandlers adds one or more Endpoints to the engine and returns the engine for chaining.
+// A handler whose route conflicts with a registered one is left out and reported by Validate.
 //
-// Threading model: All handler registration must complete before calling Start().
+// Threading model: All WithHandlers calls must complete before calling Start().
 // Calling WithHandlers concurrently or after Start() results in undefined behavior.
-// This follows the standard Go pattern for HTTP server configuration.
+// This follows the standard Go pattern for HTTP server configuration. To add routes
+// while serving, use AddHandler.
 func (e *Engine) WithHandlers(handlers ...Endpoint) *Engine {
 	// Ensure default handlers are registered first (only once)
 	e.ensureDefaultHandlers()
 
+	e.handlersMu.Lock()
 	for _, handler := range handlers {
-		// Store handler for OpenAPI generation.
-		e.handlers = append(e.handlers, handler)
+		if err := e.registerHandler(handler); err != nil {
+			e.routeErrors = append(e.routeErrors, err)
+		}
+	}
+	e.handlersMu.Unlock()
 
-		// Adapt our handler to http.HandlerFunc.
-		httpHandler := e.adaptHandler(handler)
+	// Handlers registered after the spec was first served must still appear in it.
+	e.RefreshOpenAPI()
+	return e
+}
 
-		// Build middleware stack: handler middleware + auth middleware (if handler requires it)
-		handlerSpec := handler.Spec()
-		middleware := handler.Middleware()
+// AddHandler registers a handler while the engine may already be serving, for plugin
+// systems and other routes added at runtime. It is safe to call concurrently with
+// requests and with other AddHandler calls. A route conflict is returned rather than
+// reported by Validate, and the handler is not registered. On success the cached
+// OpenAPI spec is refreshed to include the new operation.
+func (e *Engine) AddHandler(handler Endpoint) error {
+	e.ensureDefaultHandlers()
 
-		// Add authentication middleware if handler requires it
-		if handlerSpec.RequiresAuth && e.extractIdentity != nil {
-			authMiddleware := e.buildAuthMiddleware()
-			middleware = append(middleware, authMiddleware)
+	e.handlersMu.Lock()
+	err := e.registerHandler(handler)
+	e.handlersMu.Unlock()
+	if err != nil {
+		return err
+	}
 
-			// Add authorization middleware if handler has scope/role requirements
-			if len(handlerSpec.ScopeGroups) > 0 || len(handlerSpec.RoleGroups) > 0 {
-				authzMiddleware := e.buildAuthorizationMiddleware(handler)
-				middleware = append(middleware, authzMiddleware)
-			}
+	e.RefreshOpenAPI()
+	return nil
+}
 
-			// Add usage limit middleware if handler has usage limits
-			if len(handlerSpec.UsageLimits) > 0 {
-				usageLimitMiddleware := e.buildUsageLimitMiddleware(handler)
-				middleware = append(middleware, usageLimitMiddleware)
-			}
+// registerHandler wraps handler with its middleware and adds it to the router and the
+// OpenAPI handler set. The caller must hold handlersMu.
+func (e *Engine) registerHandler(handler Endpoint) error {
+	// Let the handler pick an optimized Process path now that its configuration is final.
+	if preparer, ok := handler.(fastPathPreparer); ok {
+		preparer.prepareFastPath()
+	}
+
+	// Adapt our handler to http.HandlerFunc.
+	httpHandler := e.adaptHandler(handler)
+
+	// Build middleware stack: handler middleware + auth middleware (if handler requires it)
+	handlerSpec := handler.Spec()
+	middleware := handler.Middleware()
+
+	// Add authentication middleware if handler requires it
+	if handlerSpec.RequiresAuth && e.extractIdentity != nil {
+		authMiddleware := e.buildAuthMiddleware()
+		middleware = append(middleware, authMiddleware)
+
+		// Add authorization middleware if handler has scope/role requirements
+		if len(handlerSpec.ScopeGroups) > 0 || len(handlerSpec.RoleGroups) > 0 {
+			authzMiddleware := e.buildAuthorizationMiddleware(handler)
+			middleware = append(middleware, authzMiddleware)
 		}
 
-		// Compose all middleware: global + handler-specific
-		allMiddleware := make([]func(http.Handler) http.Handler, 0, len(e.globalMiddleware)+len(middleware))
-		allMiddleware = append(allMiddleware, e.globalMiddleware...)
-		allMiddleware = append(allMiddleware, middleware...)
-		wrappedHandler := chain(httpHandler, allMiddleware...)
+		// Add usage limit middleware if handler has usage limits
+		if len(handlerSpec.UsageLimits) > 0 {
+			usageLimitMiddleware := e.buildUsageLimitMiddleware(handler)
+			middleware = append(middleware, usageLimitMiddleware)
+		}
+	} else if handlerSpec.OptionalAuth && e.extractIdentity != nil {
+		middleware = append(middleware, e.buildOptionalAuthMiddleware())
+	}
 
-		// Register with stdlib mux using "METHOD /path" pattern
-		pattern := handlerSpec.Method + " " + handlerSpec.Path
-		e.mux.Handle(pattern, wrappedHandler)
+	// Compose all middleware: global + handler-specific
+	allMiddleware := make([]func(http.Handler) http.Handler, 0, len(e.globalMiddleware)+len(middleware))
+	allMiddleware = append(allMiddleware, e.globalMiddleware...)
+	allMiddleware = append(allMiddleware, middleware...)
+	var wrappedHandler http.Handler = e.resolveProxyHeaders(e.injectLogger(e.interceptRequests(chain(httpHandler, allMiddleware...), handlerSpec.Name)))
+	if !handlerSpec.IsStream {
+		wrappedHandler = e.limitRequestDuration(wrappedHandler)
+	}
+	if e.panicMode == PanicRecover {
+		wrappedHandler = recoverPanics(wrappedHandler, handlerSpec.Name)
+	}
+	wrappedHandler = trackResponses(wrappedHandler)
 
-		// Emit handler registered event
-		capitan.Debug(e.ctx, HandlerRegistered,
+	// Register with the router; a conflicting handler is left out of the router and the spec.
+	if err := e.registerRoute(handlerSpec.Name, handlerSpec.Method, handlerSpec.Host, handlerSpec.Path, wrappedHandler); err != nil {
+		capitan.Error(e.ctx, DuplicateRoute,
 			HandlerNameKey.Field(handlerSpec.Name),
 			MethodKey.Field(handlerSpec.Method),
 			PathKey.Field(handlerSpec.Path),
+			ErrorKey.Field(err.Error()),
 		)
+		return err
 	}
-	return e
+
+	// Store handler for OpenAPI generation.
+	e.handlers = append(e.handlers, handler)
+
+	// Surface type introspection failures that would leave schemas empty
+	if reporter, ok := handler.(scanErrorReporter); ok {
+		for _, scanErr := range reporter.ScanErrors() {
+			capitan.Warn(e.ctx, HandlerTypeScanFailed,
+				HandlerNameKey.Field(handlerSpec.Name),
+				ErrorKey.Field(scanErr.Error()),
+			)
+		}
+	}
+
+	// Emit handler registered event
+	capitan.Debug(e.ctx, HandlerRegistered,
+		HandlerNameKey.Field(handlerSpec.Name),
+		MethodKey.Field(handlerSpec.Method),
+		PathKey.Field(handlerSpec.Path),
+	)
+	return nil
+}
+
+// RefreshOpenAPI discards the cached OpenAPI spec so the next request to /openapi or
+// /openapi.yaml regenerates it. WithHandlers calls it automatically; call it yourself
+// after changing the engine spec (e.g. WithTag) once the spec has been served.
+// It is safe to call while the engine is serving requests.
+func (e *Engine) RefreshOpenAPI() {
+	e.openAPIMu.Lock()
+	defer e.openAPIMu.Unlock()
+	e.openAPICached = false
+	e.cachedOpenAPISpec = nil
+	e.cachedOpenAPIYAML = nil
 }
 
 // buildAuthMiddleware creates authentication middleware using the extractIdentity callback.
@@ -221,6 +610,33 @@ func (e *Engine) buildAuthMiddleware() func(http.Handler) http.Handler {
 	}
 }
 
+// buildOptionalAuthMiddleware creates middleware that extracts identity when possible.
+// Extraction failures are not errors; the request continues anonymously.
+func (e *Engine) buildOptionalAuthMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			identity, err := e.extractIdentity(ctx, r)
+			if err != nil || identity == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx = context.WithValue(ctx, identityContextKey, identity)
+
+			capitan.Debug(ctx, AuthenticationSucceeded,
+				MethodKey.Field(r.Method),
+				PathKey.Field(r.URL.Path),
+				IdentityIDKey.Field(identity.ID()),
+				TenantIDKey.Field(identity.TenantID()),
+			)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
 // identityContextKey is the context key for storing Identity.
 type contextKey string
 
@@ -358,45 +774,91 @@ func (*Engine) buildUsageLimitMiddleware(handler Endpoint) func(http.Handler) ht
 	}
 }
 
-// ensureDefaultHandlers sets up OpenAPI spec and docs handlers at /openapi and /docs (once).
+// ensureDefaultHandlers sets up OpenAPI spec and docs handlers at /openapi, /openapi.yaml and /docs (once).
 func (e *Engine) ensureDefaultHandlers() {
 	e.defaultHandlersOnce.Do(func() {
+		e.handlersMu.Lock()
+		defer e.handlersMu.Unlock()
 		e.registerDefaultHandlers()
 	})
 }
 
-// registerDefaultHandlers sets up OpenAPI spec and docs handlers at /openapi and /docs.
-func (e *Engine) registerDefaultHandlers() {
-	// OpenAPI spec handler at /openapi
-	e.mux.HandleFunc("GET /openapi", func(w http.ResponseWriter, r *http.Request) {
-		// Generate and cache spec on first request (cached forever after)
-		e.openAPIOnce.Do(func() {
-			spec := e.GenerateOpenAPI(nil)
-			data, err := json.MarshalIndent(spec, "", "  ")
-			if err != nil {
-				// Marshal failure is a programming error - spec remains nil
-				return
-			}
-			e.cachedOpenAPISpec = data
-		})
-
-		if e.cachedOpenAPISpec == nil {
-			http.Error(w, "failed to generate OpenAPI spec", http.StatusInternalServerError)
+// registerDefaultRoute registers a built-in route, recording any conflict for Validate.
+// Protection is checked per request because the routes are registered by the first
+// WithHandlers call, which may come before WithProtectedDocs.
+func (e *Engine) registerDefaultRoute(name, path string, fn http.HandlerFunc) {
+	protected := e.buildAuthMiddleware()(fn)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !e.protectedDocs {
+			fn(w, r)
 			return
 		}
+		if e.extractIdentity == nil {
+			writeError(r.Context(), w, ErrUnauthorized, name)
+			return
+		}
+		protected.ServeHTTP(w, r)
+	})
+	if err := e.registerRoute(name, http.MethodGet, "", path, e.resolveProxyHeaders(e.interceptRequests(handler, name))); err != nil {
+		e.routeErrors = append(e.routeErrors, err)
+	}
+}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		if _, err := w.Write(e.cachedOpenAPISpec); err != nil {
-			capitan.Warn(r.Context(), ResponseWriteError,
-				HandlerNameKey.Field("openapi"),
-				ErrorKey.Field(err.Error()),
-			)
+// cachedOpenAPI returns the JSON and YAML encodings of the OpenAPI spec, generating them
+// on first use and again after RefreshOpenAPI, so /openapi and /openapi.yaml serve the
+// same document.
+func (e *Engine) cachedOpenAPI(ctx context.Context) (jsonSpec, yamlSpec []byte) {
+	e.openAPIMu.Lock()
+	defer e.openAPIMu.Unlock()
+	if !e.openAPICached {
+		doc := e.GenerateOpenAPI(nil)
+		// Marshal failure is a programming error - that encoding remains nil
+		if data, err := json.MarshalIndent(doc, "", "  "); err == nil {
+			e.cachedOpenAPISpec = data
 		}
+		if data, err := yaml.Marshal(doc); err == nil {
+			e.cachedOpenAPIYAML = data
+		}
+		e.openAPICached = true
+		capitan.Info(ctx, OpenAPIGenerated)
+	}
+	return e.cachedOpenAPISpec, e.cachedOpenAPIYAML
+}
+
+// writeOpenAPISpec writes a cached encoding of the OpenAPI spec for the named route.
+func writeOpenAPISpec(w http.ResponseWriter, r *http.Request, name, contentType string, data []byte) {
+	if data == nil {
+		http.Error(w, "failed to generate OpenAPI spec", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		capitan.Warn(r.Context(), ResponseWriteError,
+			HandlerNameKey.Field(name),
+			ErrorKey.Field(err.Error()),
+		)
+	}
+}
+
+// registerDefaultHandlers sets up OpenAPI spec and docs handlers at /openapi,
+// /openapi.yaml and /docs.
+func (e *Engine) registerDefaultHandlers() {
+	// OpenAPI spec handler at /openapi
+	e.registerDefaultRoute("openapi", "/openapi", func(w http.ResponseWriter, r *http.Request) {
+		jsonSpec, _ := e.cachedOpenAPI(r.Context())
+		writeOpenAPISpec(w, r, "openapi", "application/json", jsonSpec)
+	})
+
+	// The same spec as YAML at /openapi.yaml
+	e.registerDefaultRoute("openapi.yaml", "/openapi.yaml", func(w http.ResponseWriter, r *http.Request) {
+		_, yamlSpec := e.cachedOpenAPI(r.Context())
+		writeOpenAPISpec(w, r, "openapi.yaml", "application/yaml", yamlSpec)
 	})
 
 	// Docs handler at /docs
-	e.mux.HandleFunc("GET /docs", func(w http.ResponseWriter, r *http.Request) {
+	e.registerDefaultRoute("docs", "/docs", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		w.WriteHeader(http.StatusOK)
 
@@ -423,13 +885,21 @@ func (e *Engine) registerDefaultHandlers() {
 }
 
 // adaptHandler converts a Endpoint to http.HandlerFunc.
-func (*Engine) adaptHandler(handler Endpoint) http.HandlerFunc {
+func (e *Engine) adaptHandler(handler Endpoint) http.HandlerFunc {
 	handlerSpec := handler.Spec()
 
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx := r.Context()
 		startTime := time.Now()
 
+		// Make the engine's settings available to the handler. Streams always need them,
+		// to end when the engine shuts down and not only when the client leaves.
+		ctx := r.Context()
+		if handlerSpec.IsStream {
+			ctx = context.WithValue(ctx, engineContextKey, e)
+		} else {
+			ctx = e.withRequestSettings(ctx)
+		}
+
 		// Emit request received event
 		capitan.Debug(ctx, RequestReceived,
 			MethodKey.Field(r.Method),
@@ -437,37 +907,87 @@ func (*Engine) adaptHandler(handler Endpoint) http.HandlerFunc {
 			HandlerNameKey.Field(handlerSpec.Name),
 		)
 
+		// Track remaining usage of deprecated operations
+		if handlerSpec.Deprecated {
+			capitan.Warn(ctx, HandlerDeprecated,
+				MethodKey.Field(r.Method),
+				PathKey.Field(r.URL.Path),
+				HandlerNameKey.Field(handlerSpec.Name),
+			)
+		}
+
 		// Handler processes and writes response
 		status, err := handler.Process(ctx, r, w)
 
-		// Calculate duration
-		durationMs := time.Since(startTime).Milliseconds()
+		// Calculate duration from when the request entered the middleware chain, and
+		// split out the handler function's share when Process could record it
+		fields := []capitan.Field{
+			MethodKey.Field(r.Method),
+			PathKey.Field(r.URL.Path),
+			HandlerNameKey.Field(handlerSpec.Name),
+			StatusCodeKey.Field(status),
+		}
+		if state := trackedResponse(w); state != nil {
+			fields = append(fields,
+				DurationMsKey.Field(time.Since(state.start).Milliseconds()),
+				HandlerDurationMsKey.Field(state.fnTime.Milliseconds()),
+			)
+		} else {
+			fields = append(fields, DurationMsKey.Field(time.Since(startTime).Milliseconds()))
+		}
 
 		// Emit request completion event
 		if err != nil {
-			capitan.Error(ctx, RequestFailed,
-				MethodKey.Field(r.Method),
-				PathKey.Field(r.URL.Path),
-				HandlerNameKey.Field(handlerSpec.Name),
-				StatusCodeKey.Field(status),
-				DurationMsKey.Field(durationMs),
-				ErrorKey.Field(err.Error()),
-			)
+			capitan.Error(ctx, RequestFailed, append(fields, ErrorKey.Field(err.Error()))...)
 		} else {
-			capitan.Info(ctx, RequestCompleted,
-				MethodKey.Field(r.Method),
-				PathKey.Field(r.URL.Path),
-				HandlerNameKey.Field(handlerSpec.Name),
-				StatusCodeKey.Field(status),
-				DurationMsKey.Field(durationMs),
-			)
+			capitan.Info(ctx, RequestCompleted, fields...)
 		}
 	}
 }
 
+// engineContextKey carries the serving Engine, whose request-time settings (error detail
+// mode, validation formatter, encoders, envelope, stream shutdown) handlers read from a
+// single context value rather than one per setting.
+const engineContextKey contextKey = "rocco_engine"
+
+// withRequestSettings returns ctx carrying e when any setting handlers read differs from
+// its default, so requests on a default engine pay nothing for them.
+func (e *Engine) withRequestSettings(ctx context.Context) context.Context {
+	if e.validationErrorFormatter == nil && e.errorDetailMode == DetailsDev &&
+		len(e.encoders) == 0 && e.responseEnvelope == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, engineContextKey, e)
+}
+
+// engineFromContext returns the Engine serving ctx's request, or nil when there is none
+// (e.g. Process called directly) or all its settings are defaults.
+func engineFromContext(ctx context.Context) *Engine {
+	e, _ := ctx.Value(engineContextKey).(*Engine)
+	return e
+}
+
+// errorDetailModeFrom returns the error detail mode of the Engine serving ctx's request.
+func errorDetailModeFrom(ctx context.Context) ErrorDetailMode {
+	var mode ErrorDetailMode
+	if e := engineFromContext(ctx); e != nil {
+		mode = e.errorDetailMode
+	}
+	return mode
+}
+
 // Start begins listening for HTTP requests.
 // This method blocks until the server is shutdown.
 func (e *Engine) Start() error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	if e.strictSpec {
+		if err := e.ValidateSpec(); err != nil {
+			return err
+		}
+	}
+
 	// Emit engine starting event
 	capitan.Info(e.ctx, EngineStarting,
 		HostKey.Field(e.config.Host),
@@ -475,21 +995,88 @@ func (e *Engine) Start() error {
 		AddressKey.Field(e.server.Addr),
 	)
 
-	err := e.server.ListenAndServe()
+	// Bind explicitly so the real address (e.g. for port 0) is known before serving
+	addr := e.server.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("server error: %w", err)
+	}
+
+	return e.serve(l)
+}
+
+// Serve accepts HTTP requests on l instead of listening on the configured host and port.
+// Use it for Unix domain sockets, systemd socket activation, or custom listeners
+// (TLS, proxy protocol). The listener is closed when Serve returns.
+// This method blocks until the server is shutdown; Shutdown works as with Start.
+func (e *Engine) Serve(l net.Listener) error {
+	if err := e.Validate(); err != nil {
+		_ = l.Close()
+		return err
+	}
+	if e.strictSpec {
+		if err := e.ValidateSpec(); err != nil {
+			_ = l.Close()
+			return err
+		}
+	}
+
+	// Emit engine starting event
+	capitan.Info(e.ctx, EngineStarting,
+		HostKey.Field(e.config.Host),
+		PortKey.Field(e.config.Port),
+		AddressKey.Field(l.Addr().String()),
+	)
+
+	return e.serve(l)
+}
+
+// serve notifies the listening callback and accepts requests on l until shutdown.
+func (e *Engine) serve(l net.Listener) error {
+	addr := l.Addr().String()
+	e.boundAddr.Store(&addr)
+	if e.onListening != nil {
+		e.onListening(addr)
+	}
+
+	err := e.server.Serve(l)
 	if err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("server error: %w", err)
 	}
 	return nil
 }
 
+// Addr returns the address the engine is listening on, including the port assigned
+// by the OS when configured with port 0. It returns "" until Start or Serve has bound
+// a listener; use WithOnListening to be notified when that happens.
+func (e *Engine) Addr() string {
+	if addr := e.boundAddr.Load(); addr != nil {
+		return *addr
+	}
+	return ""
+}
+
 // Shutdown performs a graceful shutdown of the engine.
 func (e *Engine) Shutdown(ctx context.Context) error {
 	// Emit shutdown started event
 	capitan.Info(ctx, EngineShutdownStarted)
 
+	// End active streams first; the server would otherwise wait for their clients to leave
+	e.stopStreams()
+
 	// Shutdown HTTP server (waits for active connections to finish)
 	err := e.server.Shutdown(ctx)
 
+	// Run shutdown hooks, most recently registered first
+	for i := len(e.shutdownHooks) - 1; i >= 0; i-- {
+		if hookErr := e.shutdownHooks[i](ctx); hookErr != nil {
+			err = errors.Join(err, hookErr)
+		}
+	}
+
 	// Cancel engine context
 	e.cancel()
 
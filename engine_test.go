@@ -4,12 +4,22 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/go-playground/validator/v10"
+	"github.com/zoobzio/capitan"
 	"github.com/zoobzio/openapi"
+	"gopkg.in/yaml.v3"
 )
 
 // newTestEngine creates an engine for testing without authentication.
@@ -191,8 +201,51 @@ func TestEngine_RegisterOpenAPIHandler(t *testing.T) {
 	}
 }
 
+func TestEngine_ShutdownHooks(t *testing.T) {
+	engine := NewEngine("localhost", 0, nil)
+
+	var order []string
+	errFlush := errors.New("flush failed")
+	errClose := errors.New("close failed")
+	engine.
+		WithShutdownHook(func(_ context.Context) error {
+			order = append(order, "first")
+			return errClose
+		}).
+		WithShutdownHook(func(ctx context.Context) error {
+			order = append(order, "second")
+			if engine.ctx.Err() != nil {
+				t.Error("hooks should run before the engine context is cancelled")
+			}
+			if _, ok := ctx.Deadline(); !ok {
+				t.Error("hooks should receive the shutdown context")
+			}
+			return errFlush
+		}).
+		WithShutdownHook(func(_ context.Context) error {
+			order = append(order, "third")
+			return nil
+		})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := engine.Shutdown(ctx)
+	if !errors.Is(err, errFlush) || !errors.Is(err, errClose) {
+		t.Errorf("expected joined hook errors, got %v", err)
+	}
+	if strings.Join(order, ",") != "third,second,first" {
+		t.Errorf("expected hooks in reverse registration order, got %v", order)
+	}
+	if engine.ctx.Err() == nil {
+		t.Error("expected engine context to be cancelled after shutdown")
+	}
+}
+
 func TestEngine_Shutdown(t *testing.T) {
-	engine := NewEngine("localhost", 0, nil) // Use random port
+	listening := make(chan string, 1)
+	// Port 0 picks a random port
+	engine := NewEngine("localhost", 0, nil).WithOnListening(func(addr string) { listening <- addr })
 
 	// Start server in background
 	serverErr := make(chan error, 1)
@@ -200,8 +253,12 @@ func TestEngine_Shutdown(t *testing.T) {
 		serverErr <- engine.Start()
 	}()
 
-	// Give server time to start
-	time.Sleep(100 * time.Millisecond)
+	// Wait for the listener to be bound
+	select {
+	case <-listening:
+	case err := <-serverErr:
+		t.Fatalf("server failed to start: %v", err)
+	}
 
 	// Shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
@@ -224,6 +281,168 @@ func TestEngine_Shutdown(t *testing.T) {
 	}
 }
 
+func TestEngine_WithOnListening(t *testing.T) {
+	listening := make(chan string, 1)
+	engine := NewEngine("127.0.0.1", 0, nil).WithOnListening(func(addr string) {
+		listening <- addr
+	})
+	engine.WithHandlers(NewHandler[NoBody, testOutput](
+		"ready",
+		"GET",
+		"/ready",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{Message: "ready"}, nil
+		},
+	))
+
+	if got := engine.Addr(); got != "" {
+		t.Errorf("expected empty address before listening, got %q", got)
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- engine.Start()
+	}()
+
+	var addr string
+	select {
+	case addr = <-listening:
+	case err := <-serverErr:
+		t.Fatalf("server failed to start: %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("listening callback was not invoked")
+	}
+	if strings.HasSuffix(addr, ":0") {
+		t.Fatalf("expected the assigned port, got %q", addr)
+	}
+	if got := engine.Addr(); got != addr {
+		t.Errorf("expected Addr %q, got %q", addr, got)
+	}
+
+	// The address is usable immediately, without waiting.
+	resp, err := http.Get("http://" + addr + "/ready")
+	if err != nil {
+		t.Fatalf("request to reported address failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200, got %d", resp.StatusCode)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := engine.Shutdown(ctx); err != nil {
+		t.Errorf("unexpected shutdown error: %v", err)
+	}
+	if err := <-serverErr; err != nil {
+		t.Errorf("unexpected server error: %v", err)
+	}
+}
+
+func TestEngine_Start_ListenError(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	port := l.Addr().(*net.TCPAddr).Port
+
+	called := false
+	engine := NewEngine("127.0.0.1", port, nil).WithOnListening(func(string) { called = true })
+	if err := engine.Start(); err == nil {
+		t.Fatal("expected error when the port is already in use")
+	}
+	if called {
+		t.Error("listening callback should not run when binding fails")
+	}
+}
+
+func TestEngine_Serve_UnixSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "rocco.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+
+	engine := NewEngine("", 0, nil)
+	handler := NewHandler[NoBody, testOutput](
+		"socket",
+		"GET",
+		"/socket",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{Message: "over unix"}, nil
+		},
+	)
+	engine.WithHandlers(handler)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- engine.Serve(listener)
+	}()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socketPath)
+			},
+		},
+		Timeout: 2 * time.Second,
+	}
+
+	resp, err := client.Get("http://unix/socket")
+	if err != nil {
+		t.Fatalf("request over unix socket failed: %v", err)
+	}
+	var output testOutput
+	json.NewDecoder(resp.Body).Decode(&output)
+	resp.Body.Close()
+
+	if output.Message != "over unix" {
+		t.Errorf("expected message 'over unix', got %q", output.Message)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	if err := engine.Shutdown(ctx); err != nil {
+		t.Errorf("unexpected shutdown error: %v", err)
+	}
+
+	select {
+	case err := <-serverErr:
+		if err != nil {
+			t.Errorf("unexpected server error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("server did not shut down in time")
+	}
+}
+
+func TestEngine_Serve_StrictSpecFails(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	engine := NewEngine("", 0, nil).WithStrictSpec()
+	handler := NewHandler[NoBody, testOutput](
+		"tagged",
+		"GET",
+		"/tagged",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	).WithTags("undeclared")
+	engine.WithHandlers(handler)
+
+	if err := engine.Serve(listener); err == nil {
+		t.Fatal("expected Serve to fail with invalid spec")
+	}
+	if _, err := listener.Accept(); err == nil {
+		t.Error("expected listener to be closed")
+	}
+}
+
 func TestEngine_Register_HandlerMiddleware(t *testing.T) {
 	engine := newTestEngine()
 
@@ -550,6 +769,52 @@ func TestEngine_DefaultHandlers_OpenAPI(t *testing.T) {
 	}
 }
 
+func TestEngine_WithTagGroup(t *testing.T) {
+	engine := newTestEngine().
+		WithTag("users", "User accounts").
+		WithTag("billing", "Invoices").
+		WithTagGroup("Account", "users").
+		WithTagGroup("Data", "reports").
+		WithTagGroup("Account", "users", "billing")
+	engine.WithHandlers(newRouteHandler("list-users", "GET", "/users"))
+
+	w := httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, httptest.NewRequest("GET", "/openapi", nil))
+
+	var doc struct {
+		OpenAPI   string     `json:"openapi"`
+		TagGroups []TagGroup `json:"x-tagGroups"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("expected the spec fields alongside x-tagGroups, got openapi %q", doc.OpenAPI)
+	}
+	want := []TagGroup{{Name: "Account", Tags: []string{"users", "billing"}}, {Name: "Data", Tags: []string{"reports"}}}
+	if !reflect.DeepEqual(doc.TagGroups, want) {
+		t.Errorf("expected x-tagGroups %+v, got %+v", want, doc.TagGroups)
+	}
+
+	// The document GenerateOpenAPI returns carries the groups and encodes them too.
+	data, err := json.Marshal(engine.GenerateOpenAPI(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.TagGroups = nil
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(doc.TagGroups, want) {
+		t.Errorf("expected GenerateOpenAPI to encode x-tagGroups %+v, got %+v", want, doc.TagGroups)
+	}
+
+	err = engine.ValidateSpec()
+	if err == nil || !strings.Contains(err.Error(), `tag group "Data": tag "reports" is not documented`) {
+		t.Errorf("expected undeclared grouped tag to be reported, got %v", err)
+	}
+}
+
 func TestEngine_DefaultHandlers_OpenAPI_Cached(t *testing.T) {
 	engine := newTestEngine()
 
@@ -579,6 +844,118 @@ func TestEngine_DefaultHandlers_OpenAPI_Cached(t *testing.T) {
 	}
 }
 
+func TestEngine_RefreshOpenAPI(t *testing.T) {
+	setupSyncMode(t)
+
+	var generated int
+	listener := capitan.Hook(OpenAPIGenerated, func(context.Context, *capitan.Event) {
+		generated++
+	})
+	defer listener.Close()
+
+	engine := newTestEngine()
+	engine.WithHandlers(newRouteHandler("first", "GET", "/first"))
+
+	fetch := func() string {
+		w := httptest.NewRecorder()
+		engine.mux.ServeHTTP(w, httptest.NewRequest("GET", "/openapi", nil))
+		return w.Body.String()
+	}
+
+	fetch()
+	fetch()
+	if generated != 1 {
+		t.Fatalf("expected the spec to be generated once, got %d", generated)
+	}
+
+	// Handlers registered after the spec was served must not be silently missing.
+	engine.WithHandlers(newRouteHandler("second", "GET", "/second"))
+	if body := fetch(); !strings.Contains(body, "/second") {
+		t.Error("expected late handler in regenerated spec")
+	}
+
+	engine.WithTag("late", "Declared after the spec was served")
+	if body := fetch(); strings.Contains(body, "Declared after") {
+		t.Error("expected cached spec until RefreshOpenAPI")
+	}
+	engine.RefreshOpenAPI()
+	if body := fetch(); !strings.Contains(body, "Declared after") {
+		t.Error("expected refreshed spec to include the new tag")
+	}
+
+	if generated != 3 {
+		t.Errorf("expected 3 generations, got %d", generated)
+	}
+}
+
+func TestEngine_RefreshOpenAPI_Concurrent(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(newRouteHandler("test", "GET", "/test"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				path := "/openapi"
+				if j%2 == 1 {
+					path = "/openapi.yaml"
+				}
+				w := httptest.NewRecorder()
+				engine.mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+				if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "/test") {
+					t.Errorf("%s: expected complete spec during refresh, got %d", path, w.Code)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		engine.RefreshOpenAPI()
+	}
+	wg.Wait()
+}
+
+func TestEngine_DefaultHandlers_OpenAPIYAML(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithTag("items", "Item operations").WithTagGroup("Catalog", "items")
+	engine.WithHandlers(newRouteHandler("test", "GET", "/test").WithTags("items"))
+
+	w := httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, httptest.NewRequest("GET", "/openapi.yaml", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/yaml" {
+		t.Errorf("expected Content-Type 'application/yaml', got %q", ct)
+	}
+
+	fromYAML, err := openapi.FromYAML(w.Body.Bytes())
+	if err != nil {
+		t.Fatalf("failed to parse YAML spec: %v", err)
+	}
+	w = httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, httptest.NewRequest("GET", "/openapi", nil))
+	fromJSON, err := openapi.FromJSON(w.Body.Bytes())
+	if err != nil {
+		t.Fatalf("failed to parse JSON spec: %v", err)
+	}
+	if !reflect.DeepEqual(fromYAML, fromJSON) {
+		t.Error("expected YAML and JSON endpoints to serve the same spec")
+	}
+
+	var doc struct {
+		TagGroups []TagGroup `yaml:"x-tagGroups"`
+	}
+	if err := yaml.Unmarshal(engine.cachedOpenAPIYAML, &doc); err != nil {
+		t.Fatalf("failed to parse YAML spec: %v", err)
+	}
+	if len(doc.TagGroups) != 1 || doc.TagGroups[0].Name != "Catalog" {
+		t.Errorf("expected x-tagGroups in YAML spec, got %+v", doc.TagGroups)
+	}
+}
+
 func TestEngine_DefaultHandlers_Docs(t *testing.T) {
 	engine := newTestEngine()
 
@@ -614,6 +991,45 @@ func TestEngine_DefaultHandlers_Docs(t *testing.T) {
 	}
 }
 
+func TestEngine_WithProtectedDocs(t *testing.T) {
+	engine := NewEngine("localhost", 8080, func(_ context.Context, r *http.Request) (Identity, error) {
+		if r.Header.Get("Authorization") == "Bearer valid-token" {
+			return &testIdentity{id: "user-123"}, nil
+		}
+		return nil, errors.New("invalid token")
+	})
+	// Registered before protection is enabled: the default routes already exist.
+	engine.WithHandlers(newRouteHandler("test", "GET", "/test"))
+	engine.WithProtectedDocs()
+
+	for _, path := range []string{"/openapi", "/openapi.yaml", "/docs"} {
+		w := httptest.NewRecorder()
+		engine.mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected status 401 without credentials, got %d", path, w.Code)
+		}
+
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer valid-token")
+		w = httptest.NewRecorder()
+		engine.mux.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected status 200 with credentials, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestEngine_WithProtectedDocs_NoExtractor(t *testing.T) {
+	engine := newTestEngine().WithProtectedDocs()
+	engine.WithHandlers(newRouteHandler("test", "GET", "/test"))
+
+	w := httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, httptest.NewRequest("GET", "/openapi", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401 without an identity extractor, got %d", w.Code)
+	}
+}
+
 // Tests for authentication middleware
 
 func TestEngine_AuthMiddleware_Success(t *testing.T) {
@@ -865,6 +1281,268 @@ func TestWriteError_EncodeFails(t *testing.T) {
 	}
 }
 
+func TestEngine_WithMaxRequestDuration(t *testing.T) {
+	setupSyncMode(t)
+
+	var timeouts sync.Map
+	listener := capitan.Hook(HandlerTimeout, func(_ context.Context, e *capitan.Event) {
+		name, _ := HandlerNameKey.From(e)
+		timeouts.Store(name, true)
+	})
+	defer listener.Close()
+
+	var streamBounded bool
+	engine := newTestEngine().WithMaxRequestDuration(20 * time.Millisecond)
+	if engine.server.ReadHeaderTimeout != 10*time.Second {
+		t.Errorf("expected a 10s read header timeout, got %v", engine.server.ReadHeaderTimeout)
+	}
+	engine.WithHandlers(
+		NewHandler[NoBody, testOutput]("runaway", "GET", "/runaway", func(req *Request[NoBody]) (testOutput, error) {
+			select {
+			case <-time.After(200 * time.Millisecond):
+			case <-req.Context.Done():
+			}
+			return testOutput{Message: "too late"}, nil
+		}),
+		NewHandler[NoBody, testOutput]("oblivious", "GET", "/oblivious", func(_ *Request[NoBody]) (testOutput, error) {
+			time.Sleep(40 * time.Millisecond)
+			return testOutput{Message: "too late"}, nil
+		}),
+		NewHandler[NoBody, testOutput]("quick", "GET", "/quick", func(req *Request[NoBody]) (testOutput, error) {
+			if _, ok := req.Context.Deadline(); !ok {
+				return testOutput{}, errors.New("expected the ceiling on req.Context")
+			}
+			return testOutput{Message: "fast"}, nil
+		}),
+		NewStreamHandler[NoBody, testOutput]("events", "GET", "/events", func(req *Request[NoBody], _ Stream[testOutput]) error {
+			_, streamBounded = req.Context.Deadline()
+			return nil
+		}),
+	)
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/runaway", nil))
+	if w.Code != http.StatusGatewayTimeout || !strings.Contains(w.Body.String(), ErrGatewayTimeout.Code()) {
+		t.Errorf("expected 504, got %d: %s", w.Code, w.Body.String())
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("response was not bounded by the ceiling (took %v)", elapsed)
+	}
+	if _, ok := timeouts.Load("runaway"); !ok {
+		t.Error("expected a HandlerTimeout event")
+	}
+
+	// A handler ignoring its context is not abandoned, but its late result is not sent.
+	w = httptest.NewRecorder()
+	engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/oblivious", nil))
+	if w.Code != http.StatusGatewayTimeout || strings.Contains(w.Body.String(), "too late") {
+		t.Errorf("expected 504 once the handler returned, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, ok := timeouts.Load("oblivious"); !ok {
+		t.Error("expected a HandlerTimeout event for the late handler")
+	}
+
+	// The ceiling alone does not turn a client disconnect into an abort.
+	aborted := false
+	abortListener := capitan.Hook(RequestAborted, func(context.Context, *capitan.Event) { aborted = true })
+	defer abortListener.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w = httptest.NewRecorder()
+	engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/quick", nil).WithContext(ctx))
+	if w.Code != http.StatusOK || aborted {
+		t.Errorf("expected the handler's result after a disconnect, got %d (aborted=%v)", w.Code, aborted)
+	}
+
+	for _, path := range []string{"/quick", "/events"} {
+		w := httptest.NewRecorder()
+		engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d: %s", path, w.Code, w.Body.String())
+		}
+	}
+	if streamBounded {
+		t.Error("expected streams to opt out of the ceiling")
+	}
+}
+
+func TestEngine_WithErrorDetailMode(t *testing.T) {
+	cause := fmt.Errorf("query users: %w", errors.New("connection refused to 10.0.0.5"))
+	handlerErrs := map[string]error{
+		"/server": ErrServiceUnavailable.WithMessage("replica lag on db-2").
+			WithDetails(ServiceUnavailableDetails{Reason: "db-2 lagging"}).WithCause(cause),
+		"/client": ErrNotFound.WithMessage("user 42 not found").
+			WithDetails(NotFoundDetails{Resource: "user"}).WithCause(cause),
+		"/maintenance": ErrServiceUnavailable.WithMessage("down for maintenance").
+			WithDetails(ServiceUnavailableDetails{Reason: "maintenance"}),
+	}
+
+	newEngine := func(mode ErrorDetailMode) *Engine {
+		engine := newTestEngine().WithErrorDetailMode(mode)
+		for path, err := range handlerErrs {
+			engine.WithHandlers(NewHandler[NoBody, testOutput](path, "GET", path, func(_ *Request[NoBody]) (testOutput, error) {
+				return testOutput{}, err
+			}).WithErrors(ErrServiceUnavailable, ErrNotFound))
+		}
+		return engine
+	}
+
+	tests := []struct {
+		name    string
+		mode    ErrorDetailMode
+		path    string
+		message string
+		details bool
+		cause   bool
+	}{
+		{"prod hides 5xx messages set with a cause", DetailsProd, "/server", "service unavailable", true, false},
+		{"prod keeps declared 5xx errors", DetailsProd, "/maintenance", "down for maintenance", true, false},
+		{"prod keeps 4xx messages", DetailsProd, "/client", "user 42 not found", true, false},
+		{"dev exposes 5xx specifics", DetailsDev, "/server", "replica lag on db-2", true, true},
+		{"dev exposes 4xx cause", DetailsDev, "/client", "user 42 not found", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newEngine(tt.mode).mux.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+
+			var resp errorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("invalid error body: %v", err)
+			}
+			if resp.Message != tt.message {
+				t.Errorf("expected message %q, got %q", tt.message, resp.Message)
+			}
+			if (resp.Details != nil) != tt.details {
+				t.Errorf("expected details present=%v, got %v", tt.details, resp.Details)
+			}
+			if tt.cause && resp.Cause != cause.Error() {
+				t.Errorf("expected cause %q, got %q", cause.Error(), resp.Cause)
+			}
+			if !tt.cause && strings.Contains(w.Body.String(), "10.0.0.5") {
+				t.Errorf("cause leaked into response: %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestNewErrorResponse_Undeclared(t *testing.T) {
+	err := ErrServiceUnavailable.WithMessage("replica lag on db-2").
+		WithDetails(ServiceUnavailableDetails{Reason: "db-2 lagging"})
+
+	resp := newErrorResponse(err, DetailsProd, false)
+	if resp.Message != "service unavailable" || resp.Details != nil {
+		t.Errorf("expected an undeclared 5xx error reduced to its code, got %+v", resp)
+	}
+	if resp = newErrorResponse(err, DetailsDev, false); resp.Message != "replica lag on db-2" || resp.Details == nil {
+		t.Errorf("expected dev mode to write the error in full, got %+v", resp)
+	}
+}
+
+func TestEngine_DefaultErrorDetailMode(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(NewHandler[NoBody, testOutput]("server", "GET", "/server", func(_ *Request[NoBody]) (testOutput, error) {
+		return testOutput{}, ErrServiceUnavailable.WithMessage("replica lag on db-2").
+			WithDetails(ServiceUnavailableDetails{Reason: "db-2 lagging"}).WithCause(errors.New("connection refused"))
+	}).WithErrors(ErrServiceUnavailable))
+
+	w := httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, httptest.NewRequest("GET", "/server", nil))
+
+	// Without WithErrorDetailMode, errors are written in full, cause chain included.
+	var resp errorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid error body: %v", err)
+	}
+	if resp.Message != "replica lag on db-2" || resp.Details == nil || resp.Cause != "connection refused" {
+		t.Errorf("expected the default mode to write the error in full, got %s", w.Body.String())
+	}
+}
+
+func TestEngine_WithResponseEnvelope(t *testing.T) {
+	engine := newTestEngine().WithResponseEnvelope(func(out any) any {
+		return map[string]any{"data": out}
+	})
+	engine.WithHandlers(
+		NewHandler[NoBody, testOutput]("get-item", "GET", "/item", func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{Message: "hello", Result: 7}, nil
+		}).WithSparseFields(),
+		NewHandler[NoBody, testOutput]("missing-item", "GET", "/missing", func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{}, ErrNotFound
+		}).WithErrors(ErrNotFound),
+	)
+
+	w := httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, httptest.NewRequest("GET", "/item", nil))
+	if got, want := w.Body.String(), `{"data":{"message":"hello","result":7}}`; got != want {
+		t.Errorf("expected body %s, got %s", want, got)
+	}
+
+	w = httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, httptest.NewRequest("GET", "/item?fields=result", nil))
+	if got, want := w.Body.String(), `{"data":{"result":7}}`; got != want {
+		t.Errorf("expected sparse body %s, got %s", want, got)
+	}
+
+	w = httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, httptest.NewRequest("GET", "/missing", nil))
+	if strings.Contains(w.Body.String(), `"data"`) {
+		t.Errorf("error responses should not be wrapped, got %s", w.Body.String())
+	}
+
+	schema := engine.GenerateOpenAPI(nil).Paths["/item"].Get.Responses["200"].Content["application/json"].Schema
+	data, ok := schema.Properties["data"]
+	if !ok || data.Ref != "#/components/schemas/testOutput" {
+		t.Errorf("expected success schema to wrap testOutput under data, got %+v", schema)
+	}
+}
+
+func TestEngine_WithResponseEnvelope_OutputType(t *testing.T) {
+	var received []string
+	engine := newTestEngine().
+		WithEncoder("text/csv", csvEncoder{}).
+		WithResponseEnvelope(func(out any) any {
+			received = append(received, fmt.Sprintf("%T", out))
+			return out // csvEncoder only encodes testOutput, so pass it through.
+		})
+	engine.WithHandlers(NewHandler[NoBody, testOutput]("get-item", "GET", "/item", func(_ *Request[NoBody]) (testOutput, error) {
+		return testOutput{Message: "hello", Result: 7}, nil
+	}))
+
+	for _, accept := range []string{"application/json", "text/csv"} {
+		req := httptest.NewRequest("GET", "/item", nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		engine.mux.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", accept, w.Code, w.Body.String())
+		}
+	}
+
+	// Both the JSON and the encoder path hand the envelope the handler's output value.
+	if want := []string{"rocco.testOutput", "rocco.testOutput"}; !slices.Equal(received, want) {
+		t.Errorf("expected the envelope to receive %v, got %v", want, received)
+	}
+}
+
+func TestEngine_WithResponseEnvelope_TimeFormat(t *testing.T) {
+	ts := time.Date(2024, time.March, 5, 12, 0, 0, 0, time.UTC)
+	engine := newTestEngine().WithResponseEnvelope(func(out any) any {
+		return map[string]any{"data": out, "version": 1}
+	})
+	engine.WithHandlers(NewHandler[NoBody, timeFormatOutput]("time", "GET", "/time", func(_ *Request[NoBody]) (timeFormatOutput, error) {
+		return timeFormatOutput{Created: ts, Day: ts, Plain: ts}, nil
+	}).WithSparseFields())
+
+	w := httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, httptest.NewRequest("GET", "/time?fields=created,day", nil))
+	want := fmt.Sprintf(`{"data":{"created":%d,"day":"2024-03-05"},"version":1}`, ts.Unix())
+	if got := w.Body.String(); got != want {
+		t.Errorf("expected time formats and sparse fields inside the envelope, want %s, got %s", want, got)
+	}
+}
+
 func TestEngine_DefaultHandlers_OpenAPI_WriteFails(t *testing.T) {
 	engine := newTestEngine()
 
@@ -927,3 +1605,129 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestEngine_OptionalAuthMiddleware(t *testing.T) {
+	engine := NewEngine("localhost", 8080, func(_ context.Context, r *http.Request) (Identity, error) {
+		if r.Header.Get("Authorization") == "Bearer valid-token" {
+			return &testIdentity{id: "user-123"}, nil
+		}
+		return nil, errors.New("no token")
+	})
+
+	handler := NewHandler[NoBody, testOutput](
+		"greet",
+		"GET",
+		"/greet",
+		func(req *Request[NoBody]) (testOutput, error) {
+			if req.Identity.ID() == "" {
+				return testOutput{Message: "Hello stranger"}, nil
+			}
+			return testOutput{Message: "Hello " + req.Identity.ID()}, nil
+		},
+	).WithOptionalAuthentication()
+
+	engine.WithHandlers(handler)
+
+	// Anonymous request succeeds
+	req := httptest.NewRequest("GET", "/greet", nil)
+	w := httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var resp testOutput
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Message != "Hello stranger" {
+		t.Errorf("expected anonymous greeting, got %q", resp.Message)
+	}
+
+	// Authenticated request sees identity
+	req = httptest.NewRequest("GET", "/greet", nil)
+	req.Header.Set("Authorization", "Bearer valid-token")
+	w = httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, req)
+
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Message != "Hello user-123" {
+		t.Errorf("expected authenticated greeting, got %q", resp.Message)
+	}
+}
+
+type formattedInput struct {
+	Email string `json:"email" validate:"required,email"`
+	Age   int    `json:"age" validate:"min=18"`
+}
+
+type fieldMapDetails struct {
+	Errors map[string]string `json:"errors"`
+}
+
+var errFieldMapValidation = NewError[fieldMapDetails]("INVALID_FIELDS", http.StatusBadRequest, "invalid fields")
+
+func TestEngine_WithValidationErrorFormatter(t *testing.T) {
+	tests := []struct {
+		name       string
+		formatter  func(validator.ValidationErrors) ErrorDefinition
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name: "custom shape",
+			formatter: func(ve validator.ValidationErrors) ErrorDefinition {
+				fields := make(map[string]string, len(ve))
+				for _, fe := range ve {
+					fields[fe.Field()] = fe.Tag()
+				}
+				return errFieldMapValidation.WithDetails(fieldMapDetails{Errors: fields})
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "INVALID_FIELDS",
+		},
+		{
+			name:       "nil falls back to default",
+			formatter:  func(validator.ValidationErrors) ErrorDefinition { return nil },
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   "VALIDATION_FAILED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newTestEngine().WithValidationErrorFormatter(tt.formatter)
+			handler := NewHandler[formattedInput, testOutput](
+				"formatted",
+				"POST",
+				"/formatted",
+				func(_ *Request[formattedInput]) (testOutput, error) {
+					return testOutput{}, nil
+				},
+			)
+			engine.WithHandlers(handler)
+
+			req := httptest.NewRequest("POST", "/formatted", strings.NewReader(`{"email":"nope","age":5}`))
+			w := httptest.NewRecorder()
+			engine.mux.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+
+			var body struct {
+				Code    string          `json:"code"`
+				Details json.RawMessage `json:"details"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &body)
+			if body.Code != tt.wantCode {
+				t.Errorf("expected code %q, got %q", tt.wantCode, body.Code)
+			}
+			if tt.wantCode == "INVALID_FIELDS" {
+				var details fieldMapDetails
+				json.Unmarshal(body.Details, &details)
+				if details.Errors["Email"] != "email" || details.Errors["Age"] != "min" {
+					t.Errorf("unexpected field map: %v", details.Errors)
+				}
+			}
+		})
+	}
+}
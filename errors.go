@@ -1,6 +1,8 @@
 package rocco
 
 import (
+	"errors"
+
 	"github.com/zoobzio/sentinel"
 )
 
@@ -130,6 +132,35 @@ func (e *Error[D]) WithCause(cause error) *Error[D] {
 	}
 }
 
+// errorChain describes err including the WithCause chain of any rocco Error within it.
+// Error() of a rocco Error is only its message, so causes would otherwise be lost from
+// events; the result is for logs and events only and must not be written to clients.
+func errorChain(err error) string {
+	msg := err.Error()
+	var roccoErr ErrorDefinition
+	if errors.As(err, &roccoErr) {
+		if cause := errors.Unwrap(roccoErr); cause != nil {
+			msg += ": " + errorChain(cause)
+		}
+	}
+	return msg
+}
+
+// ErrorDetailMode controls how much of an error is written to clients.
+// Set it with Engine.WithErrorDetailMode.
+type ErrorDetailMode int
+
+const (
+	// DetailsDev writes every error in full and adds its WithCause chain as "cause",
+	// for debugging. This is the default; do not keep it where responses reach
+	// untrusted clients.
+	DetailsDev ErrorDetailMode = iota
+	// DetailsProd writes undeclared 5xx errors with only their code and a generic status
+	// message. Errors declared with WithErrors keep their details, and their message unless
+	// they carry a cause. Causes are never written.
+	DetailsProd
+)
+
 // NoDetails is used for errors that don't carry additional details.
 type NoDetails struct{}
 
@@ -155,6 +186,11 @@ type NotFoundDetails struct {
 	Resource string `json:"resource,omitempty" description:"The type of resource that was not found"`
 }
 
+// MethodNotAllowedDetails provides context for method not allowed errors.
+type MethodNotAllowedDetails struct {
+	Allowed []string `json:"allowed,omitempty" description:"Methods the resource accepts"`
+}
+
 // ConflictDetails provides context for conflict errors.
 type ConflictDetails struct {
 	Reason string `json:"reason,omitempty" description:"What caused the conflict"`
@@ -182,6 +218,16 @@ type PayloadTooLargeDetails struct {
 	MaxSize int64 `json:"max_size,omitempty" description:"Maximum allowed payload size in bytes"`
 }
 
+// UnsupportedMediaTypeDetails provides context for content type errors.
+type UnsupportedMediaTypeDetails struct {
+	Supported []string `json:"supported,omitempty" description:"Media types accepted by this endpoint"`
+}
+
+// RangeNotSatisfiableDetails provides context for unsatisfiable Range requests.
+type RangeNotSatisfiableDetails struct {
+	Size int64 `json:"size" description:"Size of the representation in bytes"`
+}
+
 // TooManyRequestsDetails provides context for rate limit errors.
 type TooManyRequestsDetails struct {
 	RetryAfter int `json:"retry_after,omitempty" description:"Seconds until the client can retry"`
@@ -202,6 +248,11 @@ type ServiceUnavailableDetails struct {
 	Reason string `json:"reason,omitempty" description:"Why the service is unavailable"`
 }
 
+// GatewayTimeoutDetails provides context for timeout errors.
+type GatewayTimeoutDetails struct {
+	Reason string `json:"reason,omitempty" description:"What timed out"`
+}
+
 // Client errors (4xx)
 var (
 	// ErrBadRequest indicates the request was invalid (400)
@@ -216,12 +267,21 @@ var (
 	// ErrNotFound indicates the resource was not found (404)
 	ErrNotFound = NewError[NotFoundDetails]("NOT_FOUND", 404, "not found")
 
+	// ErrMethodNotAllowed indicates the resource does not accept the request method (405)
+	ErrMethodNotAllowed = NewError[MethodNotAllowedDetails]("METHOD_NOT_ALLOWED", 405, "method not allowed")
+
 	// ErrConflict indicates a conflict with existing data (409)
 	ErrConflict = NewError[ConflictDetails]("CONFLICT", 409, "conflict")
 
 	// ErrPayloadTooLarge indicates the request body exceeds the size limit (413)
 	ErrPayloadTooLarge = NewError[PayloadTooLargeDetails]("PAYLOAD_TOO_LARGE", 413, "payload too large")
 
+	// ErrUnsupportedMediaType indicates the request body media type is not accepted (415)
+	ErrUnsupportedMediaType = NewError[UnsupportedMediaTypeDetails]("UNSUPPORTED_MEDIA_TYPE", 415, "unsupported media type")
+
+	// ErrRangeNotSatisfiable indicates no requested byte range overlaps the representation (416)
+	ErrRangeNotSatisfiable = NewError[RangeNotSatisfiableDetails]("RANGE_NOT_SATISFIABLE", 416, "range not satisfiable")
+
 	// ErrUnprocessableEntity indicates the request was well-formed but semantically invalid (422)
 	ErrUnprocessableEntity = NewError[UnprocessableEntityDetails]("UNPROCESSABLE_ENTITY", 422, "unprocessable entity")
 
@@ -242,4 +302,7 @@ var (
 
 	// ErrServiceUnavailable indicates the service is temporarily unavailable (503)
 	ErrServiceUnavailable = NewError[ServiceUnavailableDetails]("SERVICE_UNAVAILABLE", 503, "service unavailable")
+
+	// ErrGatewayTimeout indicates the request did not complete before its deadline (504)
+	ErrGatewayTimeout = NewError[GatewayTimeoutDetails]("GATEWAY_TIMEOUT", 504, "gateway timeout")
 )
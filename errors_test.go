@@ -179,14 +179,18 @@ func TestSentinelErrors_Exist(t *testing.T) {
 		{"ErrUnauthorized", ErrUnauthorized, "UNAUTHORIZED", 401, "unauthorized"},
 		{"ErrForbidden", ErrForbidden, "FORBIDDEN", 403, "forbidden"},
 		{"ErrNotFound", ErrNotFound, "NOT_FOUND", 404, "not found"},
+		{"ErrMethodNotAllowed", ErrMethodNotAllowed, "METHOD_NOT_ALLOWED", 405, "method not allowed"},
 		{"ErrConflict", ErrConflict, "CONFLICT", 409, "conflict"},
 		{"ErrPayloadTooLarge", ErrPayloadTooLarge, "PAYLOAD_TOO_LARGE", 413, "payload too large"},
+		{"ErrUnsupportedMediaType", ErrUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE", 415, "unsupported media type"},
+		{"ErrRangeNotSatisfiable", ErrRangeNotSatisfiable, "RANGE_NOT_SATISFIABLE", 416, "range not satisfiable"},
 		{"ErrUnprocessableEntity", ErrUnprocessableEntity, "UNPROCESSABLE_ENTITY", 422, "unprocessable entity"},
 		{"ErrValidationFailed", ErrValidationFailed, "VALIDATION_FAILED", 422, "validation failed"},
 		{"ErrTooManyRequests", ErrTooManyRequests, "TOO_MANY_REQUESTS", 429, "too many requests"},
 		{"ErrInternalServer", ErrInternalServer, "INTERNAL_SERVER_ERROR", 500, "internal server error"},
 		{"ErrNotImplemented", ErrNotImplemented, "NOT_IMPLEMENTED", 501, "not implemented"},
 		{"ErrServiceUnavailable", ErrServiceUnavailable, "SERVICE_UNAVAILABLE", 503, "service unavailable"},
+		{"ErrGatewayTimeout", ErrGatewayTimeout, "GATEWAY_TIMEOUT", 504, "gateway timeout"},
 	}
 
 	for _, tt := range tests {
@@ -213,14 +217,18 @@ func TestSentinelErrors_AreUnique(t *testing.T) {
 		ErrUnauthorized,
 		ErrForbidden,
 		ErrNotFound,
+		ErrMethodNotAllowed,
 		ErrConflict,
 		ErrPayloadTooLarge,
+		ErrUnsupportedMediaType,
+		ErrRangeNotSatisfiable,
 		ErrUnprocessableEntity,
 		ErrValidationFailed,
 		ErrTooManyRequests,
 		ErrInternalServer,
 		ErrNotImplemented,
 		ErrServiceUnavailable,
+		ErrGatewayTimeout,
 	}
 
 	for i, err1 := range sentinels {
@@ -19,6 +19,10 @@ var (
 	// EngineShutdownComplete is emitted when shutdown finishes.
 	// Fields: GracefulKey, ErrorKey (if failed).
 	EngineShutdownComplete = capitan.NewSignal("http.engine.shutdown.complete", "HTTP engine shutdown completed, graceful or with error")
+
+	// TrustedProxyInvalid is emitted when a WithTrustedProxies entry cannot be parsed.
+	// Fields: AddressKey, ErrorKey.
+	TrustedProxyInvalid = capitan.NewSignal("http.engine.proxy.invalid", "Trusted proxy entry is not a valid IP address or CIDR and was ignored")
 )
 
 // Handler registration signals.
@@ -26,6 +30,15 @@ var (
 	// HandlerRegistered is emitted when a handler is registered with the engine.
 	// Fields: HandlerNameKey, MethodKey, PathKey.
 	HandlerRegistered = capitan.NewSignal("http.handler.registered", "HTTP handler registered with engine for specific route")
+
+	// HandlerTypeScanFailed is emitted at registration for each input/output type sentinel could not introspect.
+	// Fields: HandlerNameKey, ErrorKey.
+	HandlerTypeScanFailed = capitan.NewSignal("http.handler.scan.failed", "Handler input or output type could not be introspected; its schema will be empty")
+
+	// DuplicateRoute is emitted when a handler's route conflicts with one already registered.
+	// The handler is not registered, and Validate, Start, and Serve report the conflict.
+	// Fields: HandlerNameKey, MethodKey, PathKey, ErrorKey.
+	DuplicateRoute = capitan.NewSignal("http.handler.route.duplicate", "Handler route conflicts with an already registered route and was not registered")
 )
 
 // Request lifecycle signals.
@@ -35,12 +48,20 @@ var (
 	RequestReceived = capitan.NewSignal("http.request.received", "HTTP request received by engine and routed to handler")
 
 	// RequestCompleted is emitted when a request completes successfully.
-	// Fields: MethodKey, PathKey, HandlerNameKey, StatusCodeKey, DurationMsKey.
+	// Fields: MethodKey, PathKey, HandlerNameKey, StatusCodeKey, DurationMsKey, HandlerDurationMsKey.
 	RequestCompleted = capitan.NewSignal("http.request.completed", "HTTP request completed successfully with response sent")
 
 	// RequestFailed is emitted when a request fails with an error.
-	// Fields: MethodKey, PathKey, HandlerNameKey, StatusCodeKey, DurationMsKey, ErrorKey.
+	// Fields: MethodKey, PathKey, HandlerNameKey, StatusCodeKey, DurationMsKey, HandlerDurationMsKey, ErrorKey.
 	RequestFailed = capitan.NewSignal("http.request.failed", "HTTP request failed during processing with error")
+
+	// RequestIntercepted is emitted when a request interceptor rejects a request.
+	// Fields: MethodKey, PathKey, HandlerNameKey, StatusCodeKey, ErrorKey.
+	RequestIntercepted = capitan.NewSignal("http.request.intercepted", "HTTP request rejected by a request interceptor")
+
+	// RequestAborted is emitted when a handler is abandoned because the client disconnected.
+	// Fields: HandlerNameKey.
+	RequestAborted = capitan.NewSignal("http.request.aborted", "HTTP request aborted after client disconnected before response")
 )
 
 // Handler processing signals.
@@ -49,6 +70,10 @@ var (
 	// Fields: HandlerNameKey.
 	HandlerExecuting = capitan.NewSignal("http.handler.executing", "Handler execution started for incoming request")
 
+	// HandlerDeprecated is emitted when a request is routed to a handler marked WithDeprecated.
+	// Fields: MethodKey, PathKey, HandlerNameKey.
+	HandlerDeprecated = capitan.NewSignal("http.handler.deprecated", "Request routed to a deprecated handler")
+
 	// HandlerSuccess is emitted when a handler returns successfully.
 	// Fields: HandlerNameKey, StatusCodeKey.
 	HandlerSuccess = capitan.NewSignal("http.handler.success", "Handler completed successfully and returned response")
@@ -57,6 +82,22 @@ var (
 	// Fields: HandlerNameKey, ErrorKey.
 	HandlerError = capitan.NewSignal("http.handler.error", "Handler returned unexpected error during execution")
 
+	// HandlerTimeout is emitted when a handler returns context.DeadlineExceeded.
+	// Fields: HandlerNameKey, ErrorKey.
+	HandlerTimeout = capitan.NewSignal("http.handler.timeout", "Handler exceeded its deadline, responded with gateway timeout")
+
+	// HandlerCanceled is emitted when a handler returns context.Canceled.
+	// Fields: HandlerNameKey, ErrorKey.
+	HandlerCanceled = capitan.NewSignal("http.handler.canceled", "Handler cancelled because client disconnected, no response written")
+
+	// HandlerResponseCommitted is emitted when a handler is reached after the response was already written.
+	// Fields: HandlerNameKey, StatusCodeKey.
+	HandlerResponseCommitted = capitan.NewSignal("http.handler.response.committed", "Handler skipped because an earlier layer already wrote the response")
+
+	// HandlerPanicked is emitted when panic recovery (WithRecovery) catches a handler panic.
+	// Fields: HandlerNameKey, MethodKey, PathKey, ErrorKey (panic value), StackKey.
+	HandlerPanicked = capitan.NewSignal("http.handler.panicked", "Handler panicked, recovered and responded with internal server error")
+
 	// HandlerSentinelError is emitted when a declared sentinel error is returned.
 	// Fields: HandlerNameKey, ErrorKey, StatusCodeKey.
 	HandlerSentinelError = capitan.NewSignal("http.handler.sentinel.error", "Handler returned declared sentinel error mapped to HTTP status")
@@ -73,6 +114,10 @@ var (
 	// Fields: HandlerNameKey, ErrorKey.
 	RequestBodyReadError = capitan.NewSignal("http.request.body.read.error", "Failed to read request body from HTTP stream")
 
+	// RequestUnsupportedMediaType is emitted when the request Content-Type does not match the declared media type.
+	// Fields: HandlerNameKey, ContentTypeKey.
+	RequestUnsupportedMediaType = capitan.NewSignal("http.request.media_type.unsupported", "Request Content-Type does not match declared request media type")
+
 	// RequestBodyParseError is emitted when parsing the JSON request body fails.
 	// Fields: HandlerNameKey, ErrorKey.
 	RequestBodyParseError = capitan.NewSignal("http.request.body.parse.error", "Failed to parse JSON request body")
@@ -149,11 +194,27 @@ var (
 	// Fields: HandlerNameKey.
 	StreamClientDisconnected = capitan.NewSignal("http.stream.client.disconnected", "Client disconnected from SSE stream")
 
+	// StreamShutdown is emitted when a stream ends because the engine is shutting down.
+	// Fields: HandlerNameKey.
+	StreamShutdown = capitan.NewSignal("http.stream.shutdown", "SSE stream ended by engine shutdown")
+
 	// StreamError is emitted when stream handler encounters an error.
 	// Fields: HandlerNameKey, ErrorKey.
 	StreamError = capitan.NewSignal("http.stream.error", "SSE stream handler encountered error")
 )
 
+// OpenAPI generation signals.
+var (
+	// SchemaGenerationWarning is emitted for each problem that leaves the generated spec incomplete.
+	// Fields: HandlerNameKey (empty for spec-wide issues), TypeNameKey, ReasonKey.
+	SchemaGenerationWarning = capitan.NewSignal("http.openapi.schema.warning", "OpenAPI generation encountered an unresolved type, collision, or dangling reference")
+
+	// OpenAPIGenerated is emitted when the served spec is generated: on the first request to
+	// /openapi or /openapi.yaml, and again after RefreshOpenAPI or a late WithHandlers call.
+	// Fields: none.
+	OpenAPIGenerated = capitan.NewSignal("http.openapi.generated", "OpenAPI spec generated and cached for the built-in spec endpoints")
+)
+
 // Event field keys (primitive types only).
 var (
 	// Engine fields.
@@ -162,13 +223,16 @@ var (
 	AddressKey = capitan.NewStringKey("address")
 
 	// Request/Response fields.
-	MethodKey      = capitan.NewStringKey("method")
-	PathKey        = capitan.NewStringKey("path")
-	HandlerNameKey = capitan.NewStringKey("handler_name")
-	StatusCodeKey  = capitan.NewIntKey("status_code")
-	DurationMsKey  = capitan.NewInt64Key("duration_ms")
-	ErrorKey       = capitan.NewStringKey("error")
-	GracefulKey    = capitan.NewBoolKey("graceful")
+	MethodKey            = capitan.NewStringKey("method")
+	PathKey              = capitan.NewStringKey("path")
+	HandlerNameKey       = capitan.NewStringKey("handler_name")
+	StatusCodeKey        = capitan.NewIntKey("status_code")
+	DurationMsKey        = capitan.NewInt64Key("duration_ms")
+	HandlerDurationMsKey = capitan.NewInt64Key("handler_duration_ms")
+	ErrorKey             = capitan.NewStringKey("error")
+	GracefulKey          = capitan.NewBoolKey("graceful")
+	ContentTypeKey       = capitan.NewStringKey("content_type")
+	StackKey             = capitan.NewStringKey("stack")
 
 	// Authentication/Authorization fields.
 	IdentityIDKey     = capitan.NewStringKey("identity_id")
@@ -182,4 +246,8 @@ var (
 	LimitKeyKey     = capitan.NewStringKey("limit_key")
 	CurrentValueKey = capitan.NewIntKey("current_value")
 	ThresholdKey    = capitan.NewIntKey("threshold")
+
+	// OpenAPI generation fields.
+	TypeNameKey = capitan.NewStringKey("type_name")
+	ReasonKey   = capitan.NewStringKey("reason")
 )
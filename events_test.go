@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -148,6 +149,43 @@ func TestEvents_RequestLifecycle_Success(t *testing.T) {
 	}
 }
 
+func TestEvents_RequestCompleted_HandlerDuration(t *testing.T) {
+	setupSyncMode(t)
+
+	var duration, handlerDuration int64
+	var hasHandlerDuration bool
+	listener := capitan.Hook(RequestCompleted, func(_ context.Context, e *capitan.Event) {
+		duration, _ = DurationMsKey.From(e)
+		handlerDuration, hasHandlerDuration = HandlerDurationMsKey.From(e)
+	})
+	defer listener.Close()
+
+	slowMiddleware := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(30 * time.Millisecond)
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	engine := newTestEngine()
+	engine.WithHandlers(NewHandler[NoBody, testOutput]("slow", "GET", "/slow", func(_ *Request[NoBody]) (testOutput, error) {
+		time.Sleep(10 * time.Millisecond)
+		return testOutput{}, nil
+	}).WithMiddleware(slowMiddleware))
+
+	engine.mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil))
+
+	if !hasHandlerDuration {
+		t.Fatal("expected HandlerDurationMsKey on RequestCompleted")
+	}
+	if handlerDuration < 10 {
+		t.Errorf("expected handler duration of at least 10ms, got %d", handlerDuration)
+	}
+	if duration < handlerDuration+30 {
+		t.Errorf("expected total duration %dms to include 30ms of middleware on top of the handler's %dms", duration, handlerDuration)
+	}
+}
+
 func TestEvents_RequestLifecycle_Failed(t *testing.T) {
 	setupSyncMode(t)
 
@@ -218,6 +256,38 @@ func TestEvents_HandlerExecuting(t *testing.T) {
 	}
 }
 
+func TestEvents_HandlerDeprecated(t *testing.T) {
+	setupSyncMode(t)
+
+	var names []string
+	listener := capitan.Hook(HandlerDeprecated, func(_ context.Context, e *capitan.Event) {
+		name, _ := HandlerNameKey.From(e)
+		names = append(names, name)
+	})
+	defer listener.Close()
+
+	engine := newTestEngine()
+	engine.WithHandlers(
+		newRouteHandler("search-v1", "GET", "/v1/search").WithDeprecated(),
+		newRouteHandler("search-v2", "GET", "/v2/search"),
+	)
+
+	for _, path := range []string{"/v1/search", "/v2/search", "/v1/search"} {
+		engine.mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	if len(names) != 2 || names[0] != "search-v1" || names[1] != "search-v1" {
+		t.Errorf("expected a HandlerDeprecated event per request to search-v1, got %v", names)
+	}
+
+	spec := engine.GenerateOpenAPI(nil)
+	if !spec.Paths["/v1/search"].Get.Deprecated {
+		t.Error("expected deprecated operation in spec")
+	}
+	if spec.Paths["/v2/search"].Get.Deprecated {
+		t.Error("expected current operation not to be deprecated")
+	}
+}
+
 func TestEvents_HandlerSuccess(t *testing.T) {
 	setupSyncMode(t)
 
@@ -329,6 +399,46 @@ func TestEvents_HandlerSentinelError(t *testing.T) {
 	}
 }
 
+func TestEvents_HandlerSentinelError_CauseChain(t *testing.T) {
+	setupSyncMode(t)
+
+	var errorMsg string
+	listener := capitan.Hook(HandlerSentinelError, func(_ context.Context, e *capitan.Event) {
+		errorMsg, _ = ErrorKey.From(e)
+	})
+	defer listener.Close()
+
+	engine := newTestEngine().WithErrorDetailMode(DetailsProd)
+	handler := NewHandler[NoBody, testOutput](
+		"cause-handler",
+		"GET",
+		"/cause",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			cause := ErrUnprocessableEntity.WithCause(errors.New("connection refused"))
+			return testOutput{}, ErrConflict.WithCause(fmt.Errorf("insert user: %w", cause))
+		},
+	).WithErrors(ErrConflict)
+	engine.WithHandlers(handler)
+
+	req := httptest.NewRequest("GET", "/cause", nil)
+	w := httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", w.Code)
+	}
+	want := "conflict: insert user: unprocessable entity: connection refused"
+	if errorMsg != want {
+		t.Errorf("expected event error %q, got %q", want, errorMsg)
+	}
+	body := w.Body.String()
+	for _, leaked := range []string{"insert user", "connection refused"} {
+		if strings.Contains(body, leaked) {
+			t.Errorf("response body leaked cause %q: %s", leaked, body)
+		}
+	}
+}
+
 func TestEvents_HandlerUndeclaredSentinel(t *testing.T) {
 	setupSyncMode(t)
 
@@ -498,15 +608,17 @@ func TestEvents_EngineShutdown(t *testing.T) {
 	})
 	defer listener2.Close()
 
-	engine := NewEngine("localhost", 0, nil) // Random port
+	listening := make(chan struct{})
+	// Port 0 picks a random port
+	engine := NewEngine("localhost", 0, nil).WithOnListening(func(string) { close(listening) })
 
 	// Start server in background
 	go func() {
 		_ = engine.Start()
 	}()
 
-	// Give server time to start
-	time.Sleep(50 * time.Millisecond)
+	// Wait for the listener to be bound
+	<-listening
 
 	// Shutdown
 	ctx, ctxCancel := context.WithTimeout(context.Background(), 1*time.Second)
@@ -527,3 +639,107 @@ func TestEvents_EngineShutdown(t *testing.T) {
 		t.Error("expected graceful shutdown")
 	}
 }
+
+func TestEvents_SchemaGenerationWarning(t *testing.T) {
+	setupSyncMode(t)
+
+	var handlerNames, typeNames, reasons []string
+
+	listener := capitan.Hook(SchemaGenerationWarning, func(_ context.Context, e *capitan.Event) {
+		name, _ := HandlerNameKey.From(e)
+		typeName, _ := TypeNameKey.From(e)
+		reason, _ := ReasonKey.From(e)
+		handlerNames = append(handlerNames, name)
+		typeNames = append(typeNames, typeName)
+		reasons = append(reasons, reason)
+	})
+	defer listener.Close()
+
+	engine := newTestEngine()
+	handler := NewHandler[testInput, testOutput](
+		"broken-handler",
+		"POST",
+		"/broken",
+		func(_ *Request[testInput]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	)
+	handler.spec.OutputTypeName = "MissingOutput"
+	engine.WithHandlers(handler)
+
+	engine.GenerateOpenAPI(nil)
+
+	if len(reasons) != 2 {
+		t.Fatalf("expected 2 warnings, got %d: %v", len(reasons), reasons)
+	}
+	if handlerNames[0] != "broken-handler" || typeNames[0] != "MissingOutput" || reasons[0] != "type metadata not found" {
+		t.Errorf("unexpected lookup warning: %q %q %q", handlerNames[0], typeNames[0], reasons[0])
+	}
+	if typeNames[1] != "MissingOutput" || !strings.Contains(reasons[1], "dangling $ref") {
+		t.Errorf("unexpected dangling ref warning: %q %q", typeNames[1], reasons[1])
+	}
+}
+
+func TestEvents_SchemaGenerationWarning_NoneForValidSpec(t *testing.T) {
+	setupSyncMode(t)
+
+	var count int
+	listener := capitan.Hook(SchemaGenerationWarning, func(_ context.Context, _ *capitan.Event) {
+		count++
+	})
+	defer listener.Close()
+
+	engine := newTestEngine()
+	handler := NewHandler[testInput, testOutput](
+		"valid-handler",
+		"POST",
+		"/valid",
+		func(_ *Request[testInput]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	).WithErrors(ErrNotFound)
+	engine.WithHandlers(handler)
+
+	spec := engine.GenerateOpenAPI(nil)
+
+	if count != 0 {
+		t.Errorf("expected no warnings, got %d", count)
+	}
+	for _, name := range []string{"testInput", "testOutput", "NotFoundDetails"} {
+		if _, ok := spec.Components.Schemas[name]; !ok {
+			t.Errorf("expected schema %q in components", name)
+		}
+	}
+}
+
+func TestEvents_HandlerTypeScanFailed(t *testing.T) {
+	setupSyncMode(t)
+
+	var handlerName, errorMsg string
+	listener := capitan.Hook(HandlerTypeScanFailed, func(_ context.Context, e *capitan.Event) {
+		handlerName, _ = HandlerNameKey.From(e)
+		errorMsg, _ = ErrorKey.From(e)
+	})
+	defer listener.Close()
+
+	engine := newTestEngine()
+	handler := NewHandler[NoBody, string](
+		"scalar-handler",
+		"GET",
+		"/scalar",
+		func(_ *Request[NoBody]) (string, error) {
+			return "ok", nil
+		},
+	)
+	engine.WithHandlers(handler)
+
+	if handlerName != "scalar-handler" {
+		t.Errorf("expected handler name 'scalar-handler', got %q", handlerName)
+	}
+	if !strings.Contains(errorMsg, "cannot introspect string") {
+		t.Errorf("unexpected error message: %q", errorMsg)
+	}
+	if err := engine.ValidateSpec(); err == nil || !strings.Contains(err.Error(), "cannot introspect string") {
+		t.Errorf("expected ValidateSpec to report scan failure, got %v", err)
+	}
+}
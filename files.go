@@ -0,0 +1,172 @@
+package rocco
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/zoobzio/capitan"
+	"github.com/zoobzio/sentinel"
+)
+
+// fileMediaTypes are the success content types documented for served files.
+var fileMediaTypes = []string{"text/html", "application/octet-stream"}
+
+// FileHandler serves files from an http.FileSystem under a URL prefix, e.g. a single-page
+// app or static assets alongside the API. Unlike a route added to Router, it is documented
+// in the OpenAPI spec and runs through the engine's middleware, authentication and events.
+// Create one with NewFileHandler or register one directly with Engine.ServeFiles.
+type FileHandler struct {
+	fs         http.FileSystem
+	server     http.Handler
+	spec       HandlerSpec
+	middleware []func(http.Handler) http.Handler
+}
+
+// NewFileHandler creates a handler serving files from fs under urlPrefix, so that
+// "/static/css/app.css" under prefix "/static/" serves "css/app.css". A missing trailing
+// slash is added to the prefix. Directories are served by their index.html or listed,
+// as http.FileServer does; files that do not exist answer ErrNotFound.
+func NewFileHandler(name, urlPrefix string, fs http.FileSystem) *FileHandler {
+	if !strings.HasSuffix(urlPrefix, "/") {
+		urlPrefix += "/"
+	}
+	return &FileHandler{
+		fs:     fs,
+		server: http.FileServer(fs),
+		spec: HandlerSpec{
+			Name:                 name,
+			Method:               http.MethodGet,
+			Path:                 urlPrefix + "{path...}",
+			PathParams:           []string{"path"},
+			Summary:              "Serve files under " + urlPrefix,
+			SuccessStatus:        http.StatusOK,
+			ResponseContentTypes: fileMediaTypes,
+		},
+	}
+}
+
+// Process implements Endpoint.
+func (h *FileHandler) Process(ctx context.Context, r *http.Request, w http.ResponseWriter) (int, error) {
+	// An earlier layer (e.g. middleware) already responded; writing again would corrupt the response.
+	if status, committed := committedStatus(w); committed {
+		capitan.Warn(ctx, HandlerResponseCommitted,
+			HandlerNameKey.Field(h.spec.Name),
+			StatusCodeKey.Field(status),
+		)
+		return status, errResponseCommitted
+	}
+
+	name := path.Clean("/" + r.PathValue("path"))
+
+	// Answer missing files with the API's error format instead of http.FileServer's text.
+	f, err := h.fs.Open(name)
+	if err != nil {
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			writeError(ctx, w, ErrNotFound, h.spec.Name)
+			return http.StatusNotFound, nil
+		case errors.Is(err, fs.ErrPermission):
+			writeError(ctx, w, ErrForbidden, h.spec.Name)
+			return http.StatusForbidden, nil
+		}
+		writeError(ctx, w, ErrInternalServer.WithCause(err), h.spec.Name)
+		return http.StatusInternalServerError, err
+	}
+	_ = f.Close()
+
+	// Serve relative to the prefix; redirects http.FileServer issues are relative too.
+	served := r.Clone(ctx)
+	served.URL.Path = name
+	if strings.HasSuffix(r.URL.Path, "/") && name != "/" {
+		served.URL.Path += "/"
+	}
+	served.URL.RawPath = ""
+	h.server.ServeHTTP(w, served)
+
+	if status, committed := committedStatus(w); committed {
+		return status, nil
+	}
+	return http.StatusOK, nil
+}
+
+// Spec implements Endpoint.
+func (h *FileHandler) Spec() HandlerSpec {
+	return h.spec
+}
+
+// ErrorDefs implements Endpoint.
+func (h *FileHandler) ErrorDefs() []ErrorDefinition {
+	return []ErrorDefinition{ErrNotFound}
+}
+
+// InputMetadata implements Endpoint. Files take no request body.
+func (h *FileHandler) InputMetadata() sentinel.Metadata {
+	return sentinel.Metadata{}
+}
+
+// OutputMetadata implements Endpoint. Files have no JSON response type.
+func (h *FileHandler) OutputMetadata() sentinel.Metadata {
+	return sentinel.Metadata{}
+}
+
+// Middleware implements Endpoint.
+func (h *FileHandler) Middleware() []func(http.Handler) http.Handler {
+	return h.middleware
+}
+
+// Close implements Endpoint.
+func (h *FileHandler) Close() error {
+	return nil
+}
+
+// WithSummary sets the OpenAPI summary.
+func (h *FileHandler) WithSummary(summary string) *FileHandler {
+	h.spec.Summary = summary
+	return h
+}
+
+// WithDescription sets the OpenAPI description.
+func (h *FileHandler) WithDescription(desc string) *FileHandler {
+	h.spec.Description = desc
+	return h
+}
+
+// WithTags sets the OpenAPI tags.
+func (h *FileHandler) WithTags(tags ...string) *FileHandler {
+	h.spec.Tags = tags
+	return h
+}
+
+// WithMiddleware adds middleware to this handler, e.g. caching headers for assets.
+func (h *FileHandler) WithMiddleware(middleware ...func(http.Handler) http.Handler) *FileHandler {
+	h.middleware = append(h.middleware, middleware...)
+	return h
+}
+
+// WithAuthentication requires authentication to read the files.
+func (h *FileHandler) WithAuthentication() *FileHandler {
+	h.spec.RequiresAuth = true
+	return h
+}
+
+// WithScopes adds a scope requirement group (OR logic within group, AND across multiple calls).
+func (h *FileHandler) WithScopes(scopes ...string) *FileHandler {
+	if len(scopes) > 0 {
+		h.spec.ScopeGroups = append(h.spec.ScopeGroups, scopes)
+		h.spec.RequiresAuth = true
+	}
+	return h
+}
+
+// WithRoles adds a role requirement group (OR logic within group, AND across multiple calls).
+func (h *FileHandler) WithRoles(roles ...string) *FileHandler {
+	if len(roles) > 0 {
+		h.spec.RoleGroups = append(h.spec.RoleGroups, roles)
+		h.spec.RequiresAuth = true
+	}
+	return h
+}
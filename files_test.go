@@ -0,0 +1,87 @@
+package rocco
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func testFiles() http.FileSystem {
+	return http.FS(fstest.MapFS{
+		"index.html":  {Data: []byte("<h1>app</h1>")},
+		"css/app.css": {Data: []byte("body{}")},
+	})
+}
+
+func TestEngine_ServeFiles(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Global", "yes")
+			next.ServeHTTP(w, r)
+		})
+	})
+	engine.ServeFiles("/static", testFiles())
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/static/css/app.css", http.StatusOK, "body{}"},
+		{"/static/", http.StatusOK, "<h1>app</h1>"},
+		{"/static/missing.js", http.StatusNotFound, `"NOT_FOUND"`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("GET %s: expected %d with %q, got %d: %s", tt.path, tt.status, tt.body, w.Code, w.Body.String())
+		}
+		if w.Header().Get("X-Global") != "yes" {
+			t.Errorf("GET %s: expected global middleware to run", tt.path)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/static/css", nil))
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "css/" {
+		t.Errorf("expected a relative directory redirect, got %d to %q", w.Code, w.Header().Get("Location"))
+	}
+
+	op := engine.GenerateOpenAPI(nil).Paths["/static/{path}"].Get
+	if op == nil {
+		t.Fatal("expected the files route in the spec")
+	}
+	if op.OperationID != "serve-files-static" {
+		t.Errorf("expected operation ID serve-files-static, got %q", op.OperationID)
+	}
+	for _, mediaType := range fileMediaTypes {
+		if schema := op.Responses["200"].Content[mediaType].Schema; schema == nil || schema.Format != "binary" {
+			t.Errorf("expected a binary %s response, got %+v", mediaType, schema)
+		}
+	}
+	if _, ok := op.Responses["404"]; !ok {
+		t.Error("expected the 404 response to be documented")
+	}
+	if err := engine.ValidateSpec(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestFileHandler_Authentication(t *testing.T) {
+	engine := NewEngine("localhost", 8080, func(_ context.Context, _ *http.Request) (Identity, error) {
+		return nil, errors.New("authentication failed")
+	})
+	engine.WithHandlers(NewFileHandler("private-files", "/private/", testFiles()).WithAuthentication())
+
+	w := httptest.NewRecorder()
+	engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/private/index.html", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
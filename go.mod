@@ -7,6 +7,7 @@ require (
 	github.com/zoobzio/capitan v0.1.0
 	github.com/zoobzio/openapi v0.1.1
 	github.com/zoobzio/sentinel v0.1.4
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -17,5 +18,4 @@ require (
 	golang.org/x/crypto v0.42.0 // indirect
 	golang.org/x/sys v0.36.0 // indirect
 	golang.org/x/text v0.29.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -1,15 +1,27 @@
 package rocco
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"reflect"
+	"regexp"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/zoobzio/capitan"
+	"github.com/zoobzio/openapi"
 	"github.com/zoobzio/sentinel"
 )
 
@@ -19,6 +31,15 @@ type UsageLimit struct {
 	ThresholdFunc func(Identity) int // Function that returns threshold for this identity
 }
 
+// jsonContentType is shared across responses to avoid allocating the header value per request.
+var jsonContentType = []string{"application/json"}
+
+// fastPathPreparer is implemented by endpoints that can select an optimized Process path.
+// The engine calls prepareFastPath at registration, once all builder methods have run.
+type fastPathPreparer interface {
+	prepareFastPath()
+}
+
 // Handler wraps a typed handler function with metadata for documentation and parsing.
 // It implements Endpoint interface.
 // The handler function receives a Request with typed input and parameters.
@@ -30,13 +51,27 @@ type Handler[In, Out any] struct {
 	spec HandlerSpec
 
 	// Runtime configuration
-	responseHeaders map[string]string // Default response headers.
-	maxBodySize     int64             // Maximum request body size in bytes (0 = unlimited, default: 10MB).
-	validateOutput  bool              // Whether to validate output structs (disabled by default).
+	responseHeaders   map[string]string         // Default response headers.
+	maxBodySize       int64                     // Maximum request body size in bytes (0 = unlimited, default: 10MB).
+	validateOutput    bool                      // Whether to validate output structs (disabled by default).
+	inputTimes        *timeFormats              // Request body time fields with custom formats (nil = none).
+	outputTimes       *timeFormats              // Response time fields with custom formats (nil = none).
+	abortOnDisconnect bool                      // Whether to stop waiting on the handler when the client disconnects.
+	jsonSchema        *jsonSchemaValidator      // Raw body schema checked before decoding (nil = disabled).
+	strictFields      *bodyFields               // Body keys accepted under WithStrictJSON (nil = unknown fields ignored).
+	pathPatterns      map[string]*regexp.Regexp // Compiled WithPathParamPattern patterns by parameter name.
+	fastPath          bool                      // Set at registration for GET + NoBody handlers without params or auth.
+	queryBinding      *queryBinding             // Query struct decoder from WithQueryStruct (nil = none).
+	lastModified      func(Out) time.Time       // Modification time for Last-Modified/If-Modified-Since (nil = disabled).
+	timeout           time.Duration             // Per-request deadline for the handler (0 = none).
+	rawResponse       bool                      // Whether output is written verbatim rather than JSON-encoded.
+	rawContentType    string                    // Content type of raw output (empty = detected by net/http).
+	requestPool       sync.Pool                 // Recycled *pooledRequest[In] values.
 
 	// Type metadata from sentinel.
 	InputMeta  sentinel.Metadata
 	OutputMeta sentinel.Metadata
+	scanErrors []error // Introspection failures for In/Out and invalid JSON schemas.
 
 	// Error definitions with schemas for OpenAPI generation.
 	errorDefs []ErrorDefinition
@@ -55,62 +90,121 @@ func (h *Handler[In, Out]) Process(ctx context.Context, r *http.Request, w http.
 		HandlerNameKey.Field(h.spec.Name),
 	)
 
+	// An earlier layer (e.g. middleware) already responded; writing again would corrupt the response.
+	if status, committed := committedStatus(w); committed {
+		capitan.Warn(ctx, HandlerResponseCommitted,
+			HandlerNameKey.Field(h.spec.Name),
+			StatusCodeKey.Field(status),
+		)
+		return status, errResponseCommitted
+	}
+
+	// Bound the request with the handler's deadline so downstream calls observe it.
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+	}
+
+	if h.fastPath {
+		return h.processFast(ctx, r, w)
+	}
+
 	// Extract and validate parameters.
-	params, err := extractParams(ctx, r, h.spec.PathParams, h.spec.QueryParams)
+	params, err := extractParams(ctx, r, &h.spec, h.pathPatterns)
 	if err != nil {
 		capitan.Error(ctx, RequestParamsInvalid,
 			HandlerNameKey.Field(h.spec.Name),
 			ErrorKey.Field(err.Error()),
 		)
+		if errors.Is(err, errPathParamMismatch) {
+			writeError(ctx, w, ErrNotFound.WithCause(err), h.spec.Name)
+			return http.StatusNotFound, err
+		}
 		writeError(ctx, w, ErrUnprocessableEntity.WithMessage("invalid parameters").WithCause(err), h.spec.Name)
 		return http.StatusUnprocessableEntity, err
 	}
 
+	// Bind and validate the typed query struct.
+	var query any
+	if h.queryBinding != nil {
+		bound, violations := h.queryBinding.decode(r.URL.Query())
+		if len(violations) > 0 {
+			capitan.Warn(ctx, RequestParamsInvalid,
+				HandlerNameKey.Field(h.spec.Name),
+				ErrorKey.Field("query parameters could not be parsed"),
+			)
+			writeError(ctx, w, ErrValidationFailed.WithDetails(ValidationDetails{
+				Fields: violations,
+			}), h.spec.Name)
+			return ErrValidationFailed.Status(), ErrValidationFailed
+		}
+		query = bound.Interface()
+		if queryErr := h.queryBinding.validator.Struct(query); queryErr != nil {
+			capitan.Warn(ctx, RequestValidationInputFailed,
+				HandlerNameKey.Field(h.spec.Name),
+				ErrorKey.Field(queryErr.Error()),
+			)
+			return writeValidationErrorResponse(ctx, w, queryErr, h.spec.Name), queryErr
+		}
+	}
+
 	// Parse request body.
 	var input In
-	if h.InputMeta.TypeName != noBodyTypeName && r.Body != nil {
-		// Limit body size if configured - use MaxBytesReader for proper 413 errors
-		if h.maxBodySize > 0 {
-			r.Body = http.MaxBytesReader(w, r.Body, h.maxBodySize)
+	var provided map[string]json.RawMessage
+	var form *multipart.Form
+	if h.InputMeta.TypeName != noBodyTypeName {
+		if r.Body == nil {
+			r.Body = http.NoBody
 		}
 
-		body, readErr := io.ReadAll(r.Body)
-		if readErr != nil {
-			// Check if this is a max bytes exceeded error
-			var maxBytesErr *http.MaxBytesError
-			if errors.As(readErr, &maxBytesErr) {
-				capitan.Warn(ctx, RequestBodyReadError,
-					HandlerNameKey.Field(h.spec.Name),
-					ErrorKey.Field("payload too large"),
-				)
-				writeError(ctx, w, ErrPayloadTooLarge.WithDetails(PayloadTooLargeDetails{
-					MaxSize: h.maxBodySize,
-				}), h.spec.Name)
-				return http.StatusRequestEntityTooLarge, readErr
-			}
-			capitan.Error(ctx, RequestBodyReadError,
+		// Enforce declared request media type if one was configured.
+		if h.spec.RequestMediaType != "" && !matchesMediaType(r.Header.Get("Content-Type"), h.spec.RequestMediaType) {
+			capitan.Warn(ctx, RequestUnsupportedMediaType,
 				HandlerNameKey.Field(h.spec.Name),
-				ErrorKey.Field(readErr.Error()),
+				ContentTypeKey.Field(r.Header.Get("Content-Type")),
 			)
-			writeError(ctx, w, ErrBadRequest.WithMessage("failed to read request body").WithCause(readErr), h.spec.Name)
-			return http.StatusBadRequest, readErr
+			writeError(ctx, w, ErrUnsupportedMediaType.WithDetails(UnsupportedMediaTypeDetails{
+				Supported: []string{h.spec.RequestMediaType},
+			}), h.spec.Name)
+			return http.StatusUnsupportedMediaType, fmt.Errorf("unsupported media type %q", r.Header.Get("Content-Type"))
 		}
-		if closeErr := r.Body.Close(); closeErr != nil {
-			capitan.Warn(ctx, RequestBodyCloseError,
+
+		// Limit body size if configured - use MaxBytesReader for proper 413 errors
+		if h.maxBodySize > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, h.maxBodySize)
+		}
+
+		// Form submissions bypass JSON Schema and strict decoding, so only inputs meant
+		// for them (file uploads, or an explicit multipart media type) accept them.
+		multipartInput := hasFileUploadFields(h.InputMeta) || h.spec.RequestMediaType == MultipartFormMediaType
+		if !multipartInput && isMultipartForm(r.Header.Get("Content-Type")) {
+			capitan.Warn(ctx, RequestUnsupportedMediaType,
 				HandlerNameKey.Field(h.spec.Name),
-				ErrorKey.Field(closeErr.Error()),
+				ContentTypeKey.Field(r.Header.Get("Content-Type")),
 			)
+			writeError(ctx, w, ErrUnsupportedMediaType.WithDetails(UnsupportedMediaTypeDetails{
+				Supported: []string{"application/json"},
+			}), h.spec.Name)
+			return http.StatusUnsupportedMediaType, fmt.Errorf("unsupported media type %q", r.Header.Get("Content-Type"))
 		}
 
-		if len(body) > 0 {
-			if unmarshalErr := json.Unmarshal(body, &input); unmarshalErr != nil {
-				capitan.Error(ctx, RequestBodyParseError,
-					HandlerNameKey.Field(h.spec.Name),
-					ErrorKey.Field(unmarshalErr.Error()),
-				)
-				writeError(ctx, w, ErrUnprocessableEntity.WithMessage("invalid request body").WithCause(unmarshalErr), h.spec.Name)
-				return http.StatusUnprocessableEntity, unmarshalErr
+		if multipartInput && isMultipartForm(r.Header.Get("Content-Type")) {
+			formInput, formProvided, status, formErr := h.readMultipartInput(ctx, r, w)
+			if formErr != nil {
+				if r.MultipartForm != nil {
+					_ = r.MultipartForm.RemoveAll()
+				}
+				return status, formErr
+			}
+			input, provided, form = formInput, formProvided, r.MultipartForm
+		} else {
+			jsonInput, jsonProvided, status, jsonErr := h.readJSONInput(ctx, r, w)
+			if jsonErr != nil {
+				return status, jsonErr
 			}
+			input, provided = jsonInput, jsonProvided
 
 			// Validate input.
 			if inputErr := h.validator.Struct(input); inputErr != nil {
@@ -118,8 +212,7 @@ func (h *Handler[In, Out]) Process(ctx context.Context, r *http.Request, w http.
 					HandlerNameKey.Field(h.spec.Name),
 					ErrorKey.Field(inputErr.Error()),
 				)
-				writeValidationErrorResponse(ctx, w, inputErr, h.spec.Name)
-				return http.StatusUnprocessableEntity, inputErr
+				return writeValidationErrorResponse(ctx, w, inputErr, h.spec.Name), inputErr
 			}
 		}
 	}
@@ -133,16 +226,319 @@ func (h *Handler[In, Out]) Process(ctx context.Context, r *http.Request, w http.
 	}
 
 	// Create Request for callback.
-	req := &Request[In]{
+	pr := h.acquireRequest()
+	pr.req = Request[In]{
 		Context:  ctx,
 		Request:  r,
 		Params:   params,
 		Body:     input,
 		Identity: identity,
+		provided: provided,
+		query:    query,
+	}
+	pr.form = form
+
+	if !h.callsOnGoroutine() {
+		defer h.releaseRequest(pr)
+	}
+	return h.respond(ctx, r, w, pr)
+}
+
+// respondNotModified answers a satisfied conditional request with 304 and no body.
+func (h *Handler[In, Out]) respondNotModified(ctx context.Context, w http.ResponseWriter) (int, error) {
+	for key, value := range h.responseHeaders {
+		w.Header().Set(key, value)
+	}
+	w.WriteHeader(http.StatusNotModified)
+
+	capitan.Info(ctx, HandlerSuccess,
+		HandlerNameKey.Field(h.spec.Name),
+		StatusCodeKey.Field(http.StatusNotModified),
+	)
+
+	return http.StatusNotModified, nil
+}
+
+// notModifiedSince reports whether r's If-Modified-Since covers modified (RFC 9110 13.1.3).
+// The header only applies to GET and HEAD and is ignored when If-None-Match is present.
+func notModifiedSince(r *http.Request, modified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if r.Header.Get("If-None-Match") != "" {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !modified.After(since)
+}
+
+// maxPooledResponseBuffer bounds the buffers kept for reuse, so one unusually large
+// response does not pin its memory in the pool.
+const maxPooledResponseBuffer = 1 << 20
+
+// responseBufferPool recycles response encoding buffers across requests.
+var responseBufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// acquireResponseBuffer returns an empty buffer from the pool.
+func acquireResponseBuffer() *bytes.Buffer {
+	return responseBufferPool.Get().(*bytes.Buffer)
+}
+
+// releaseResponseBuffer returns buf to the pool. Call it only once the response is written
+// and nothing references buf's bytes; on error paths let the buffer be collected instead.
+func releaseResponseBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledResponseBuffer {
+		return
+	}
+	buf.Reset()
+	responseBufferPool.Put(buf)
+}
+
+// encodeJSON encodes v into buf, returning the same bytes json.Marshal would without
+// allocating a result slice. The bytes alias buf.
+func encodeJSON(buf *bytes.Buffer, v any) ([]byte, error) {
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	// Encode terminates the document with a newline that Marshal does not add.
+	return buf.Bytes()[:buf.Len()-1], nil
+}
+
+// renderOutput applies the handler's time formats and the requested sparse fieldset to
+// the encoded output.
+func (h *Handler[In, Out]) renderOutput(body []byte, fields string) ([]byte, error) {
+	if h.outputTimes != nil {
+		formatted, err := h.outputTimes.format(body)
+		if err != nil {
+			return nil, err
+		}
+		body = formatted
+	}
+	if fields != "" {
+		body = filterFields(body, fields)
+	}
+	return body, nil
+}
+
+// encodeEnveloped encodes the envelope built around output into buf. Time formats and
+// sparse fieldsets apply to the output alone, so when either is in play the output's
+// plain encoding inside the envelope is replaced by its rendered form. An envelope that
+// does not embed the output as-is is written unchanged.
+func (h *Handler[In, Out]) encodeEnveloped(buf *bytes.Buffer, wrapped any, output Out, fields string) ([]byte, error) {
+	body, err := encodeJSON(buf, wrapped)
+	if err != nil || (h.outputTimes == nil && fields == "") {
+		return body, err
+	}
+	plain, err := json.Marshal(output)
+	if err != nil {
+		return nil, err
+	}
+	at := bytes.Index(body, plain)
+	if at < 0 {
+		return body, nil
+	}
+	rendered, err := h.renderOutput(plain, fields)
+	if err != nil {
+		return nil, err
 	}
+	return slices.Concat(body[:at], rendered, body[at+len(plain):]), nil
+}
 
+// respondEncoded writes output using a negotiated non-JSON encoder.
+func (h *Handler[In, Out]) respondEncoded(ctx context.Context, w http.ResponseWriter, enc Encoder, output any) (int, error) {
+	buf := acquireResponseBuffer()
+	if err := enc.Encode(buf, output); err != nil {
+		capitan.Error(ctx, RequestResponseMarshalError,
+			HandlerNameKey.Field(h.spec.Name),
+			ErrorKey.Field(err.Error()),
+		)
+		writeError(ctx, w, ErrInternalServer.WithCause(err), h.spec.Name)
+		return http.StatusInternalServerError, err
+	}
+
+	for key, value := range h.responseHeaders {
+		w.Header().Set(key, value)
+	}
+	w.Header().Set("Content-Type", enc.ContentType())
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+
+	w.WriteHeader(h.spec.SuccessStatus)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		capitan.Warn(ctx, ResponseWriteError,
+			HandlerNameKey.Field(h.spec.Name),
+			ErrorKey.Field(err.Error()),
+		)
+	}
+	releaseResponseBuffer(buf)
+
+	capitan.Info(ctx, HandlerSuccess,
+		HandlerNameKey.Field(h.spec.Name),
+		StatusCodeKey.Field(h.spec.SuccessStatus),
+	)
+
+	return h.spec.SuccessStatus, nil
+}
+
+// respondRaw writes string, []byte or io.Reader output verbatim with the WithRawResponse
+// content type. Readers are copied without buffering and closed afterwards if they can be.
+func (h *Handler[In, Out]) respondRaw(ctx context.Context, w http.ResponseWriter, output Out) (int, error) {
+	var body io.Reader
+	size := 0
+	switch v := any(output).(type) {
+	case []byte:
+		body, size = bytes.NewReader(v), len(v)
+	case string:
+		body, size = strings.NewReader(v), len(v)
+	case io.Reader:
+		body, size = v, -1
+		if closer, ok := v.(io.Closer); ok {
+			defer closer.Close()
+		}
+	}
+
+	for key, value := range h.responseHeaders {
+		w.Header().Set(key, value)
+	}
+	if h.rawContentType != "" {
+		w.Header().Set("Content-Type", h.rawContentType)
+	}
+	if size >= 0 {
+		w.Header().Set("Content-Length", strconv.Itoa(size))
+	}
+
+	w.WriteHeader(h.spec.SuccessStatus)
+	if body == nil {
+		body = http.NoBody
+	}
+	if _, err := io.Copy(w, body); err != nil {
+		capitan.Warn(ctx, ResponseWriteError,
+			HandlerNameKey.Field(h.spec.Name),
+			ErrorKey.Field(err.Error()),
+		)
+	}
+
+	capitan.Info(ctx, HandlerSuccess,
+		HandlerNameKey.Field(h.spec.Name),
+		StatusCodeKey.Field(h.spec.SuccessStatus),
+	)
+
+	return h.spec.SuccessStatus, nil
+}
+
+// pooledRequest is the unit recycled through a handler's request pool.
+// It carries a Params value so the fast path needs no separate allocation.
+type pooledRequest[In any] struct {
+	req    Request[In]
+	params Params
+	form   *multipart.Form // Parsed upload, removed on release once the handler is done with it
+}
+
+// acquireRequest takes a cleared pooledRequest from the handler's pool.
+func (h *Handler[In, Out]) acquireRequest() *pooledRequest[In] {
+	if pr, ok := h.requestPool.Get().(*pooledRequest[In]); ok {
+		return pr
+	}
+	return new(pooledRequest[In])
+}
+
+// releaseRequest removes any uploads spilled to temporary files, clears pr so it holds no
+// request data (Body, Params, provided fields, query) and returns it to the pool.
+// Call it only once the handler function has returned.
+func (h *Handler[In, Out]) releaseRequest(pr *pooledRequest[In]) {
+	if pr.form != nil {
+		_ = pr.form.RemoveAll()
+	}
+	*pr = pooledRequest[In]{}
+	h.requestPool.Put(pr)
+}
+
+// processFast serves handlers selected by prepareFastPath. With no body, declared
+// parameters, or authentication there is nothing to read, extract, or look up, so
+// the Request is built directly around the pooled (empty, nil-map) Params.
+func (h *Handler[In, Out]) processFast(ctx context.Context, r *http.Request, w http.ResponseWriter) (int, error) {
+	pr := h.acquireRequest()
+	pr.req = Request[In]{
+		Context:  ctx,
+		Request:  r,
+		Params:   &pr.params,
+		Identity: NoIdentity{},
+	}
+
+	status, err := h.respond(ctx, r, w, pr)
+	if !h.callsOnGoroutine() {
+		h.releaseRequest(pr)
+	}
+	return status, err
+}
+
+// prepareFastPath implements fastPathPreparer.
+func (h *Handler[In, Out]) prepareFastPath() {
+	h.fastPath = h.spec.Method == http.MethodGet &&
+		h.InputMeta.TypeName == noBodyTypeName &&
+		len(h.spec.PathParams) == 0 &&
+		len(h.pathPatterns) == 0 &&
+		len(h.spec.QueryParams) == 0 &&
+		len(h.spec.MultiQueryParams) == 0 &&
+		len(h.spec.HeaderParams) == 0 &&
+		len(h.spec.CookieParams) == 0 &&
+		!h.spec.RequiresAuth &&
+		!h.spec.OptionalAuth
+}
+
+// callsOnGoroutine reports whether the handler function runs on its own goroutine (see
+// callWithAbort), which then owns the pooled request and releases it. Only handlers that
+// opted into WithTimeout or WithAbortOnDisconnect pay for it.
+func (h *Handler[In, Out]) callsOnGoroutine() bool {
+	return h.abortOnDisconnect || h.timeout > 0
+}
+
+// respond calls the user handler and writes its result or error. Nothing here touches the
+// pooled request once the handler function has returned.
+func (h *Handler[In, Out]) respond(ctx context.Context, r *http.Request, w http.ResponseWriter, pr *pooledRequest[In]) (int, error) {
 	// Call user handler.
-	output, err := h.fn(req)
+	var output Out
+	var err error
+	fnStart := time.Now()
+	if h.callsOnGoroutine() {
+		var aborted bool
+		output, aborted, err = h.callWithAbort(ctx, pr)
+		recordHandlerTime(w, fnStart)
+		if aborted && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			// The deadline passed while the handler was still running; it is left to the GC.
+			// capitan drops events on cancelled contexts, so emit on one that stays live.
+			capitan.Warn(context.WithoutCancel(ctx), HandlerTimeout,
+				HandlerNameKey.Field(h.spec.Name),
+				ErrorKey.Field(ctx.Err().Error()),
+			)
+			writeError(ctx, w, ErrGatewayTimeout, h.spec.Name)
+			return http.StatusGatewayTimeout, ctx.Err()
+		}
+		if aborted {
+			// The client cancelled ctx, so emit on a context that stays live.
+			capitan.Info(context.WithoutCancel(ctx), RequestAborted,
+				HandlerNameKey.Field(h.spec.Name),
+			)
+			return StatusClientClosedRequest, nil
+		}
+	} else {
+		output, err = h.fn(&pr.req)
+		recordHandlerTime(w, fnStart)
+		if err == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			// A deadline such as WithMaxRequestDuration passed before the handler returned;
+			// its result is too late to send.
+			capitan.Warn(context.WithoutCancel(ctx), HandlerTimeout,
+				HandlerNameKey.Field(h.spec.Name),
+				ErrorKey.Field(ctx.Err().Error()),
+			)
+			writeError(ctx, w, ErrGatewayTimeout, h.spec.Name)
+			return http.StatusGatewayTimeout, ctx.Err()
+		}
+	}
 	if err != nil {
 		// Check if this is a rocco Error.
 		if e := getRoccoError(err); e != nil {
@@ -151,7 +547,7 @@ func (h *Handler[In, Out]) Process(ctx context.Context, r *http.Request, w http.
 				// Undeclared error - programming error.
 				capitan.Warn(ctx, HandlerUndeclaredSentinel,
 					HandlerNameKey.Field(h.spec.Name),
-					ErrorKey.Field(err.Error()),
+					ErrorKey.Field(errorChain(err)),
 					StatusCodeKey.Field(e.Status()),
 				)
 				writeError(ctx, w, ErrInternalServer, h.spec.Name)
@@ -161,17 +557,37 @@ func (h *Handler[In, Out]) Process(ctx context.Context, r *http.Request, w http.
 			// Declared error - successful handling.
 			capitan.Warn(ctx, HandlerSentinelError,
 				HandlerNameKey.Field(h.spec.Name),
-				ErrorKey.Field(err.Error()),
+				ErrorKey.Field(errorChain(err)),
 				StatusCodeKey.Field(e.Status()),
 			)
-			writeError(ctx, w, e, h.spec.Name)
+			writeDeclaredError(ctx, w, e, h.spec.Name)
 			return e.Status(), nil
 		}
 
+		// Handler gave up because the request deadline passed.
+		if errors.Is(err, context.DeadlineExceeded) {
+			capitan.Warn(context.WithoutCancel(ctx), HandlerTimeout,
+				HandlerNameKey.Field(h.spec.Name),
+				ErrorKey.Field(errorChain(err)),
+			)
+			writeError(ctx, w, ErrGatewayTimeout, h.spec.Name)
+			return http.StatusGatewayTimeout, err
+		}
+
+		// Handler gave up because the client went away; nobody is listening for a body.
+		// A cancellation the client did not cause (ctx still live) is a server error below.
+		if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+			capitan.Info(context.WithoutCancel(ctx), HandlerCanceled,
+				HandlerNameKey.Field(h.spec.Name),
+				ErrorKey.Field(errorChain(err)),
+			)
+			return StatusClientClosedRequest, nil
+		}
+
 		// Real unexpected error.
 		capitan.Error(ctx, HandlerError,
 			HandlerNameKey.Field(h.spec.Name),
-			ErrorKey.Field(err.Error()),
+			ErrorKey.Field(errorChain(err)),
 		)
 		writeError(ctx, w, ErrInternalServer, h.spec.Name)
 		return http.StatusInternalServerError, err
@@ -189,8 +605,57 @@ func (h *Handler[In, Out]) Process(ctx context.Context, r *http.Request, w http.
 		}
 	}
 
-	// Marshal response.
-	body, err := json.Marshal(output)
+	// Answer conditional requests before encoding anything.
+	if h.lastModified != nil {
+		if modified := h.lastModified(output); !modified.IsZero() {
+			modified = modified.UTC().Truncate(time.Second)
+			w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+			if notModifiedSince(r, modified) {
+				return h.respondNotModified(ctx, w)
+			}
+		}
+	}
+
+	// Already-serialized output is written as-is, bypassing encoders and the envelope.
+	if h.rawResponse {
+		return h.respondRaw(ctx, w, output)
+	}
+
+	var envelope func(any) any
+	var encoders []registeredEncoder
+	if e := engineFromContext(ctx); e != nil {
+		envelope, encoders = e.responseEnvelope, e.encoders
+	}
+
+	// Use a registered encoder if the client negotiated one.
+	if len(encoders) > 0 {
+		w.Header().Add("Vary", "Accept")
+		if enc := negotiateEncoder(r.Header.Get("Accept"), encoders); enc != nil {
+			var v any = output
+			if envelope != nil {
+				v = envelope(output)
+			}
+			return h.respondEncoded(ctx, w, enc.encoder, v)
+		}
+	}
+
+	// Sparse fieldsets are requested per call.
+	var fields string
+	if h.spec.SparseFields {
+		fields = r.URL.Query().Get(sparseFieldsParam)
+	}
+
+	// Encode into a pooled buffer; it goes back to the pool only once the body is written.
+	buf := acquireResponseBuffer()
+	var body []byte
+	if envelope != nil {
+		body, err = h.encodeEnveloped(buf, envelope(output), output, fields)
+	} else {
+		body, err = encodeJSON(buf, output)
+		if err == nil {
+			body, err = h.renderOutput(body, fields)
+		}
+	}
 	if err != nil {
 		capitan.Error(ctx, RequestResponseMarshalError,
 			HandlerNameKey.Field(h.spec.Name),
@@ -204,7 +669,9 @@ func (h *Handler[In, Out]) Process(ctx context.Context, r *http.Request, w http.
 	for key, value := range h.responseHeaders {
 		w.Header().Set(key, value)
 	}
-	w.Header().Set("Content-Type", "application/json")
+	w.Header()["Content-Type"] = jsonContentType
+	// The body is fully buffered, so advertise its size rather than falling back to chunked encoding.
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
 
 	// Write status and body.
 	w.WriteHeader(h.spec.SuccessStatus)
@@ -214,6 +681,7 @@ func (h *Handler[In, Out]) Process(ctx context.Context, r *http.Request, w http.
 			ErrorKey.Field(err.Error()),
 		)
 	}
+	releaseResponseBuffer(buf)
 
 	// Emit handler success event
 	capitan.Info(ctx, HandlerSuccess,
@@ -224,43 +692,113 @@ func (h *Handler[In, Out]) Process(ctx context.Context, r *http.Request, w http.
 	return h.spec.SuccessStatus, nil
 }
 
+// callWithAbort runs the handler function and stops waiting if its deadline passes first, or
+// with WithAbortOnDisconnect, if the client disconnects. A disconnect otherwise only cancels
+// the handler's context, and its result is handled as usual.
+// The handler receives a cancellable context that is cancelled on abort. The handler
+// goroutine owns pr and releases it once the function returns, so an abandoned handler
+// keeps its request, including uploaded files, intact.
+func (h *Handler[In, Out]) callWithAbort(ctx context.Context, pr *pooledRequest[In]) (Out, bool, error) {
+	handlerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	pr.req.Context = handlerCtx
+
+	type result struct {
+		output Out
+		err    error
+		panic  *handlerPanic
+	}
+	done := make(chan result, 1)
+	go func() {
+		// A panic here would crash the process; hand it to the serving goroutine instead.
+		defer func() {
+			if rec := recover(); rec != nil {
+				h.releaseRequest(pr)
+				done <- result{panic: &handlerPanic{value: rec, stack: debug.Stack()}}
+			}
+		}()
+		output, err := h.fn(&pr.req)
+		h.releaseRequest(pr)
+		done <- result{output: output, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.panic != nil {
+			panic(res.panic)
+		}
+		return res.output, false, res.err
+	case <-ctx.Done():
+		if h.abortOnDisconnect || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			var zero Out
+			return zero, true, nil
+		}
+	}
+	res := <-done
+	if res.panic != nil {
+		panic(res.panic)
+	}
+	return res.output, false, res.err
+}
+
 // Spec implements Endpoint.
 func (h *Handler[In, Out]) Spec() HandlerSpec {
 	return h.spec
 }
 
+// ScanErrors returns any failures sentinel hit introspecting the input and output types,
+// plus any schema rejected by WithJSONSchema.
+// A non-empty result means the OpenAPI schema for the affected types will be empty.
+func (h *Handler[In, Out]) ScanErrors() []error {
+	return h.scanErrors
+}
+
 // Close implements Endpoint.
 func (*Handler[In, Out]) Close() error {
 	return nil
 }
 
+// defaultMaxBodySize is the request body limit for handlers that do not set one.
+const defaultMaxBodySize = 10 * 1024 * 1024 // 10MB
+
 // NewHandler creates a new typed handler with sentinel metadata.
 func NewHandler[In, Out any](name string, method, path string, fn func(*Request[In]) (Out, error)) *Handler[In, Out] {
-	inputMeta := sentinel.Scan[In]()
-	outputMeta := sentinel.Scan[Out]()
+	inputMeta, inputErr := scanType[In]()
+	outputMeta, outputErr := scanType[Out]()
+
+	// Inputs carrying file uploads are submitted as multipart forms.
+	var requestMediaType string
+	if hasFileUploadFields(inputMeta) {
+		requestMediaType = MultipartFormMediaType
+	}
 
 	return &Handler[In, Out]{
 		fn: fn,
 		spec: HandlerSpec{
-			Name:           name,
-			Method:         method,
-			Path:           path,
-			PathParams:     []string{},
-			QueryParams:    []string{},
-			InputTypeName:  inputMeta.TypeName,
-			OutputTypeName: outputMeta.TypeName,
-			SuccessStatus:  http.StatusOK, // Default to 200.
-			ErrorCodes:     []int{},
-			RequiresAuth:   false,
-			ScopeGroups:    [][]string{},
-			RoleGroups:     [][]string{},
-			UsageLimits:    []UsageLimit{},
-			Tags:           []string{},
+			Name:             name,
+			Method:           method,
+			Path:             path,
+			PathParams:       []string{},
+			QueryParams:      []string{},
+			InputTypeName:    inputMeta.TypeName,
+			OutputTypeName:   outputMeta.TypeName,
+			SuccessStatus:    http.StatusOK, // Default to 200.
+			ErrorCodes:       []int{},
+			RequiresAuth:     false,
+			ScopeGroups:      [][]string{},
+			RoleGroups:       [][]string{},
+			UsageLimits:      []UsageLimit{},
+			Tags:             []string{},
+			RequestMediaType: requestMediaType,
+			MaxBodySize:      defaultMaxBodySize,
 		},
 		responseHeaders: make(map[string]string),
-		maxBodySize:     10 * 1024 * 1024, // Default to 10MB.
+		maxBodySize:     defaultMaxBodySize,
+		inputTimes:      timeFormatsFor[In](),
+		outputTimes:     timeFormatsFor[Out](),
 		InputMeta:       inputMeta,
 		OutputMeta:      outputMeta,
+		scanErrors:      collectScanErrors(inputErr, outputErr),
 		validator:       validator.New(),
 		middleware:      make([]func(http.Handler) http.Handler, 0),
 	}
@@ -278,12 +816,44 @@ func (h *Handler[In, Out]) WithDescription(desc string) *Handler[In, Out] {
 	return h
 }
 
+// WithDescriptionMarkdown sets the OpenAPI description from a markdown block,
+// typically an indented raw string literal. Common indentation and surrounding
+// blank lines are removed so the markdown renders as written.
+func (h *Handler[In, Out]) WithDescriptionMarkdown(markdown string) *Handler[In, Out] {
+	h.spec.Description = dedentMarkdown(markdown)
+	return h
+}
+
 // WithTags sets the OpenAPI tags.
 func (h *Handler[In, Out]) WithTags(tags ...string) *Handler[In, Out] {
 	h.spec.Tags = tags
 	return h
 }
 
+// WithDeprecated marks the operation as deprecated in the OpenAPI spec.
+// Each request to it emits a HandlerDeprecated warning, so remaining usage can be tracked.
+func (h *Handler[In, Out]) WithDeprecated() *Handler[In, Out] {
+	h.spec.Deprecated = true
+	return h
+}
+
+// WithServers sets the OpenAPI servers for this operation, overriding the engine-level
+// servers, e.g. for routes a gateway exposes under a different base URL.
+func (h *Handler[In, Out]) WithServers(servers ...openapi.Server) *Handler[In, Out] {
+	h.spec.Servers = servers
+	return h
+}
+
+// WithHost scopes the route to requests whose Host header is host (e.g.
+// "tenant-a.example.com"), so the same method and path can route to a different handler
+// per host. The port is ignored when matching. The operation is documented with its own
+// server, "https://" + host, unless WithServers is set. Requires a Router that implements
+// HostRouter; the default router does.
+func (h *Handler[In, Out]) WithHost(host string) *Handler[In, Out] {
+	h.spec.Host = host
+	return h
+}
+
 // WithSuccessStatus sets the HTTP status code for successful responses.
 func (h *Handler[In, Out]) WithSuccessStatus(status int) *Handler[In, Out] {
 	h.spec.SuccessStatus = status
@@ -296,15 +866,137 @@ func (h *Handler[In, Out]) WithPathParams(params ...string) *Handler[In, Out] {
 	return h
 }
 
+// WithPathParamStyle documents the OpenAPI serialization style of a path parameter:
+// "simple" (the default), "label" or "matrix". It only affects the generated spec;
+// an unsupported style is reported by ScanErrors.
+func (h *Handler[In, Out]) WithPathParamStyle(name, style string) *Handler[In, Out] {
+	if err := setPathParamStyle(&h.spec, name, style); err != nil {
+		h.scanErrors = append(h.scanErrors, err)
+	}
+	return h
+}
+
+// WithPathParamExplode documents whether array and object values of a path parameter are
+// exploded (e.g. ";id=3;id=4" rather than ";id=3,4" with the matrix style). Like
+// WithPathParamStyle, it only affects the generated spec.
+func (h *Handler[In, Out]) WithPathParamExplode(name string, explode bool) *Handler[In, Out] {
+	setPathParamExplode(&h.spec, name, explode)
+	return h
+}
+
+// WithPathParamPattern constrains a path parameter to values matching the regular expression
+// pattern, e.g. `[0-9]+` for numeric IDs. The whole value must match; other values answer 404,
+// as if the route did not exist. The pattern is documented on the parameter's schema.
+// An invalid pattern is reported by ScanErrors.
+func (h *Handler[In, Out]) WithPathParamPattern(name, pattern string) *Handler[In, Out] {
+	if err := setPathParamPattern(&h.spec, &h.pathPatterns, name, pattern); err != nil {
+		h.scanErrors = append(h.scanErrors, err)
+	}
+	return h
+}
+
+// setPathParamPattern compiles pattern for the named path parameter into patterns, anchored
+// so the whole value must match, and records the anchored form in spec for documentation.
+func setPathParamPattern(spec *HandlerSpec, patterns *map[string]*regexp.Regexp, name, pattern string) error {
+	if !strings.HasPrefix(pattern, "^") || !strings.HasSuffix(pattern, "$") {
+		pattern = "^(?:" + pattern + ")$"
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("path parameter %s: invalid pattern: %w", name, err)
+	}
+	if *patterns == nil {
+		*patterns = make(map[string]*regexp.Regexp)
+	}
+	(*patterns)[name] = compiled
+	if spec.PathParamPatterns == nil {
+		spec.PathParamPatterns = make(map[string]string)
+	}
+	spec.PathParamPatterns[name] = pattern
+	return nil
+}
+
+// setPathParamStyle records style for the named path parameter in spec.
+func setPathParamStyle(spec *HandlerSpec, name, style string) error {
+	switch style {
+	case "simple", "label", "matrix":
+	default:
+		return fmt.Errorf("path parameter %s: unsupported style %q (want simple, label or matrix)", name, style)
+	}
+	if spec.PathParamStyles == nil {
+		spec.PathParamStyles = make(map[string]string)
+	}
+	spec.PathParamStyles[name] = style
+	return nil
+}
+
+// setPathParamExplode records explode for the named path parameter in spec.
+func setPathParamExplode(spec *HandlerSpec, name string, explode bool) {
+	if spec.PathParamExplode == nil {
+		spec.PathParamExplode = make(map[string]bool)
+	}
+	spec.PathParamExplode[name] = explode
+}
+
 // WithQueryParams specifies required query parameters.
 func (h *Handler[In, Out]) WithQueryParams(params ...string) *Handler[In, Out] {
 	h.spec.QueryParams = params
 	return h
 }
 
+// WithQueryParamsMulti declares repeatable query parameters (e.g., ?id=1&id=2&id=3).
+// Every value is available as Params.QueryMulti[name], and the first as Params.Query[name].
+// The OpenAPI spec documents them as exploded arrays of strings.
+func (h *Handler[In, Out]) WithQueryParamsMulti(params ...string) *Handler[In, Out] {
+	h.spec.MultiQueryParams = appendUnique(h.spec.MultiQueryParams, params...)
+	return h
+}
+
+// WithHeaderParams declares optional request headers, available as Params.Header
+// and documented as header parameters. Absent headers read as empty strings.
+func (h *Handler[In, Out]) WithHeaderParams(names ...string) *Handler[In, Out] {
+	h.spec.HeaderParams = appendUnique(h.spec.HeaderParams, names...)
+	return h
+}
+
+// WithRequiredHeaderParams declares request headers that must be present.
+// Requests missing any of them are rejected with 422 before the handler runs.
+func (h *Handler[In, Out]) WithRequiredHeaderParams(names ...string) *Handler[In, Out] {
+	h.spec.HeaderParams = appendUnique(h.spec.HeaderParams, names...)
+	h.spec.RequiredHeaderParams = appendUnique(h.spec.RequiredHeaderParams, names...)
+	return h
+}
+
+// WithCookieParams declares request cookies, available as Params.Cookie and documented
+// as cookie parameters. Absent cookies read as empty strings.
+func (h *Handler[In, Out]) WithCookieParams(names ...string) *Handler[In, Out] {
+	h.spec.CookieParams = appendUnique(h.spec.CookieParams, names...)
+	return h
+}
+
+// WithRequestMediaType sets the media type accepted for the request body (e.g., "application/merge-patch+json").
+// The body is still decoded as JSON. Requests with a different Content-Type are rejected with 415,
+// and the media type is used as the request body content key in OpenAPI.
+func (h *Handler[In, Out]) WithRequestMediaType(mediaType string) *Handler[In, Out] {
+	h.spec.RequestMediaType = mediaType
+	return h
+}
+
 // WithResponseHeaders sets default response headers for this handler.
 func (h *Handler[In, Out]) WithResponseHeaders(headers map[string]string) *Handler[In, Out] {
 	h.responseHeaders = headers
+	h.spec.ResponseHeaders = headers
+	return h
+}
+
+// WithResponseHeaderDocs documents a success response header in the OpenAPI spec.
+// Use it for headers set dynamically (e.g. by middleware) and to describe headers
+// set via WithResponseHeaders. It does not set the header.
+func (h *Handler[In, Out]) WithResponseHeaderDocs(name, description string) *Handler[In, Out] {
+	if h.spec.ResponseHeaderDocs == nil {
+		h.spec.ResponseHeaderDocs = make(map[string]string)
+	}
+	h.spec.ResponseHeaderDocs[name] = description
 	return h
 }
 
@@ -326,10 +1018,45 @@ func (h *Handler[In, Out]) ErrorDefs() []ErrorDefinition {
 	return h.errorDefs
 }
 
+// InputMetadata implements Endpoint.
+func (h *Handler[In, Out]) InputMetadata() sentinel.Metadata {
+	return h.InputMeta
+}
+
+// OutputMetadata implements Endpoint.
+func (h *Handler[In, Out]) OutputMetadata() sentinel.Metadata {
+	return h.OutputMeta
+}
+
+// WithStrictJSON rejects JSON request bodies containing fields the input type does not
+// declare, at any depth, so client typos fail with 422 VALIDATION_FAILED naming the field
+// instead of being silently ignored.
+func (h *Handler[In, Out]) WithStrictJSON() *Handler[In, Out] {
+	h.strictFields = bodyFieldsFor[In]()
+	return h
+}
+
 // WithMaxBodySize sets the maximum request body size in bytes for this handler.
 // Set to 0 for unlimited (not recommended for production).
 func (h *Handler[In, Out]) WithMaxBodySize(size int64) *Handler[In, Out] {
 	h.maxBodySize = size
+	h.spec.MaxBodySize = size
+	return h
+}
+
+// WithJSONSchema validates the raw request body against a JSON Schema before it is decoded.
+// Violations return 422 with one field entry per failing keyword; struct validation via
+// validate tags still runs afterwards. The schema also replaces the input type's schema
+// for the request body in the OpenAPI spec. An invalid schema is reported by ScanErrors
+// and leaves request validation unchanged.
+func (h *Handler[In, Out]) WithJSONSchema(schema []byte) *Handler[In, Out] {
+	compiled, err := compileJSONSchema(schema)
+	if err != nil {
+		h.scanErrors = append(h.scanErrors, err)
+		return h
+	}
+	h.jsonSchema = compiled
+	h.spec.RequestSchema = compiled.schema
 	return h
 }
 
@@ -341,6 +1068,75 @@ func (h *Handler[In, Out]) WithOutputValidation() *Handler[In, Out] {
 	return h
 }
 
+// WithRawResponse writes the handler's output verbatim with contentType instead of
+// JSON-encoding it, e.g. an SVG badge, a plain-text health string, or an upstream body
+// being proxied. The output type must be string, []byte or an io.Reader; readers are
+// copied to the response as they are read and closed afterwards if they are io.Closers.
+// Other output types are recorded as a scan error and keep JSON. An empty contentType
+// leaves it to net/http to detect from the first bytes written. The OpenAPI success
+// response is documented under contentType, or application/octet-stream if empty.
+func (h *Handler[In, Out]) WithRawResponse(contentType string) *Handler[In, Out] {
+	outputType := reflect.TypeFor[Out]()
+	if outputType != reflect.TypeFor[string]() && outputType != reflect.TypeFor[[]byte]() &&
+		!outputType.Implements(reflect.TypeFor[io.Reader]()) {
+		h.scanErrors = append(h.scanErrors, fmt.Errorf("raw response: output type %s must be string, []byte or an io.Reader", outputType))
+		return h
+	}
+	h.rawResponse = true
+	h.rawContentType = contentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h.spec.ResponseContentTypes = []string{contentType}
+
+	// The output is not a schema type, so sentinel's failure to introspect it is expected.
+	h.spec.OutputTypeName = ""
+	if _, outputErr := scanType[Out](); outputErr != nil {
+		h.scanErrors = slices.DeleteFunc(h.scanErrors, func(err error) bool {
+			return err.Error() == outputErr.Error()
+		})
+	}
+	return h
+}
+
+// WithSparseFields enables JSON:API style sparse fieldsets via the "fields" query parameter.
+// When a request includes ?fields=id,name only those top-level keys of the JSON response
+// are returned. Responses that are not JSON objects are returned unchanged.
+func (h *Handler[In, Out]) WithSparseFields() *Handler[In, Out] {
+	h.spec.SparseFields = true
+	return h
+}
+
+// WithLastModified sets the Last-Modified header from fn's result and honors If-Modified-Since
+// on GET and HEAD requests, answering 304 Not Modified when the output has not changed since
+// the client's copy. The handler still runs to produce the output, so this saves encoding and
+// transfer rather than the handler's own work. A zero time disables the header for a response.
+func (h *Handler[In, Out]) WithLastModified(fn func(out Out) time.Time) *Handler[In, Out] {
+	h.lastModified = fn
+	h.spec.LastModified = true
+	return h
+}
+
+// WithTimeout bounds each request to this handler by d. The request context (req.Context)
+// carries the deadline so downstream calls observe it; if the handler has not returned when
+// it passes, the client receives ErrGatewayTimeout (504), which is declared automatically.
+// A zero duration means no timeout, the default.
+func (h *Handler[In, Out]) WithTimeout(d time.Duration) *Handler[In, Out] {
+	h.timeout = d
+	if d > 0 && !h.isErrorDeclared(ErrGatewayTimeout) {
+		h.WithErrors(ErrGatewayTimeout)
+	}
+	return h
+}
+
+// WithAbortOnDisconnect stops waiting on the handler if the client disconnects before it returns.
+// The handler context is cancelled, no response is written, and a RequestAborted event is emitted.
+// Handlers should observe req.Context to stop expensive work early.
+func (h *Handler[In, Out]) WithAbortOnDisconnect() *Handler[In, Out] {
+	h.abortOnDisconnect = true
+	return h
+}
+
 // WithMiddleware adds middleware to this handler and returns the handler for chaining.
 func (h *Handler[In, Out]) WithMiddleware(middleware ...func(http.Handler) http.Handler) *Handler[In, Out] {
 	h.middleware = append(h.middleware, middleware...)
@@ -358,6 +1154,83 @@ func (h *Handler[In, Out]) WithAuthentication() *Handler[In, Out] {
 	return h
 }
 
+// WithOptionalAuthentication extracts the caller's identity when credentials are present
+// but still serves anonymous callers. Failed extraction results in NoIdentity rather than 401.
+func (h *Handler[In, Out]) WithOptionalAuthentication() *Handler[In, Out] {
+	h.spec.OptionalAuth = true
+	return h
+}
+
+// WithSecurityScheme selects the named security scheme, registered on the engine with
+// WithAPIKeySecurityScheme or WithOAuth2SecurityScheme, that the OpenAPI spec lists for
+// this handler. Handlers that do not call it use DefaultSecurityScheme.
+func (h *Handler[In, Out]) WithSecurityScheme(name string) *Handler[In, Out] {
+	h.spec.SecurityScheme = name
+	return h
+}
+
+// WithRequestExampleNamed adds a named example of the request body to the OpenAPI spec.
+// Call it once per scenario (e.g. "minimal", "full"); the docs UI lets readers switch between them.
+// Reusing a name replaces the earlier example.
+func (h *Handler[In, Out]) WithRequestExampleNamed(name, summary string, value any) *Handler[In, Out] {
+	if h.spec.RequestExamples == nil {
+		h.spec.RequestExamples = make(map[string]*openapi.Example)
+	}
+	h.spec.RequestExamples[name] = &openapi.Example{
+		Summary: summary,
+		Value:   value,
+	}
+	return h
+}
+
+// WithRequestExample documents a complete request body as the example for the handler's
+// request content type, in place of one synthesized from field-level example tags.
+// The value is marshaled as JSON when called; a value that cannot be is reported by ScanErrors.
+func (h *Handler[In, Out]) WithRequestExample(value any) *Handler[In, Out] {
+	example, err := marshalExample(value)
+	if err != nil {
+		h.scanErrors = append(h.scanErrors, fmt.Errorf("request example: %w", err))
+		return h
+	}
+	h.spec.RequestExample = example
+	return h
+}
+
+// WithResponseExample documents a complete response body for status, e.g. the success
+// status or a declared error's status. It is attached to that response's JSON content only;
+// ValidateSpec reports a status the operation does not document. The value is marshaled as
+// JSON when called; a value that cannot be is reported by ScanErrors.
+func (h *Handler[In, Out]) WithResponseExample(status int, value any) *Handler[In, Out] {
+	example, err := marshalExample(value)
+	if err != nil {
+		h.scanErrors = append(h.scanErrors, fmt.Errorf("response example for status %d: %w", status, err))
+		return h
+	}
+	if h.spec.ResponseExamplesByStatus == nil {
+		h.spec.ResponseExamplesByStatus = make(map[int]any)
+	}
+	h.spec.ResponseExamplesByStatus[status] = example
+	return h
+}
+
+// WithAuthVariants documents how the success response differs for authenticated and anonymous callers.
+// The values are emitted as named "authenticated" and "anonymous" examples on the success response.
+// Typically combined with WithOptionalAuthentication.
+func (h *Handler[In, Out]) WithAuthVariants(authenticated, anonymous Out) *Handler[In, Out] {
+	if h.spec.ResponseExamples == nil {
+		h.spec.ResponseExamples = make(map[string]*openapi.Example)
+	}
+	h.spec.ResponseExamples["authenticated"] = &openapi.Example{
+		Summary: "Authenticated caller",
+		Value:   authenticated,
+	}
+	h.spec.ResponseExamples["anonymous"] = &openapi.Example{
+		Summary: "Anonymous caller",
+		Value:   anonymous,
+	}
+	return h
+}
+
 // WithScopes adds a scope requirement group (OR logic within group, AND across multiple calls).
 // Example: .WithScopes("read", "write") requires (read OR write).
 // Calling multiple times creates AND: .WithScopes("read").WithScopes("admin") = read AND admin.
@@ -406,11 +1279,215 @@ func getRoccoError(err error) ErrorDefinition {
 	return nil
 }
 
+// readJSONInput decodes a JSON request body into an In value.
+func (h *Handler[In, Out]) readJSONInput(ctx context.Context, r *http.Request, w http.ResponseWriter) (In, map[string]json.RawMessage, int, error) {
+	return decodeJSONInput[In](ctx, r, w, jsonInput{
+		handlerName:  h.spec.Name,
+		maxBodySize:  h.maxBodySize,
+		jsonSchema:   h.jsonSchema,
+		inputTimes:   h.inputTimes,
+		strictFields: h.strictFields,
+	})
+}
+
+// jsonInput is what decodeJSONInput needs from a handler: its name for events and error
+// responses, and the limits and options that apply to its body.
+type jsonInput struct {
+	handlerName  string
+	maxBodySize  int64
+	jsonSchema   *jsonSchemaValidator // nil = no raw document validation
+	inputTimes   *timeFormats         // nil = no timeformat fields
+	strictFields *bodyFields          // nil = unknown fields ignored
+}
+
+// decodeJSONInput decodes a JSON request body into an In value, returning the top-level
+// keys it contained. The body streams into the decoder unless a JSON Schema or timeformat
+// fields need the whole document first. On failure the error response has already been
+// written and a non-zero status is returned.
+func decodeJSONInput[In any](ctx context.Context, r *http.Request, w http.ResponseWriter, in jsonInput) (In, map[string]json.RawMessage, int, error) {
+	var input In
+	defer func() {
+		if closeErr := r.Body.Close(); closeErr != nil {
+			capitan.Warn(ctx, RequestBodyCloseError,
+				HandlerNameKey.Field(in.handlerName),
+				ErrorKey.Field(closeErr.Error()),
+			)
+		}
+	}()
+
+	var src io.Reader = r.Body
+	if in.jsonSchema != nil || in.inputTimes != nil {
+		body, readErr := readBody(r, in.maxBodySize)
+		if readErr != nil {
+			return input, nil, in.writeBodyReadError(ctx, w, readErr), readErr
+		}
+		if len(body) > 0 {
+			// Validate the raw document against the JSON Schema before decoding into In.
+			if in.jsonSchema != nil {
+				if violations := in.jsonSchema.validate(body); len(violations) > 0 {
+					capitan.Warn(ctx, RequestValidationInputFailed,
+						HandlerNameKey.Field(in.handlerName),
+						ErrorKey.Field("request body does not match JSON schema"),
+					)
+					writeError(ctx, w, ErrValidationFailed.WithDetails(ValidationDetails{
+						Fields: violations,
+					}), in.handlerName)
+					return input, nil, ErrValidationFailed.Status(), ErrValidationFailed
+				}
+			}
+			// Convert timeformat fields to RFC 3339 so encoding/json can decode them.
+			if in.inputTimes != nil {
+				parsed, parseErr := in.inputTimes.parse(body)
+				if parseErr != nil {
+					return input, nil, in.writeBodyParseError(ctx, w, parseErr), parseErr
+				}
+				body = parsed
+			}
+		}
+		src = bytes.NewReader(body)
+	}
+
+	scanner := newBodyScanner(src, in.strictFields)
+	dec := json.NewDecoder(scanner)
+	err := dec.Decode(&input)
+	if err == nil {
+		// Like json.Unmarshal, reject anything after the document.
+		if _, tokenErr := dec.Token(); !errors.Is(tokenErr, io.EOF) {
+			err = errors.New("invalid data after top-level value")
+		}
+	}
+
+	switch {
+	case scanner.readErr != nil:
+		return input, nil, in.writeBodyReadError(ctx, w, scanner.readErr), scanner.readErr
+	case errors.Is(err, io.EOF):
+		// A typed input means a body is required; don't hand the handler a zero value.
+		capitan.Warn(ctx, RequestBodyReadError,
+			HandlerNameKey.Field(in.handlerName),
+			ErrorKey.Field("request body required"),
+		)
+		writeError(ctx, w, ErrBadRequest.WithMessage("request body required"), in.handlerName)
+		return input, nil, http.StatusBadRequest, errors.New("request body required")
+	case err != nil:
+		return input, nil, in.writeBodyParseError(ctx, w, err), err
+	case scanner.unknown != "":
+		unknownErr := fmt.Errorf("unknown field %q", scanner.unknown)
+		capitan.Warn(ctx, RequestValidationInputFailed,
+			HandlerNameKey.Field(in.handlerName),
+			ErrorKey.Field(unknownErr.Error()),
+		)
+		writeError(ctx, w, ErrValidationFailed.WithDetails(ValidationDetails{
+			Fields: []ValidationFieldError{{Field: scanner.unknown, Tag: "unknown"}},
+		}), in.handlerName)
+		return input, nil, ErrValidationFailed.Status(), unknownErr
+	}
+	return input, scanner.keys, 0, nil
+}
+
+// writeBodyReadError answers a failed body read: 413 past the size limit, 400 otherwise.
+func (in jsonInput) writeBodyReadError(ctx context.Context, w http.ResponseWriter, err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		capitan.Warn(ctx, RequestBodyReadError,
+			HandlerNameKey.Field(in.handlerName),
+			ErrorKey.Field("payload too large"),
+		)
+		writeError(ctx, w, ErrPayloadTooLarge.WithDetails(PayloadTooLargeDetails{
+			MaxSize: in.maxBodySize,
+		}), in.handlerName)
+		return http.StatusRequestEntityTooLarge
+	}
+	capitan.Error(ctx, RequestBodyReadError,
+		HandlerNameKey.Field(in.handlerName),
+		ErrorKey.Field(err.Error()),
+	)
+	writeError(ctx, w, ErrBadRequest.WithMessage("failed to read request body").WithCause(err), in.handlerName)
+	return http.StatusBadRequest
+}
+
+// writeBodyParseError answers a body that is not valid JSON for the input with 422.
+func (in jsonInput) writeBodyParseError(ctx context.Context, w http.ResponseWriter, err error) int {
+	capitan.Error(ctx, RequestBodyParseError,
+		HandlerNameKey.Field(in.handlerName),
+		ErrorKey.Field(err.Error()),
+	)
+	writeError(ctx, w, ErrUnprocessableEntity.WithMessage("invalid request body").WithCause(err), in.handlerName)
+	return http.StatusUnprocessableEntity
+}
+
+// matchesMediaType reports whether a Content-Type header value matches the expected media type.
+// Parameters such as charset are ignored and an empty header is accepted.
+func matchesMediaType(contentType, expected string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(mediaType, expected)
+}
+
+// sparseFieldsParam is the query parameter used to request sparse fieldsets.
+const sparseFieldsParam = "fields"
+
+// filterFields restricts a JSON object to the comma-separated top-level keys in fields.
+// If body is not a JSON object it is returned unchanged.
+func filterFields(body []byte, fields string) []byte {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return body
+	}
+
+	filtered := make(map[string]json.RawMessage)
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+		if val, ok := obj[field]; ok {
+			filtered[field] = val
+		}
+	}
+
+	result, err := json.Marshal(filtered)
+	if err != nil {
+		return body
+	}
+	return result
+}
+
 // errorResponse represents the standard error response format.
 type errorResponse struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
 	Details any    `json:"details,omitempty"`
+	Cause   string `json:"cause,omitempty"` // DetailsDev only
+}
+
+// newErrorResponse builds the body written for err, exposing as much as mode allows.
+// declared marks an error the handler declared with WithErrors, whose details are part
+// of its documented response.
+func newErrorResponse(err ErrorDefinition, mode ErrorDetailMode, declared bool) errorResponse {
+	resp := errorResponse{
+		Code:    err.Code(),
+		Message: err.Message(),
+		Details: err.DetailsAny(),
+	}
+	switch {
+	case mode == DetailsDev:
+		if cause := errors.Unwrap(err); cause != nil {
+			resp.Cause = errorChain(cause)
+		}
+	case err.Status() >= http.StatusInternalServerError:
+		// Server-side specifics stay in events; clients only learn what kind of failure occurred.
+		// Declared errors keep the details their schema documents, and their message unless
+		// it was set alongside a cause.
+		if !declared || errors.Unwrap(err) != nil {
+			resp.Message = strings.ToLower(http.StatusText(err.Status()))
+		}
+		if !declared {
+			resp.Details = nil
+		}
+	}
+	return resp
 }
 
 // isErrorDeclared checks if an error was declared via WithErrors.
@@ -426,14 +1503,21 @@ func (h *Handler[In, Out]) isErrorDeclared(err ErrorDefinition) bool {
 
 // writeError writes a structured JSON error response.
 func writeError(ctx context.Context, w http.ResponseWriter, err ErrorDefinition, handlerName string) {
+	writeErrorResponse(ctx, w, err, handlerName, false)
+}
+
+// writeDeclaredError writes an error the handler declared with WithErrors, keeping its
+// documented details in DetailsProd mode.
+func writeDeclaredError(ctx context.Context, w http.ResponseWriter, err ErrorDefinition, handlerName string) {
+	writeErrorResponse(ctx, w, err, handlerName, true)
+}
+
+// writeErrorResponse writes err as JSON with its status.
+func writeErrorResponse(ctx context.Context, w http.ResponseWriter, err ErrorDefinition, handlerName string, declared bool) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(err.Status())
 
-	if encodeErr := json.NewEncoder(w).Encode(errorResponse{
-		Code:    err.Code(),
-		Message: err.Message(),
-		Details: err.DetailsAny(),
-	}); encodeErr != nil {
+	if encodeErr := json.NewEncoder(w).Encode(newErrorResponse(err, errorDetailModeFrom(ctx), declared)); encodeErr != nil {
 		capitan.Warn(ctx, ResponseWriteError,
 			HandlerNameKey.Field(handlerName),
 			ErrorKey.Field(encodeErr.Error()),
@@ -441,12 +1525,26 @@ func writeError(ctx context.Context, w http.ResponseWriter, err ErrorDefinition,
 	}
 }
 
-// writeValidationErrorResponse writes detailed validation errors using the standard error format.
-func writeValidationErrorResponse(ctx context.Context, w http.ResponseWriter, err error, handlerName string) {
+// ValidationErrorFormatter converts validator errors into the error written to the client.
+// Returning nil falls back to the default ErrValidationFailed response.
+type ValidationErrorFormatter func(validator.ValidationErrors) ErrorDefinition
+
+// writeValidationErrorResponse writes detailed validation errors and returns the status written.
+// A ValidationErrorFormatter in ctx takes precedence over the standard error format.
+func writeValidationErrorResponse(ctx context.Context, w http.ResponseWriter, err error, handlerName string) int {
+	var ve validator.ValidationErrors
+	isValidationErr := errors.As(err, &ve)
+
+	if e := engineFromContext(ctx); e != nil && e.validationErrorFormatter != nil && isValidationErr {
+		if errDef := e.validationErrorFormatter(ve); errDef != nil {
+			writeError(ctx, w, errDef, handlerName)
+			return errDef.Status()
+		}
+	}
+
 	// Extract validation errors.
 	var validationErrors []ValidationFieldError
-	var ve validator.ValidationErrors
-	if errors.As(err, &ve) {
+	if isValidationErr {
 		for _, fe := range ve {
 			validationErrors = append(validationErrors, ValidationFieldError{
 				Field: fe.Field(),
@@ -459,4 +1557,5 @@ func writeValidationErrorResponse(ctx context.Context, w http.ResponseWriter, er
 	writeError(ctx, w, ErrValidationFailed.WithDetails(ValidationDetails{
 		Fields: validationErrors,
 	}), handlerName)
+	return ErrValidationFailed.Status()
 }
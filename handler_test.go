@@ -9,8 +9,15 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/zoobzio/capitan"
 )
 
 // errorReader is a reader that always returns an error
@@ -192,6 +199,37 @@ func TestHandler_Process_InvalidJSON(t *testing.T) {
 	}
 }
 
+func TestHandler_Process_EmptyBody(t *testing.T) {
+	handler := NewHandler[testInput, testOutput](
+		"test",
+		"POST",
+		"/test",
+		func(_ *Request[testInput]) (testOutput, error) {
+			t.Error("handler should not be called without a body")
+			return testOutput{}, nil
+		},
+	)
+
+	for name, req := range map[string]*http.Request{
+		"empty":  httptest.NewRequest("POST", "/test", bytes.NewReader(nil)),
+		"absent": {Method: "POST", URL: &url.URL{Path: "/test"}, Header: http.Header{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			status, err := handler.Process(context.Background(), req, w)
+			if err == nil {
+				t.Fatal("expected error for missing body")
+			}
+			if status != http.StatusBadRequest || w.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d (written %d)", status, w.Code)
+			}
+			if !strings.Contains(w.Body.String(), "request body required") {
+				t.Errorf("expected 'request body required' message, got %s", w.Body.String())
+			}
+		})
+	}
+}
+
 func TestHandler_Process_DeclaredSentinelError(t *testing.T) {
 	handler := NewHandler[NoBody, testOutput](
 		"test",
@@ -295,7 +333,7 @@ func TestHandler_ExtractParams_PathParams(t *testing.T) {
 	req.SetPathValue("id", "123")
 
 	spec := handler.Spec()
-	params, err := extractParams(context.Background(), req, spec.PathParams, spec.QueryParams)
+	params, err := extractParams(context.Background(), req, &spec, nil)
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -318,7 +356,7 @@ func TestHandler_ExtractParams_MissingPathParam(t *testing.T) {
 	req := httptest.NewRequest("GET", "/users/123", nil)
 
 	spec := handler.Spec()
-	_, err := extractParams(context.Background(), req, spec.PathParams, spec.QueryParams)
+	_, err := extractParams(context.Background(), req, &spec, nil)
 
 	if err == nil {
 		t.Fatal("expected error for missing path param")
@@ -338,7 +376,7 @@ func TestHandler_ExtractParams_QueryParams(t *testing.T) {
 	req := httptest.NewRequest("GET", "/test?page=1&limit=10", nil)
 
 	spec := handler.Spec()
-	params, err := extractParams(context.Background(), req, spec.PathParams, spec.QueryParams)
+	params, err := extractParams(context.Background(), req, &spec, nil)
 
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -351,6 +389,38 @@ func TestHandler_ExtractParams_QueryParams(t *testing.T) {
 	}
 }
 
+func TestHandler_ExtractParams_MultiQueryParams(t *testing.T) {
+	handler := NewHandler[NoBody, testOutput](
+		"test",
+		"GET",
+		"/test",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	).WithQueryParams("page").WithQueryParamsMulti("id", "tag")
+
+	req := httptest.NewRequest("GET", "/test?id=1&id=2&id=3&page=1&page=2", nil)
+
+	spec := handler.Spec()
+	params, err := extractParams(context.Background(), req, &spec, nil)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := params.QueryMulti["id"]; !reflect.DeepEqual(got, []string{"1", "2", "3"}) {
+		t.Errorf("expected every id value, got %v", got)
+	}
+	if params.Query["id"] != "1" {
+		t.Errorf("expected the first id in Query, got %q", params.Query["id"])
+	}
+	if _, ok := params.QueryMulti["tag"]; ok {
+		t.Error("expected absent multi param to be left out")
+	}
+	if params.Query["page"] != "1" || params.QueryMulti["page"] != nil {
+		t.Errorf("expected single-value page unchanged, got %q / %v", params.Query["page"], params.QueryMulti["page"])
+	}
+}
+
 func TestHandler_ExtractParams_MissingQueryParam(t *testing.T) {
 	handler := NewHandler[NoBody, testOutput](
 		"test",
@@ -364,7 +434,7 @@ func TestHandler_ExtractParams_MissingQueryParam(t *testing.T) {
 	req := httptest.NewRequest("GET", "/test", nil)
 
 	spec := handler.Spec()
-	params, err := extractParams(context.Background(), req, spec.PathParams, spec.QueryParams)
+	params, err := extractParams(context.Background(), req, &spec, nil)
 
 	// Missing query params should result in empty string, not error
 	if err != nil {
@@ -375,6 +445,107 @@ func TestHandler_ExtractParams_MissingQueryParam(t *testing.T) {
 	}
 }
 
+func TestHandler_ExtractParams_HeaderParams(t *testing.T) {
+	handler := NewHandler[NoBody, testOutput](
+		"test",
+		"GET",
+		"/test",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	).WithHeaderParams("X-Request-ID", "X-Trace").WithRequiredHeaderParams("X-Idempotency-Key")
+
+	spec := handler.Spec()
+	if len(spec.HeaderParams) != 3 || len(spec.RequiredHeaderParams) != 1 {
+		t.Fatalf("unexpected header params: %v / %v", spec.HeaderParams, spec.RequiredHeaderParams)
+	}
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("x-request-id", "abc")
+	req.Header.Set("X-Idempotency-Key", "key-1")
+
+	params, err := extractParams(context.Background(), req, &spec, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if params.Header["X-Request-ID"] != "abc" {
+		t.Errorf("expected header 'X-Request-ID' = 'abc', got %q", params.Header["X-Request-ID"])
+	}
+	if params.Header["X-Trace"] != "" {
+		t.Errorf("expected empty string for missing optional header, got %q", params.Header["X-Trace"])
+	}
+	if params.Header["X-Idempotency-Key"] != "key-1" {
+		t.Errorf("expected required header value, got %q", params.Header["X-Idempotency-Key"])
+	}
+
+	req.Header.Del("X-Idempotency-Key")
+	if _, err := extractParams(context.Background(), req, &spec, nil); err == nil {
+		t.Error("expected error for missing required header")
+	}
+}
+
+func TestHandler_ExtractParams_CookieParams(t *testing.T) {
+	handler := NewHandler[NoBody, testOutput](
+		"test",
+		"GET",
+		"/test",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	).WithCookieParams("session", "theme")
+
+	spec := handler.Spec()
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "s-123"})
+	req.AddCookie(&http.Cookie{Name: "tracking", Value: "undeclared"})
+
+	params, err := extractParams(context.Background(), req, &spec, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if params.Cookie["session"] != "s-123" {
+		t.Errorf("expected cookie 'session' = 's-123', got %q", params.Cookie["session"])
+	}
+	if v, ok := params.Cookie["theme"]; ok || v != "" {
+		t.Errorf("expected empty string for missing cookie, got %q", v)
+	}
+	if _, ok := params.Cookie["tracking"]; ok {
+		t.Error("expected undeclared cookie to be ignored")
+	}
+}
+
+func TestHandler_Process_MissingRequiredHeader(t *testing.T) {
+	called := false
+	handler := NewHandler[NoBody, testOutput](
+		"test",
+		"POST",
+		"/test",
+		func(req *Request[NoBody]) (testOutput, error) {
+			called = true
+			return testOutput{Message: req.Params.Header["X-Idempotency-Key"]}, nil
+		},
+	).WithRequiredHeaderParams("X-Idempotency-Key")
+
+	w := httptest.NewRecorder()
+	status, err := handler.Process(context.Background(), httptest.NewRequest("POST", "/test", nil), w)
+	if err == nil || status != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 with error, got %d (%v)", status, err)
+	}
+	if called {
+		t.Error("handler should not run without the required header")
+	}
+
+	req := httptest.NewRequest("POST", "/test", nil)
+	req.Header.Set("X-Idempotency-Key", "key-1")
+	w = httptest.NewRecorder()
+	if _, err := handler.Process(context.Background(), req, w); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(w.Body.String(), "key-1") {
+		t.Errorf("expected header value in response, got %s", w.Body.String())
+	}
+}
+
 func TestGetRoccoError(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -551,6 +722,56 @@ func TestHandler_WithMaxBodySize(t *testing.T) {
 	}
 }
 
+func TestHandler_Process_StrictJSON(t *testing.T) {
+	newHandler := func() *Handler[testInput, testOutput] {
+		return NewHandler[testInput, testOutput](
+			"test",
+			"POST",
+			"/test",
+			func(req *Request[testInput]) (testOutput, error) {
+				return testOutput{Message: req.Body.Name}, nil
+			},
+		)
+	}
+	body := `{"name":"widget","cuont":3}`
+
+	w := httptest.NewRecorder()
+	if _, err := newHandler().Process(context.Background(), httptest.NewRequest("POST", "/test", strings.NewReader(body)), w); err != nil {
+		t.Fatalf("expected unknown fields to be ignored by default, got %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	_, err := newHandler().WithStrictJSON().Process(context.Background(), httptest.NewRequest("POST", "/test", strings.NewReader(body)), w)
+	if err == nil {
+		t.Fatal("expected error for unknown field")
+	}
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422, got %d", w.Code)
+	}
+	var response struct {
+		Code    string            `json:"code"`
+		Details ValidationDetails `json:"details"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Code != "VALIDATION_FAILED" || len(response.Details.Fields) != 1 || response.Details.Fields[0].Field != "cuont" {
+		t.Errorf("expected a validation error naming cuont, got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	_, err = newHandler().WithStrictJSON().Process(context.Background(), httptest.NewRequest("POST", "/test", strings.NewReader(`{"name":"a"} {"name":"b"}`)), w)
+	if err == nil || w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected trailing data to be rejected with 422, got %d: %v", w.Code, err)
+	}
+
+	w = httptest.NewRecorder()
+	_, err = newHandler().WithStrictJSON().WithMaxBodySize(10).Process(context.Background(), httptest.NewRequest("POST", "/test", strings.NewReader(body)), w)
+	if err == nil || w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status 413 before decoding, got %d: %v", w.Code, err)
+	}
+}
+
 func TestHandler_Process_MaxBodySizeExceeded(t *testing.T) {
 	handler := NewHandler[testInput, testOutput](
 		"test",
@@ -639,6 +860,112 @@ func TestHandler_Process_ResponseHeaders(t *testing.T) {
 	}
 }
 
+func TestHandler_WithRawResponse(t *testing.T) {
+	engine := newTestEngine().WithResponseEnvelope(func(data any) any {
+		return map[string]any{"data": data}
+	})
+	engine.WithHandlers(
+		NewHandler[NoBody, []byte]("badge", "GET", "/badge.svg", func(_ *Request[NoBody]) ([]byte, error) {
+			return []byte("<svg/>"), nil
+		}).WithRawResponse("image/svg+xml"),
+		NewHandler[NoBody, string]("health", "GET", "/health", func(_ *Request[NoBody]) (string, error) {
+			return "ok", nil
+		}).WithRawResponse("text/plain; charset=utf-8"),
+	)
+
+	tests := []struct {
+		path        string
+		contentType string
+		body        string
+	}{
+		{"/badge.svg", "image/svg+xml", "<svg/>"},
+		{"/health", "text/plain; charset=utf-8", "ok"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != http.StatusOK || w.Body.String() != tt.body {
+			t.Errorf("GET %s: expected 200 with %q, got %d: %q", tt.path, tt.body, w.Code, w.Body.String())
+		}
+		if ct := w.Header().Get("Content-Type"); ct != tt.contentType {
+			t.Errorf("GET %s: expected Content-Type %q, got %q", tt.path, tt.contentType, ct)
+		}
+	}
+
+	content := engine.GenerateOpenAPI(nil).Paths["/badge.svg"].Get.Responses["200"].Content
+	if _, ok := content["image/svg+xml"]; !ok || len(content) != 1 {
+		t.Errorf("expected only image/svg+xml response content, got %v", content)
+	}
+	if err := engine.ValidateSpec(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	structOutput := NewHandler[NoBody, testOutput]("test", "GET", "/test", func(_ *Request[NoBody]) (testOutput, error) {
+		return testOutput{}, nil
+	}).WithRawResponse("text/plain")
+	if len(structOutput.ScanErrors()) != 1 || len(structOutput.Spec().ResponseContentTypes) != 0 {
+		t.Errorf("expected a scan error for a struct output type, got %v", structOutput.ScanErrors())
+	}
+}
+
+// trackedBody is an upstream response body that records whether it was closed.
+type trackedBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *trackedBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestHandler_WithRawResponse_Reader(t *testing.T) {
+	upstream := &trackedBody{Reader: strings.NewReader(strings.Repeat("chunk;", 10000))}
+	engine := newTestEngine()
+	engine.WithHandlers(
+		NewHandler[NoBody, io.ReadCloser]("proxy", "GET", "/proxy", func(_ *Request[NoBody]) (io.ReadCloser, error) {
+			return upstream, nil
+		}).WithRawResponse("application/x-ndjson"),
+		NewHandler[NoBody, io.Reader]("sniffed", "GET", "/sniffed", func(_ *Request[NoBody]) (io.Reader, error) {
+			return strings.NewReader("<html><body>hi</body></html>"), nil
+		}).WithRawResponse(""),
+	)
+
+	w := httptest.NewRecorder()
+	engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/proxy", nil))
+	if w.Code != http.StatusOK || w.Body.Len() != len("chunk;")*10000 {
+		t.Errorf("expected the upstream body copied through, got %d with %d bytes", w.Code, w.Body.Len())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("expected the declared Content-Type, got %q", ct)
+	}
+	if w.Header().Get("Content-Length") != "" {
+		t.Error("expected no Content-Length for a reader of unknown size")
+	}
+	if !upstream.closed {
+		t.Error("expected the reader to be closed after copying")
+	}
+
+	server := httptest.NewServer(engine.Handler())
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/sniffed")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("expected a detected Content-Type, got %q", ct)
+	}
+
+	content := engine.GenerateOpenAPI(nil).Paths["/sniffed"].Get.Responses["200"].Content
+	if _, ok := content["application/octet-stream"]; !ok {
+		t.Errorf("expected application/octet-stream response content, got %v", content)
+	}
+	if err := engine.ValidateSpec(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
 func TestHandler_WithAuthentication(t *testing.T) {
 	handler := NewHandler[NoBody, testOutput](
 		"test",
@@ -902,3 +1229,700 @@ type failingCloser struct {
 func (f *failingCloser) Close() error {
 	return errors.New("close failed")
 }
+
+func TestHandler_WithSparseFields(t *testing.T) {
+	handler := NewHandler[NoBody, testOutput](
+		"test",
+		"GET",
+		"/test",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{Message: "hello", Result: 42}, nil
+		},
+	).WithSparseFields()
+
+	if !handler.Spec().SparseFields {
+		t.Error("expected SparseFields to be true")
+	}
+
+	req := httptest.NewRequest("GET", "/test?fields=message", nil)
+	w := httptest.NewRecorder()
+
+	_, err := handler.Process(context.Background(), req, w)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var result map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(result) != 1 {
+		t.Errorf("expected 1 field, got %d: %v", len(result), result)
+	}
+	if result["message"] != "hello" {
+		t.Errorf("expected message 'hello', got %v", result["message"])
+	}
+}
+
+func TestHandler_WithSparseFields_NoParam(t *testing.T) {
+	handler := NewHandler[NoBody, testOutput](
+		"test",
+		"GET",
+		"/test",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{Message: "hello", Result: 42}, nil
+		},
+	).WithSparseFields()
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+
+	handler.Process(context.Background(), req, w)
+
+	var result map[string]any
+	json.Unmarshal(w.Body.Bytes(), &result)
+	if len(result) != 2 {
+		t.Errorf("expected all fields, got %v", result)
+	}
+}
+
+func TestFilterFields_NonObject(t *testing.T) {
+	body := []byte(`[1,2,3]`)
+	if got := filterFields(body, "id"); string(got) != string(body) {
+		t.Errorf("expected non-object body unchanged, got %s", got)
+	}
+}
+
+func TestHandler_WithAbortOnDisconnect(t *testing.T) {
+	setupSyncMode(t)
+
+	var abortedHandler string
+	listener := capitan.Hook(RequestAborted, func(_ context.Context, e *capitan.Event) {
+		abortedHandler, _ = HandlerNameKey.From(e)
+	})
+	defer listener.Close()
+
+	started := make(chan struct{})
+	handlerCancelled := make(chan struct{})
+
+	handler := NewHandler[NoBody, testOutput](
+		"slow",
+		"GET",
+		"/slow",
+		func(req *Request[NoBody]) (testOutput, error) {
+			close(started)
+			<-req.Done()
+			close(handlerCancelled)
+			return testOutput{Message: "too late"}, nil
+		},
+	).WithAbortOnDisconnect()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/slow", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	status, err := handler.Process(ctx, req, w)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != StatusClientClosedRequest {
+		t.Errorf("expected status %d, got %d", StatusClientClosedRequest, status)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("expected no response body, got %q", w.Body.String())
+	}
+	if abortedHandler != "slow" {
+		t.Errorf("expected a RequestAborted event for the handler, got %q", abortedHandler)
+	}
+	<-handlerCancelled
+}
+
+func TestHandler_WithAbortOnDisconnect_Completes(t *testing.T) {
+	handler := NewHandler[NoBody, testOutput](
+		"fast",
+		"GET",
+		"/fast",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{Message: "done"}, nil
+		},
+	).WithAbortOnDisconnect()
+
+	req := httptest.NewRequest("GET", "/fast", nil)
+	w := httptest.NewRecorder()
+
+	status, err := handler.Process(context.Background(), req, w)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != 200 {
+		t.Errorf("expected status 200, got %d", status)
+	}
+	if !strings.Contains(w.Body.String(), "done") {
+		t.Errorf("expected response body, got %q", w.Body.String())
+	}
+}
+
+func TestHandler_Process_DeadlineExceeded(t *testing.T) {
+	handler := NewHandler[NoBody, testOutput](
+		"timeout",
+		"GET",
+		"/timeout",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{}, fmt.Errorf("query: %w", context.DeadlineExceeded)
+		},
+	)
+
+	req := httptest.NewRequest("GET", "/timeout", nil)
+	w := httptest.NewRecorder()
+
+	status, err := handler.Process(context.Background(), req, w)
+	if err == nil {
+		t.Error("expected error to be returned")
+	}
+	if status != http.StatusGatewayTimeout || w.Code != http.StatusGatewayTimeout {
+		t.Errorf("expected status 504, got %d (written %d)", status, w.Code)
+	}
+
+	var resp errorResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Code != "GATEWAY_TIMEOUT" {
+		t.Errorf("expected code GATEWAY_TIMEOUT, got %q", resp.Code)
+	}
+}
+
+func TestHandler_Process_Canceled(t *testing.T) {
+	setupSyncMode(t)
+
+	var canceledHandler string
+	listener := capitan.Hook(HandlerCanceled, func(_ context.Context, e *capitan.Event) {
+		canceledHandler, _ = HandlerNameKey.From(e)
+	})
+	defer listener.Close()
+
+	handler := NewHandler[NoBody, testOutput](
+		"canceled",
+		"GET",
+		"/canceled",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{}, fmt.Errorf("query: %w", context.Canceled)
+		},
+	)
+
+	// The client went away: nobody is listening, so no body is written.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest("GET", "/canceled", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	status, err := handler.Process(ctx, req, w)
+	if err != nil {
+		t.Errorf("expected nil error, got %v", err)
+	}
+	if status != StatusClientClosedRequest {
+		t.Errorf("expected status %d, got %d", StatusClientClosedRequest, status)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("expected no body, got %q", w.Body.String())
+	}
+	if canceledHandler != "canceled" {
+		t.Errorf("expected a HandlerCanceled event for the handler, got %q", canceledHandler)
+	}
+
+	// The client is still connected, so the handler's own cancellation is a server error.
+	canceledHandler = ""
+	req = httptest.NewRequest("GET", "/canceled", nil)
+	w = httptest.NewRecorder()
+
+	status, err = handler.Process(context.Background(), req, w)
+	if err == nil {
+		t.Error("expected error to be returned")
+	}
+	if status != http.StatusInternalServerError || w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d (written %d)", status, w.Code)
+	}
+	if canceledHandler != "" {
+		t.Errorf("expected no HandlerCanceled event, got %q", canceledHandler)
+	}
+}
+
+func TestHandler_WithRequestMediaType(t *testing.T) {
+	handler := NewHandler[testInput, testOutput](
+		"patch",
+		"PATCH",
+		"/items",
+		func(req *Request[testInput]) (testOutput, error) {
+			return testOutput{Message: req.Body.Name}, nil
+		},
+	).WithRequestMediaType("application/merge-patch+json")
+
+	if handler.Spec().RequestMediaType != "application/merge-patch+json" {
+		t.Errorf("expected media type in spec, got %q", handler.Spec().RequestMediaType)
+	}
+
+	tests := []struct {
+		contentType string
+		wantStatus  int
+	}{
+		{"application/merge-patch+json", http.StatusOK},
+		{"application/merge-patch+json; charset=utf-8", http.StatusOK},
+		{"", http.StatusOK},
+		{"application/json", http.StatusUnsupportedMediaType},
+		{"not a media type;;", http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			req := httptest.NewRequest("PATCH", "/items", strings.NewReader(`{"name":"x"}`))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+
+			status, _ := handler.Process(context.Background(), req, w)
+			if status != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, status)
+			}
+		})
+	}
+}
+
+func TestHandler_PrepareFastPath(t *testing.T) {
+	noBody := func(method string) *Handler[NoBody, testOutput] {
+		return NewHandler[NoBody, testOutput]("fast", method, "/fast", func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{}, nil
+		})
+	}
+
+	cases := []struct {
+		name    string
+		handler *Handler[NoBody, testOutput]
+		want    bool
+	}{
+		{"get no body", noBody("GET"), true},
+		{"non-get", noBody("DELETE"), false},
+		{"path params", noBody("GET").WithPathParams("id"), false},
+		{"query params", noBody("GET").WithQueryParams("q"), false},
+		{"header params", noBody("GET").WithHeaderParams("X-Request-ID"), false},
+		{"authentication", noBody("GET").WithAuthentication(), false},
+		{"optional authentication", noBody("GET").WithOptionalAuthentication(), false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.handler.prepareFastPath()
+			if tc.handler.fastPath != tc.want {
+				t.Errorf("expected fastPath %v, got %v", tc.want, tc.handler.fastPath)
+			}
+		})
+	}
+
+	t.Run("body input", func(t *testing.T) {
+		handler := NewHandler[testInput, testOutput]("body", "GET", "/body", func(_ *Request[testInput]) (testOutput, error) {
+			return testOutput{}, nil
+		})
+		handler.prepareFastPath()
+		if handler.fastPath {
+			t.Error("handlers with a body should not use the fast path")
+		}
+	})
+}
+
+func TestHandler_FastPath_Process(t *testing.T) {
+	engine := newTestEngine()
+
+	var gotParams Params
+	var gotIdentity Identity
+	var gotContext, gotRequest bool
+	handler := NewHandler[NoBody, testOutput](
+		"fast",
+		"GET",
+		"/fast",
+		func(req *Request[NoBody]) (testOutput, error) {
+			gotParams = *req.Params
+			gotIdentity = req.Identity
+			gotContext, gotRequest = req.Context != nil, req.Request != nil
+			return testOutput{Message: "hello"}, nil
+		},
+	).WithResponseHeaders(map[string]string{"X-Custom": "yes"})
+	engine.WithHandlers(handler)
+
+	if !handler.fastPath {
+		t.Fatal("expected handler to be selected for the fast path at registration")
+	}
+
+	w := httptest.NewRecorder()
+	engine.Router().ServeHTTP(w, httptest.NewRequest("GET", "/fast", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected JSON content type, got %q", w.Header().Get("Content-Type"))
+	}
+	if w.Header().Get("X-Custom") != "yes" {
+		t.Error("expected response headers to be applied")
+	}
+	if !strings.Contains(w.Body.String(), `"hello"`) {
+		t.Errorf("unexpected body: %s", w.Body.String())
+	}
+
+	if gotParams.Path["missing"] != "" || len(gotParams.Query) != 0 {
+		t.Errorf("expected empty params, got %+v", gotParams)
+	}
+	if _, ok := gotIdentity.(NoIdentity); !ok {
+		t.Errorf("expected NoIdentity, got %T", gotIdentity)
+	}
+	if !gotContext || !gotRequest {
+		t.Error("expected context and request to be set")
+	}
+}
+
+func TestHandler_FastPath_EngineAllocations(t *testing.T) {
+	allocs := func(engine *Engine) float64 {
+		engine.WithHandlers(NewHandler[NoBody, testOutput]("fast", "GET", "/fast", func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{Message: "hello"}, nil
+		}))
+		server := engine.Handler()
+		req := httptest.NewRequest("GET", "/fast", nil)
+		// Enough runs to average out sync.Pool's random drops under the race detector.
+		return testing.AllocsPerRun(1000, func() {
+			server.ServeHTTP(httptest.NewRecorder(), req)
+		})
+	}
+
+	plain := allocs(newTestEngine())
+	configured := allocs(newTestEngine().
+		WithErrorDetailMode(DetailsProd).
+		WithValidationErrorFormatter(func(validator.ValidationErrors) ErrorDefinition { return nil }))
+
+	// The engine's settings reach the handler through one context value, whatever their number.
+	if configured > plain+1 {
+		t.Errorf("expected engine settings to cost at most one allocation, got %v vs %v", configured, plain)
+	}
+}
+
+func TestHandler_RequestPooling(t *testing.T) {
+	var retained *Request[testInput]
+	var sawStale bool
+	handler := NewHandler[testInput, testOutput](
+		"pooled",
+		"POST",
+		"/pooled",
+		func(req *Request[testInput]) (testOutput, error) {
+			if req.WasProvided("count") && !strings.Contains(req.Body.Name, "count") {
+				sawStale = true
+			}
+			retained = req
+			return testOutput{Message: req.Body.Name}, nil
+		},
+	)
+
+	bodies := []string{`{"name":"with count","count":1}`, `{"name":"without"}`}
+	for _, body := range bodies {
+		w := httptest.NewRecorder()
+		if _, err := handler.Process(context.Background(), httptest.NewRequest("POST", "/pooled", strings.NewReader(body)), w); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(w.Body.String(), "with") {
+			t.Errorf("unexpected body: %s", w.Body.String())
+		}
+
+		// Released requests are cleared so they hold no request data.
+		if retained.Body.Name != "" || retained.Request != nil || retained.Params != nil || retained.provided != nil {
+			t.Errorf("expected released request to be cleared, got %+v", retained)
+		}
+	}
+
+	if sawStale {
+		t.Error("provided fields leaked between pooled requests")
+	}
+}
+
+func TestHandler_RequestPooling_Abandoned(t *testing.T) {
+	release := make(chan struct{})
+	finished := make(chan string, 1)
+	handler := NewHandler[testInput, testOutput](
+		"abandoned",
+		"POST",
+		"/abandoned",
+		func(req *Request[testInput]) (testOutput, error) {
+			if req.Body.Name == "other" {
+				return testOutput{}, nil
+			}
+			<-release
+			// Still running after the 504 was written: the request must be intact.
+			finished <- req.Body.Name
+			return testOutput{}, nil
+		},
+	).WithTimeout(10 * time.Millisecond)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/abandoned", strings.NewReader(`{"name":"kept"}`))
+	if status, _ := handler.Process(context.Background(), req, w); status != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d", status)
+	}
+
+	// Another request through the same pool must not receive the abandoned request.
+	w = httptest.NewRecorder()
+	if status, _ := handler.Process(context.Background(), httptest.NewRequest("POST", "/abandoned", strings.NewReader(`{"name":"other"}`)), w); status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+
+	close(release)
+	if name := <-finished; name != "kept" {
+		t.Errorf("expected the abandoned handler to keep its request, got %q", name)
+	}
+}
+
+func TestHandler_ContentLength(t *testing.T) {
+	handler := NewHandler[NoBody, testOutput](
+		"sized",
+		"GET",
+		"/sized",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{Message: "hello", Result: 42}, nil
+		},
+	).WithSparseFields()
+
+	for _, target := range []string{"/sized", "/sized?fields=message"} {
+		t.Run(target, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if _, err := handler.Process(context.Background(), httptest.NewRequest("GET", target, nil), w); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want := strconv.Itoa(w.Body.Len())
+			if got := w.Header().Get("Content-Length"); got != want {
+				t.Errorf("expected Content-Length %s, got %q", want, got)
+			}
+		})
+	}
+
+	t.Run("served without chunking", func(t *testing.T) {
+		engine := newTestEngine()
+		engine.WithHandlers(handler)
+		server := httptest.NewServer(engine.Router())
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "/sized")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.ContentLength <= 0 {
+			t.Errorf("expected Content-Length, got %d", resp.ContentLength)
+		}
+		if len(resp.TransferEncoding) != 0 {
+			t.Errorf("expected no transfer encoding, got %v", resp.TransferEncoding)
+		}
+	})
+}
+
+func TestHandler_WithLastModified(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 30, 45, 500, time.UTC)
+	handler := NewHandler[NoBody, testOutput](
+		"article",
+		"GET",
+		"/article",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{Message: "article", Result: 1}, nil
+		},
+	).WithLastModified(func(testOutput) time.Time {
+		return modified
+	}).WithResponseHeaders(map[string]string{"Cache-Control": "max-age=60"})
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		status  int
+	}{
+		{"unconditional", "GET", nil, http.StatusOK},
+		{"not modified", "GET", map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, http.StatusNotModified},
+		{"client copy newer", "GET", map[string]string{"If-Modified-Since": modified.Add(time.Hour).Format(http.TimeFormat)}, http.StatusNotModified},
+		{"modified since", "GET", map[string]string{"If-Modified-Since": modified.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusOK},
+		{"invalid date", "GET", map[string]string{"If-Modified-Since": "yesterday"}, http.StatusOK},
+		{"If-None-Match takes precedence", "GET", map[string]string{
+			"If-Modified-Since": modified.Format(http.TimeFormat),
+			"If-None-Match":     `"v1"`,
+		}, http.StatusOK},
+		{"unsafe method", "POST", map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/article", nil)
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			status, err := handler.Process(context.Background(), req, w)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if status != tt.status || w.Code != tt.status {
+				t.Fatalf("expected %d, got %d (recorded %d)", tt.status, status, w.Code)
+			}
+			if got := w.Header().Get("Last-Modified"); got != "Fri, 01 Mar 2024 12:30:45 GMT" {
+				t.Errorf("unexpected Last-Modified %q", got)
+			}
+			if got := w.Header().Get("Cache-Control"); got != "max-age=60" {
+				t.Errorf("expected response headers on %d, got Cache-Control %q", tt.status, got)
+			}
+			if tt.status == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("expected empty 304 body, got %q", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_WithLastModified_ZeroTime(t *testing.T) {
+	handler := NewHandler[NoBody, testOutput](
+		"draft",
+		"GET",
+		"/draft",
+		func(_ *Request[NoBody]) (testOutput, error) { return testOutput{}, nil },
+	).WithLastModified(func(testOutput) time.Time { return time.Time{} })
+
+	req := httptest.NewRequest("GET", "/draft", nil)
+	req.Header.Set("If-Modified-Since", time.Now().UTC().Format(http.TimeFormat))
+	w := httptest.NewRecorder()
+	status, _ := handler.Process(context.Background(), req, w)
+	if status != http.StatusOK {
+		t.Errorf("expected 200, got %d", status)
+	}
+	if got := w.Header().Get("Last-Modified"); got != "" {
+		t.Errorf("expected no Last-Modified header, got %q", got)
+	}
+}
+
+func TestHandler_WithTimeout(t *testing.T) {
+	tests := []struct {
+		name   string
+		fn     func(*Request[NoBody]) (testOutput, error)
+		status int
+	}{
+		{
+			name: "completes in time",
+			fn: func(req *Request[NoBody]) (testOutput, error) {
+				if _, ok := req.Context.Deadline(); !ok {
+					return testOutput{}, errors.New("expected a deadline on req.Context")
+				}
+				if _, ok := req.Request.Context().Deadline(); !ok {
+					return testOutput{}, errors.New("expected a deadline on the http.Request context")
+				}
+				return testOutput{Message: "fast"}, nil
+			},
+			status: http.StatusOK,
+		},
+		{
+			name: "observes deadline",
+			fn: func(req *Request[NoBody]) (testOutput, error) {
+				<-req.Context.Done()
+				return testOutput{}, req.Context.Err()
+			},
+			status: http.StatusGatewayTimeout,
+		},
+		{
+			name: "ignores deadline",
+			fn: func(_ *Request[NoBody]) (testOutput, error) {
+				time.Sleep(200 * time.Millisecond)
+				return testOutput{Message: "too late"}, nil
+			},
+			status: http.StatusGatewayTimeout,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHandler[NoBody, testOutput]("slow", "GET", "/slow", tt.fn).
+				WithTimeout(20 * time.Millisecond)
+
+			w := httptest.NewRecorder()
+			start := time.Now()
+			status, _ := handler.Process(context.Background(), httptest.NewRequest("GET", "/slow", nil), w)
+			if status != tt.status || w.Code != tt.status {
+				t.Fatalf("expected %d, got %d (recorded %d): %s", tt.status, status, w.Code, w.Body.String())
+			}
+			if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+				t.Errorf("response was not bounded by the timeout (took %v)", elapsed)
+			}
+			if tt.status == http.StatusGatewayTimeout && !strings.Contains(w.Body.String(), ErrGatewayTimeout.Code()) {
+				t.Errorf("expected %s body, got %s", ErrGatewayTimeout.Code(), w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandler_WithTimeout_Disconnect(t *testing.T) {
+	setupSyncMode(t)
+
+	aborted := false
+	listener := capitan.Hook(RequestAborted, func(context.Context, *capitan.Event) { aborted = true })
+	defer listener.Close()
+
+	// Without WithAbortOnDisconnect, a disconnect cancels the handler's context but its
+	// result is still handled; only the deadline abandons it.
+	handler := NewHandler[NoBody, testOutput]("slow", "GET", "/slow", func(req *Request[NoBody]) (testOutput, error) {
+		<-req.Context.Done()
+		return testOutput{Message: "done"}, nil
+	}).WithTimeout(time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	w := httptest.NewRecorder()
+	status, _ := handler.Process(ctx, httptest.NewRequest("GET", "/slow", nil).WithContext(ctx), w)
+	if status != http.StatusOK || aborted {
+		t.Errorf("expected the handler's result after a disconnect, got %d (aborted=%v)", status, aborted)
+	}
+}
+
+func TestHandler_WithTimeout_DeclaresGatewayTimeout(t *testing.T) {
+	handler := NewHandler[NoBody, testOutput]("slow", "GET", "/slow", func(_ *Request[NoBody]) (testOutput, error) {
+		return testOutput{}, nil
+	}).WithErrors(ErrGatewayTimeout).WithTimeout(time.Second).WithTimeout(2 * time.Second)
+
+	if len(handler.ErrorDefs()) != 1 {
+		t.Errorf("expected ErrGatewayTimeout declared once, got %d definitions", len(handler.ErrorDefs()))
+	}
+
+	zero := NewHandler[NoBody, testOutput]("fast", "GET", "/fast", func(_ *Request[NoBody]) (testOutput, error) {
+		return testOutput{}, nil
+	}).WithTimeout(0)
+	if len(zero.ErrorDefs()) != 0 {
+		t.Error("a zero timeout should not declare ErrGatewayTimeout")
+	}
+}
+
+func TestEncodeJSON_MatchesMarshal(t *testing.T) {
+	values := []any{
+		testOutput{Message: "<b>&</b>", Result: 7},
+		map[string]any{"b": 1, "a": []int{1, 2}},
+		json.RawMessage(`{ "spaced" : true }`),
+		"text",
+	}
+	for _, v := range values {
+		want, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := acquireResponseBuffer()
+		got, err := encodeJSON(buf, v)
+		if err != nil {
+			t.Fatalf("encodeJSON(%v): %v", v, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("encodeJSON(%v) = %s, want %s", v, got, want)
+		}
+		releaseResponseBuffer(buf)
+	}
+
+	buf := acquireResponseBuffer()
+	if _, err := encodeJSON(buf, make(chan int)); err == nil {
+		t.Error("expected an error for an unsupported type")
+	}
+}
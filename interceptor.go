@@ -0,0 +1,50 @@
+package rocco
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/zoobzio/capitan"
+)
+
+// RequestInterceptor inspects a request before any middleware runs and rejects it by
+// returning an error, e.g. for a host allowlist or maintenance mode. Return nil to let
+// the request through.
+type RequestInterceptor func(ctx context.Context, r *http.Request) error
+
+// WithRequestInterceptor adds an interceptor run for every routed request, including
+// /openapi, /openapi.yaml and /docs, before global and handler middleware. Interceptors
+// run in the order added; the first to return an error rejects the request. Rocco errors
+// are written as they are (e.g. ErrServiceUnavailable); any other error becomes a 500.
+// Interceptors see RemoteAddr as resolved by WithTrustedProxies.
+func (e *Engine) WithRequestInterceptor(interceptor RequestInterceptor) *Engine {
+	e.interceptors = append(e.interceptors, interceptor)
+	return e
+}
+
+// interceptRequests runs the engine's interceptors before next, answering a rejected
+// request with its error on behalf of the named handler.
+func (e *Engine) interceptRequests(next http.Handler, handlerName string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, intercept := range e.interceptors {
+			err := intercept(r.Context(), r)
+			if err == nil {
+				continue
+			}
+			errDef := getRoccoError(err)
+			if errDef == nil {
+				errDef = ErrInternalServer.WithCause(err)
+			}
+			capitan.Warn(r.Context(), RequestIntercepted,
+				MethodKey.Field(r.Method),
+				PathKey.Field(r.URL.Path),
+				HandlerNameKey.Field(handlerName),
+				StatusCodeKey.Field(errDef.Status()),
+				ErrorKey.Field(errorChain(err)),
+			)
+			writeError(e.withRequestSettings(r.Context()), w, errDef, handlerName)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,148 @@
+package rocco
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zoobzio/capitan"
+)
+
+func TestEngine_WithRequestInterceptor(t *testing.T) {
+	allowlist := func(_ context.Context, r *http.Request) error {
+		if strings.HasPrefix(r.URL.Path, "/docs") {
+			return nil
+		}
+		return ErrForbidden.WithMessage("host not allowed")
+	}
+
+	var called bool
+	engine := newTestEngine().WithRequestInterceptor(allowlist)
+	engine.WithHandlers(NewHandler[NoBody, testOutput](
+		"intercepted",
+		"GET",
+		"/intercepted",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			called = true
+			return testOutput{Message: "ok"}, nil
+		},
+	))
+
+	w := httptest.NewRecorder()
+	engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/intercepted", nil))
+
+	if called {
+		t.Error("expected handler not to run")
+	}
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", w.Code)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode error response: %v", err)
+	}
+	if body["code"] != "FORBIDDEN" || body["message"] != "host not allowed" {
+		t.Errorf("unexpected error response: %v", body)
+	}
+
+	w = httptest.NewRecorder()
+	engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/openapi", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected /openapi to be intercepted, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/docs", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected /docs to pass through, got %d", w.Code)
+	}
+}
+
+func TestEngine_WithRequestInterceptor_Order(t *testing.T) {
+	var order []string
+	engine := newTestEngine().
+		WithRequestInterceptor(func(_ context.Context, _ *http.Request) error {
+			order = append(order, "first")
+			return nil
+		}).
+		WithRequestInterceptor(func(_ context.Context, _ *http.Request) error {
+			order = append(order, "second")
+			return nil
+		}).
+		WithMiddleware(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, "middleware")
+				next.ServeHTTP(w, r)
+			})
+		})
+	engine.WithHandlers(NewHandler[NoBody, testOutput](
+		"ordered",
+		"GET",
+		"/ordered",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			order = append(order, "handler")
+			return testOutput{}, nil
+		},
+	))
+
+	w := httptest.NewRecorder()
+	engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/ordered", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if got := strings.Join(order, ","); got != "first,second,middleware,handler" {
+		t.Errorf("unexpected order: %s", got)
+	}
+}
+
+func TestEngine_WithRequestInterceptor_PlainError(t *testing.T) {
+	setupSyncMode(t)
+
+	var status int
+	var handlerName string
+	listener := capitan.Hook(RequestIntercepted, func(_ context.Context, e *capitan.Event) {
+		status, _ = StatusCodeKey.From(e)
+		handlerName, _ = HandlerNameKey.From(e)
+	})
+	defer listener.Close()
+
+	var secondCalled bool
+	engine := newTestEngine().
+		WithErrorDetailMode(DetailsProd).
+		WithRequestInterceptor(func(_ context.Context, _ *http.Request) error {
+			return errors.New("lookup failed")
+		}).
+		WithRequestInterceptor(func(_ context.Context, _ *http.Request) error {
+			secondCalled = true
+			return nil
+		})
+	engine.WithHandlers(NewHandler[NoBody, testOutput](
+		"failing",
+		"GET",
+		"/failing",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	))
+
+	w := httptest.NewRecorder()
+	engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/failing", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "lookup failed") {
+		t.Error("expected cause not to be exposed")
+	}
+	if secondCalled {
+		t.Error("expected later interceptors to be skipped")
+	}
+	if status != http.StatusInternalServerError || handlerName != "failing" {
+		t.Errorf("unexpected event: status=%d handler=%q", status, handlerName)
+	}
+}
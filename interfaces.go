@@ -3,9 +3,15 @@ package rocco
 import (
 	"context"
 	"net/http"
+
+	"github.com/zoobzio/sentinel"
 )
 
 // Endpoint represents an HTTP route handler with metadata.
+// Handler and StreamHandler implement it, and custom implementations may be registered
+// with Engine.WithHandlers. A custom Spec needs only Name, Method and Path; OpenAPI
+// generation treats a missing input type as no body, a missing output type as an
+// empty success response, and a zero SuccessStatus as 200.
 type Endpoint interface {
 	// Process handles the HTTP request and writes the response.
 	// Returns the HTTP status code written and any error encountered.
@@ -18,6 +24,12 @@ type Endpoint interface {
 	// Used by OpenAPI generation to extract error schemas.
 	ErrorDefs() []ErrorDefinition
 
+	// InputMetadata and OutputMetadata return the sentinel metadata of the request
+	// and response types, for tooling that inspects endpoints without knowing their
+	// concrete handler types.
+	InputMetadata() sentinel.Metadata
+	OutputMetadata() sentinel.Metadata
+
 	// Middleware returns handler-specific middleware
 	Middleware() []func(http.Handler) http.Handler
 
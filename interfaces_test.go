@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/zoobzio/sentinel"
 )
 
 // Mock implementation of Endpoint for testing
@@ -129,3 +131,75 @@ func TestEndpoint_Close(t *testing.T) {
 		t.Errorf("unexpected close error: %v", err)
 	}
 }
+
+func TestEndpoint_Metadata(t *testing.T) {
+	endpoints := []Endpoint{
+		NewHandler[testInput, testOutput]("create", "POST", "/items", func(_ *Request[testInput]) (testOutput, error) {
+			return testOutput{}, nil
+		}),
+		NewStreamHandler[NoBody, streamEvent]("events", "GET", "/events", func(_ *Request[NoBody], _ Stream[streamEvent]) error {
+			return nil
+		}),
+	}
+
+	want := [][2]string{{"testInput", "testOutput"}, {"NoBody", "streamEvent"}}
+	for i, endpoint := range endpoints {
+		if got := endpoint.InputMetadata().TypeName; got != want[i][0] {
+			t.Errorf("%s: expected input type %q, got %q", endpoint.Spec().Name, want[i][0], got)
+		}
+		if got := endpoint.OutputMetadata().TypeName; got != want[i][1] {
+			t.Errorf("%s: expected output type %q, got %q", endpoint.Spec().Name, want[i][1], got)
+		}
+	}
+}
+
+// minimalEndpoint is a custom Endpoint that reports only routing metadata.
+type minimalEndpoint struct {
+	spec HandlerSpec
+}
+
+func (*minimalEndpoint) Process(_ context.Context, _ *http.Request, w http.ResponseWriter) (int, error) {
+	w.WriteHeader(http.StatusNoContent)
+	return http.StatusNoContent, nil
+}
+
+func (m *minimalEndpoint) Spec() HandlerSpec { return m.spec }
+
+func (*minimalEndpoint) ErrorDefs() []ErrorDefinition { return nil }
+
+func (*minimalEndpoint) InputMetadata() sentinel.Metadata { return sentinel.Metadata{} }
+
+func (*minimalEndpoint) OutputMetadata() sentinel.Metadata { return sentinel.Metadata{} }
+
+func (*minimalEndpoint) Middleware() []func(http.Handler) http.Handler { return nil }
+
+func (*minimalEndpoint) Close() error { return nil }
+
+func TestEndpoint_CustomMinimal(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(&minimalEndpoint{spec: HandlerSpec{Name: "ping", Method: "GET", Path: "/ping"}})
+
+	op := engine.GenerateOpenAPI(nil).Paths["/ping"].Get
+	if op == nil {
+		t.Fatal("expected GET /ping operation")
+	}
+	if op.RequestBody != nil {
+		t.Error("expected no request body without an input type")
+	}
+	success, ok := op.Responses["200"]
+	if !ok {
+		t.Fatalf("expected default 200 response, got %v", op.Responses)
+	}
+	if success.Content != nil {
+		t.Errorf("expected no response content without an output type, got %v", success.Content)
+	}
+	if err := engine.ValidateSpec(); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, httptest.NewRequest("GET", "/ping", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected 204 from custom endpoint, got %d", w.Code)
+	}
+}
@@ -0,0 +1,328 @@
+package rocco
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/zoobzio/openapi"
+)
+
+// jsonSchemaValidator validates raw JSON documents against a JSON Schema.
+//
+// A practical subset of JSON Schema is supported: type, enum, const, required,
+// properties, additionalProperties, items, minItems, maxItems, uniqueItems,
+// minLength, maxLength, pattern, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, multipleOf, minProperties, maxProperties, allOf, anyOf,
+// oneOf, and not. References ($ref) are rejected at compile time.
+type jsonSchemaValidator struct {
+	schema   *openapi.Schema
+	patterns map[string]*regexp.Regexp
+}
+
+// compileJSONSchema parses a JSON Schema document and precompiles its patterns.
+func compileJSONSchema(data []byte) (*jsonSchemaValidator, error) {
+	var schema openapi.Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+
+	v := &jsonSchemaValidator{schema: &schema, patterns: make(map[string]*regexp.Regexp)}
+	if err := v.compile(&schema); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	return v, nil
+}
+
+// compile walks the schema, compiling patterns and rejecting unsupported keywords.
+func (v *jsonSchemaValidator) compile(s *openapi.Schema) error {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		return fmt.Errorf("$ref %q is not supported", s.Ref)
+	}
+	if s.Pattern != "" {
+		if _, ok := v.patterns[s.Pattern]; !ok {
+			re, err := regexp.Compile(s.Pattern)
+			if err != nil {
+				return fmt.Errorf("pattern %q: %w", s.Pattern, err)
+			}
+			v.patterns[s.Pattern] = re
+		}
+	}
+
+	children := []*openapi.Schema{s.Items, s.Not}
+	children = append(children, s.AllOf...)
+	children = append(children, s.AnyOf...)
+	children = append(children, s.OneOf...)
+	for _, prop := range s.Properties {
+		children = append(children, prop)
+	}
+	if additional, ok := additionalPropertiesSchema(s.AdditionalProperties); ok {
+		children = append(children, additional)
+	}
+	for _, child := range children {
+		if err := v.compile(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// additionalPropertiesSchema converts a decoded additionalProperties object into a schema.
+func additionalPropertiesSchema(value any) (*openapi.Schema, bool) {
+	obj, ok := value.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, false
+	}
+	var schema openapi.Schema
+	if json.Unmarshal(data, &schema) != nil {
+		return nil, false
+	}
+	return &schema, true
+}
+
+// validate checks body against the schema and returns every violation found.
+// Malformed JSON yields no violations so the caller's decode step reports it.
+func (v *jsonSchemaValidator) validate(body []byte) []ValidationFieldError {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil
+	}
+	return v.check(v.schema, doc, "")
+}
+
+// check validates value at path against s.
+func (v *jsonSchemaValidator) check(s *openapi.Schema, value any, path string) []ValidationFieldError {
+	if s == nil {
+		return nil
+	}
+
+	var violations []ValidationFieldError
+	fail := func(keyword string, detail any) {
+		violations = append(violations, ValidationFieldError{
+			Field: path,
+			Tag:   keyword,
+			Value: fmt.Sprintf("%v", detail),
+		})
+	}
+
+	if s.Type != nil && !s.Type.IsEmpty() && !matchesJSONType(s.Type, value) {
+		fail("type", jsonTypeOf(value))
+		return violations // Remaining keywords assume the declared type.
+	}
+
+	if len(s.Enum) > 0 && !containsJSONValue(s.Enum, value) {
+		fail("enum", value)
+	}
+	if s.Const != nil && !jsonEqual(s.Const, value) {
+		fail("const", value)
+	}
+
+	switch val := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(val)
+		if s.MinLength != nil && length < *s.MinLength {
+			fail("minLength", val)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			fail("maxLength", val)
+		}
+		if s.Pattern != "" && !v.patterns[s.Pattern].MatchString(val) {
+			fail("pattern", val)
+		}
+
+	case json.Number:
+		n, _ := val.Float64()
+		if s.Minimum != nil && n < *s.Minimum {
+			fail("minimum", val)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			fail("maximum", val)
+		}
+		if s.ExclusiveMinimum != nil && n <= *s.ExclusiveMinimum {
+			fail("exclusiveMinimum", val)
+		}
+		if s.ExclusiveMaximum != nil && n >= *s.ExclusiveMaximum {
+			fail("exclusiveMaximum", val)
+		}
+		if s.MultipleOf != nil && *s.MultipleOf != 0 {
+			if q := n / *s.MultipleOf; math.Abs(q-math.Round(q)) > 1e-9 {
+				fail("multipleOf", val)
+			}
+		}
+
+	case []any:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			fail("minItems", len(val))
+		}
+		if s.MaxItems != nil && len(val) > *s.MaxItems {
+			fail("maxItems", len(val))
+		}
+		if s.UniqueItems != nil && *s.UniqueItems && !uniqueJSONValues(val) {
+			fail("uniqueItems", len(val))
+		}
+		for i, item := range val {
+			violations = append(violations, v.check(s.Items, item, path+"["+strconv.Itoa(i)+"]")...)
+		}
+
+	case map[string]any:
+		if s.MinProperties != nil && len(val) < *s.MinProperties {
+			fail("minProperties", len(val))
+		}
+		if s.MaxProperties != nil && len(val) > *s.MaxProperties {
+			fail("maxProperties", len(val))
+		}
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				violations = append(violations, ValidationFieldError{Field: joinJSONPath(path, name), Tag: "required"})
+			}
+		}
+		additional, additionalIsSchema := additionalPropertiesSchema(s.AdditionalProperties)
+		for name, propValue := range val {
+			propPath := joinJSONPath(path, name)
+			if propSchema, ok := s.Properties[name]; ok {
+				violations = append(violations, v.check(propSchema, propValue, propPath)...)
+				continue
+			}
+			if allowed, ok := s.AdditionalProperties.(bool); ok && !allowed {
+				violations = append(violations, ValidationFieldError{Field: propPath, Tag: "additionalProperties"})
+			} else if additionalIsSchema {
+				violations = append(violations, v.check(additional, propValue, propPath)...)
+			}
+		}
+	}
+
+	for _, sub := range s.AllOf {
+		violations = append(violations, v.check(sub, value, path)...)
+	}
+	if len(s.AnyOf) > 0 && v.countMatches(s.AnyOf, value, path) == 0 {
+		fail("anyOf", "no subschema matched")
+	}
+	if len(s.OneOf) > 0 {
+		if matches := v.countMatches(s.OneOf, value, path); matches != 1 {
+			fail("oneOf", fmt.Sprintf("%d subschemas matched", matches))
+		}
+	}
+	if s.Not != nil && len(v.check(s.Not, value, path)) == 0 {
+		fail("not", value)
+	}
+
+	sortViolations(violations)
+	return violations
+}
+
+// countMatches returns how many of schemas value satisfies.
+func (v *jsonSchemaValidator) countMatches(schemas []*openapi.Schema, value any, path string) int {
+	matches := 0
+	for _, sub := range schemas {
+		if len(v.check(sub, value, path)) == 0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+// matchesJSONType reports whether value satisfies any of the declared types.
+func matchesJSONType(t *openapi.SchemaType, value any) bool {
+	actual := jsonTypeOf(value)
+	for _, want := range t.Strings() {
+		if want == actual || (want == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonTypeOf returns the JSON Schema type name of a decoded value.
+func jsonTypeOf(value any) string {
+	switch val := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := val.Int64(); err == nil {
+			return "integer"
+		}
+		if f, err := val.Float64(); err == nil && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return reflect.TypeOf(value).String()
+	}
+}
+
+// jsonEqual compares a schema literal (decoded without UseNumber) with a document value.
+func jsonEqual(a, b any) bool {
+	left, errA := json.Marshal(a)
+	right, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	var x, y any
+	if json.Unmarshal(left, &x) != nil || json.Unmarshal(right, &y) != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+// containsJSONValue reports whether value equals any enum entry.
+func containsJSONValue(values []any, value any) bool {
+	for _, candidate := range values {
+		if jsonEqual(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueJSONValues reports whether all array items are distinct.
+func uniqueJSONValues(items []any) bool {
+	for i := range items {
+		for j := i + 1; j < len(items); j++ {
+			if jsonEqual(items[i], items[j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// joinJSONPath appends a property name to a dotted path.
+func joinJSONPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// sortViolations orders violations by field then keyword for deterministic responses.
+func sortViolations(violations []ValidationFieldError) {
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].Field != violations[j].Field {
+			return violations[i].Field < violations[j].Field
+		}
+		return violations[i].Tag < violations[j].Tag
+	})
+}
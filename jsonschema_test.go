@@ -0,0 +1,198 @@
+package rocco
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testJSONSchema = `{
+	"type": "object",
+	"required": ["name", "count"],
+	"additionalProperties": false,
+	"properties": {
+		"name": {"type": "string", "minLength": 2, "pattern": "^[A-Z]"},
+		"count": {"type": "integer", "minimum": 1, "maximum": 100},
+		"tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true, "maxItems": 3},
+		"mode": {"enum": ["fast", "slow"]}
+	}
+}`
+
+func TestCompileJSONSchema(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		if _, err := compileJSONSchema([]byte(testJSONSchema)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		if _, err := compileJSONSchema([]byte(`{"type":`)); err == nil {
+			t.Error("expected error for malformed schema")
+		}
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		_, err := compileJSONSchema([]byte(`{"properties":{"a":{"type":"string","pattern":"("}}}`))
+		if err == nil || !strings.Contains(err.Error(), "pattern") {
+			t.Errorf("expected pattern error, got %v", err)
+		}
+	})
+
+	t.Run("ref unsupported", func(t *testing.T) {
+		if _, err := compileJSONSchema([]byte(`{"items":{"$ref":"#/definitions/x"}}`)); err == nil {
+			t.Error("expected error for $ref")
+		}
+	})
+}
+
+func TestJSONSchemaValidator_Validate(t *testing.T) {
+	v, err := compileJSONSchema([]byte(testJSONSchema))
+	if err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want []string // field:tag pairs
+	}{
+		{"valid", `{"name":"Alice","count":3,"tags":["a","b"],"mode":"fast"}`, nil},
+		{"integer written as float", `{"name":"Alice","count":3.0}`, nil},
+		{"missing required", `{"name":"Alice"}`, []string{"count:required"}},
+		{"wrong type", `{"name":"Alice","count":"3"}`, []string{"count:type"}},
+		{"fractional integer", `{"name":"Alice","count":1.5}`, []string{"count:type"}},
+		{"string constraints", `{"name":"a","count":3}`, []string{"name:minLength", "name:pattern"}},
+		{"range", `{"name":"Alice","count":101}`, []string{"count:maximum"}},
+		{"additional property", `{"name":"Alice","count":3,"extra":true}`, []string{"extra:additionalProperties"}},
+		{"array items", `{"name":"Alice","count":3,"tags":["a",1]}`, []string{"tags[1]:type"}},
+		{"unique items", `{"name":"Alice","count":3,"tags":["a","a"]}`, []string{"tags:uniqueItems"}},
+		{"enum", `{"name":"Alice","count":3,"mode":"medium"}`, []string{"mode:enum"}},
+		{"root type", `[]`, []string{":type"}},
+		{"malformed json deferred to decoder", `{"name":`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, violation := range v.validate([]byte(tt.body)) {
+				got = append(got, violation.Field+":"+violation.Tag)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestJSONSchemaValidator_Combinators(t *testing.T) {
+	v, err := compileJSONSchema([]byte(`{
+		"oneOf": [{"type": "string"}, {"type": "integer"}],
+		"not": {"const": 0}
+	}`))
+	if err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+
+	if violations := v.validate([]byte(`"x"`)); len(violations) != 0 {
+		t.Errorf("expected string to pass, got %v", violations)
+	}
+	if violations := v.validate([]byte(`true`)); len(violations) != 1 || violations[0].Tag != "oneOf" {
+		t.Errorf("expected oneOf violation, got %v", violations)
+	}
+	if violations := v.validate([]byte(`0`)); len(violations) != 1 || violations[0].Tag != "not" {
+		t.Errorf("expected not violation, got %v", violations)
+	}
+}
+
+func TestHandler_WithJSONSchema(t *testing.T) {
+	called := false
+	handler := NewHandler[testInput, testOutput](
+		"test",
+		"POST",
+		"/test",
+		func(_ *Request[testInput]) (testOutput, error) {
+			called = true
+			return testOutput{Message: "ok"}, nil
+		},
+	).WithJSONSchema([]byte(testJSONSchema))
+
+	t.Run("rejects before decoding", func(t *testing.T) {
+		called = false
+		req := httptest.NewRequest("POST", "/test", bytes.NewReader([]byte(`{"name":"Alice","count":"3"}`)))
+		w := httptest.NewRecorder()
+
+		status, err := handler.Process(context.Background(), req, w)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if status != 422 || w.Code != 422 {
+			t.Errorf("expected 422, got %d/%d", status, w.Code)
+		}
+		if called {
+			t.Error("handler should not be called")
+		}
+
+		var resp struct {
+			Code    string            `json:"code"`
+			Details ValidationDetails `json:"details"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp.Code != "VALIDATION_FAILED" {
+			t.Errorf("expected VALIDATION_FAILED, got %q", resp.Code)
+		}
+		if len(resp.Details.Fields) != 1 || resp.Details.Fields[0].Field != "count" || resp.Details.Fields[0].Tag != "type" {
+			t.Errorf("unexpected details: %+v", resp.Details.Fields)
+		}
+	})
+
+	t.Run("accepts valid body", func(t *testing.T) {
+		called = false
+		req := httptest.NewRequest("POST", "/test", bytes.NewReader([]byte(`{"name":"Alice","count":3}`)))
+		w := httptest.NewRecorder()
+
+		if _, err := handler.Process(context.Background(), req, w); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !called {
+			t.Error("handler should be called")
+		}
+	})
+
+	t.Run("documents schema inline", func(t *testing.T) {
+		if handler.Spec().RequestSchema == nil {
+			t.Fatal("expected RequestSchema on spec")
+		}
+		if len(handler.Spec().RequestSchema.Required) != 2 {
+			t.Errorf("expected 2 required fields, got %v", handler.Spec().RequestSchema.Required)
+		}
+	})
+}
+
+func TestHandler_WithJSONSchema_Invalid(t *testing.T) {
+	handler := NewHandler[testInput, testOutput](
+		"test",
+		"POST",
+		"/test",
+		func(_ *Request[testInput]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	).WithJSONSchema([]byte(`not json`))
+
+	if len(handler.ScanErrors()) != 1 {
+		t.Fatalf("expected 1 scan error, got %v", handler.ScanErrors())
+	}
+	if handler.Spec().RequestSchema != nil {
+		t.Error("invalid schema should not be documented")
+	}
+
+	req := httptest.NewRequest("POST", "/test", bytes.NewReader([]byte(`{"name":"x"}`)))
+	w := httptest.NewRecorder()
+	if _, err := handler.Process(context.Background(), req, w); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
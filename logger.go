@@ -0,0 +1,67 @@
+package rocco
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+// RequestIDHeader is the header consulted for request correlation IDs.
+const RequestIDHeader = "X-Request-ID"
+
+// Logger is a minimal structured logger.
+// *slog.Logger satisfies this interface.
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// loggerContextKey is the context key for storing the request-scoped Logger.
+const loggerContextKey contextKey = "rocco_logger"
+
+// noopLogger discards all log output.
+type noopLogger struct{}
+
+func (noopLogger) Debug(string, ...any) {}
+func (noopLogger) Info(string, ...any)  {}
+func (noopLogger) Warn(string, ...any)  {}
+func (noopLogger) Error(string, ...any) {}
+
+// LoggerFromContext returns the request-scoped Logger stored in ctx.
+// If no logger factory is configured, a no-op Logger is returned so callers never need a nil check.
+func LoggerFromContext(ctx context.Context) Logger {
+	if logger, ok := ctx.Value(loggerContextKey).(Logger); ok {
+		return logger
+	}
+	return noopLogger{}
+}
+
+// SlogLoggerFactory returns a logger factory that derives request-scoped loggers from base.
+// Each logger is tagged with the request method, path, and request ID (if present).
+func SlogLoggerFactory(base *slog.Logger) func(context.Context, *http.Request) Logger {
+	return func(_ context.Context, r *http.Request) Logger {
+		logger := base.With(
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+		)
+		if requestID := r.Header.Get(RequestIDHeader); requestID != "" {
+			logger = logger.With(slog.String("request_id", requestID))
+		}
+		return logger
+	}
+}
+
+// injectLogger stores a request-scoped Logger in the request context if a factory is configured.
+func (e *Engine) injectLogger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if e.loggerFactory == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := r.Context()
+		ctx = context.WithValue(ctx, loggerContextKey, e.loggerFactory(ctx, r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,54 @@
+package rocco
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoggerFromContext_Default(t *testing.T) {
+	logger := LoggerFromContext(context.Background())
+	if logger == nil {
+		t.Fatal("expected non-nil logger")
+	}
+	// Should not panic
+	logger.Info("test")
+}
+
+func TestEngine_WithLoggerFactory(t *testing.T) {
+	var buf bytes.Buffer
+	base := slog.New(slog.NewTextHandler(&buf, nil))
+
+	engine := newTestEngine().WithLoggerFactory(SlogLoggerFactory(base))
+
+	handler := NewHandler[NoBody, testOutput](
+		"logged",
+		"GET",
+		"/logged",
+		func(req *Request[NoBody]) (testOutput, error) {
+			LoggerFromContext(req.Context).Info("handling")
+			return testOutput{}, nil
+		},
+	)
+	engine.WithHandlers(handler)
+
+	req := httptest.NewRequest("GET", "/logged", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+	w := httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"msg=handling", "method=GET", "path=/logged", "request_id=req-123"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected log output to contain %q, got %q", want, out)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package rocco
+
+import "strings"
+
+// descriptionEscaper escapes the characters that let markdown renderers emit raw HTML.
+var descriptionEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// sanitizeDescription escapes raw HTML in a description so dynamic content renders as text.
+// Markdown formatting (emphasis, lists, code, links) is left intact.
+func sanitizeDescription(desc string) string {
+	return descriptionEscaper.Replace(desc)
+}
+
+// dedentMarkdown strips the indentation shared by all non-blank lines and drops
+// leading and trailing blank lines, so an indented raw string literal becomes
+// clean markdown. Trailing spaces are kept because markdown uses them as line breaks.
+func dedentMarkdown(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		width := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || width < indent {
+			indent = width
+		}
+	}
+
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			lines[i] = line[indent:]
+		} else if strings.TrimSpace(line) == "" {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,78 @@
+package rocco
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDedentMarkdown(t *testing.T) {
+	input := `
+		Creates a user.
+
+		**Required permissions:**
+		  - users:write
+		Line with break  
+		next line
+	`
+	want := "Creates a user.\n\n**Required permissions:**\n  - users:write\nLine with break  \nnext line"
+
+	if got := dedentMarkdown(input); got != want {
+		t.Errorf("unexpected result:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestDedentMarkdown_NoIndent(t *testing.T) {
+	if got := dedentMarkdown("one\ntwo"); got != "one\ntwo" {
+		t.Errorf("expected text unchanged, got %q", got)
+	}
+	if got := dedentMarkdown("\n\n"); got != "" {
+		t.Errorf("expected empty result, got %q", got)
+	}
+}
+
+func TestSanitizeDescription(t *testing.T) {
+	got := sanitizeDescription(`**Bold** <script>alert("x")</script> & [link](https://example.com)`)
+	want := `**Bold** &lt;script&gt;alert("x")&lt;/script&gt; &amp; [link](https://example.com)`
+	if got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestHandler_WithDescriptionMarkdown(t *testing.T) {
+	handler := NewHandler[NoBody, testOutput]("get-user", "GET", "/users", func(_ *Request[NoBody]) (testOutput, error) {
+		return testOutput{}, nil
+	}).WithDescriptionMarkdown(`
+		Returns the user.
+
+		- Cached for 60s
+	`)
+
+	if want := "Returns the user.\n\n- Cached for 60s"; handler.Spec().Description != want {
+		t.Errorf("expected %q, got %q", want, handler.Spec().Description)
+	}
+}
+
+func TestGenerateOpenAPI_SanitizedDescriptions(t *testing.T) {
+	newEngine := func() *Engine {
+		engine := newTestEngine()
+		engine.WithHandlers(
+			NewHandler[NoBody, testOutput]("get-user", "GET", "/users", func(_ *Request[NoBody]) (testOutput, error) {
+				return testOutput{}, nil
+			}).WithSummary("User <b>lookup</b>").WithDescription("Hello <img src=x onerror=alert(1)>"),
+		)
+		return engine
+	}
+
+	op := newEngine().GenerateOpenAPI(nil).Paths["/users"].Get
+	if !strings.Contains(op.Description, "<img") {
+		t.Errorf("expected description verbatim by default, got %q", op.Description)
+	}
+
+	op = newEngine().WithSanitizedDescriptions().GenerateOpenAPI(nil).Paths["/users"].Get
+	if strings.Contains(op.Description, "<") || strings.Contains(op.Summary, "<") {
+		t.Errorf("expected HTML to be escaped, got %q / %q", op.Summary, op.Description)
+	}
+	if op.Description != "Hello &lt;img src=x onerror=alert(1)&gt;" {
+		t.Errorf("unexpected description %q", op.Description)
+	}
+}
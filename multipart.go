@@ -0,0 +1,188 @@
+package rocco
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/zoobzio/capitan"
+	"github.com/zoobzio/sentinel"
+)
+
+// MultipartFormMediaType is the media type for multipart form submissions, including file uploads.
+const MultipartFormMediaType = "multipart/form-data"
+
+// multipartMaxMemory is the portion of a multipart body held in memory; larger
+// files spill to temporary files removed after the handler returns.
+// It is a variable so tests can force uploads to disk.
+var multipartMaxMemory int64 = 32 << 20
+
+// FileUpload is a file received in a multipart/form-data request.
+// Declare input fields as *FileUpload (or []*FileUpload for multiple files) to
+// receive uploads; they are bound by the field's JSON name and documented as
+// binary form fields.
+type FileUpload struct {
+	Filename    string // Client-supplied file name (untrusted)
+	Size        int64  // Size in bytes
+	ContentType string // Client-supplied Content-Type of the part
+
+	header *multipart.FileHeader
+}
+
+// Open returns a reader for the file's contents. The caller must close it.
+// Uploads are only readable until the handler returns.
+func (f *FileUpload) Open() (multipart.File, error) {
+	return f.header.Open()
+}
+
+// newFileUpload wraps a parsed multipart file header.
+func newFileUpload(header *multipart.FileHeader) *FileUpload {
+	return &FileUpload{
+		Filename:    header.Filename,
+		Size:        header.Size,
+		ContentType: header.Header.Get("Content-Type"),
+		header:      header,
+	}
+}
+
+var (
+	fileUploadType     = reflect.TypeFor[*FileUpload]()
+	fileUploadListType = reflect.TypeFor[[]*FileUpload]()
+	fileUploadFQDN     = typeFQDN[FileUpload]()
+)
+
+// isMultipartForm reports whether contentType is multipart/form-data.
+func isMultipartForm(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && strings.EqualFold(mediaType, MultipartFormMediaType)
+}
+
+// isFileUploadType reports whether a sentinel field type string is a FileUpload field.
+func isFileUploadType(goType string) bool {
+	goType = strings.TrimPrefix(strings.TrimPrefix(goType, "[]"), "*")
+	return goType == "rocco.FileUpload"
+}
+
+// hasFileUploadFields reports whether meta declares any FileUpload fields.
+func hasFileUploadFields(meta sentinel.Metadata) bool {
+	for _, field := range meta.Fields {
+		if isFileUploadType(field.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// bindMultipartForm copies form files and values into target, a struct value, matching
+// fields by JSON name. FileUpload fields receive files; scalar, pointer, and slice fields
+// are parsed like query parameters; other fields are decoded from a JSON form value.
+// It returns a violation per value that cannot be converted, and the submitted field names.
+func bindMultipartForm(form *multipart.Form, target reflect.Value, meta sentinel.Metadata) ([]ValidationFieldError, map[string]json.RawMessage) {
+	provided := make(map[string]json.RawMessage, len(form.Value)+len(form.File))
+	for name := range form.Value {
+		provided[name] = nil
+	}
+	for name := range form.File {
+		provided[name] = nil
+	}
+
+	if target.Kind() != reflect.Struct {
+		return nil, provided
+	}
+
+	var violations []ValidationFieldError
+	for _, field := range meta.Fields {
+		name, _ := parseJSONTag(field)
+		if name == "-" {
+			continue
+		}
+		dst := target.FieldByIndex(field.Index)
+
+		switch dst.Type() {
+		case fileUploadType:
+			if files := form.File[name]; len(files) > 0 {
+				dst.Set(reflect.ValueOf(newFileUpload(files[0])))
+			}
+			continue
+		case fileUploadListType:
+			uploads := make([]*FileUpload, 0, len(form.File[name]))
+			for _, header := range form.File[name] {
+				uploads = append(uploads, newFileUpload(header))
+			}
+			if len(uploads) > 0 {
+				dst.Set(reflect.ValueOf(uploads))
+			}
+			continue
+		}
+
+		values := form.Value[name]
+		if len(values) == 0 {
+			continue
+		}
+		var err error
+		if isQueryBindable(dst.Type()) {
+			err = setQueryValue(dst, values)
+		} else {
+			err = json.Unmarshal([]byte(values[0]), dst.Addr().Interface())
+		}
+		if err != nil {
+			violations = append(violations, ValidationFieldError{
+				Field: name,
+				Tag:   "type",
+				Value: values[0],
+			})
+		}
+	}
+	return violations, provided
+}
+
+// readMultipartInput parses a multipart/form-data body into an In value and validates it.
+// On failure the error response has already been written and a non-zero status is returned.
+func (h *Handler[In, Out]) readMultipartInput(ctx context.Context, r *http.Request, w http.ResponseWriter) (In, map[string]json.RawMessage, int, error) {
+	var input In
+	if err := r.ParseMultipartForm(multipartMaxMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			capitan.Warn(ctx, RequestBodyReadError,
+				HandlerNameKey.Field(h.spec.Name),
+				ErrorKey.Field("payload too large"),
+			)
+			writeError(ctx, w, ErrPayloadTooLarge.WithDetails(PayloadTooLargeDetails{
+				MaxSize: h.maxBodySize,
+			}), h.spec.Name)
+			return input, nil, http.StatusRequestEntityTooLarge, err
+		}
+		capitan.Error(ctx, RequestBodyParseError,
+			HandlerNameKey.Field(h.spec.Name),
+			ErrorKey.Field(err.Error()),
+		)
+		writeError(ctx, w, ErrUnprocessableEntity.WithMessage("invalid multipart form").WithCause(err), h.spec.Name)
+		return input, nil, http.StatusUnprocessableEntity, err
+	}
+
+	violations, provided := bindMultipartForm(r.MultipartForm, reflect.ValueOf(&input).Elem(), h.InputMeta)
+	if len(violations) > 0 {
+		capitan.Warn(ctx, RequestValidationInputFailed,
+			HandlerNameKey.Field(h.spec.Name),
+			ErrorKey.Field("form fields could not be parsed"),
+		)
+		writeError(ctx, w, ErrValidationFailed.WithDetails(ValidationDetails{
+			Fields: violations,
+		}), h.spec.Name)
+		return input, nil, ErrValidationFailed.Status(), ErrValidationFailed
+	}
+
+	if inputErr := h.validator.Struct(input); inputErr != nil {
+		capitan.Warn(ctx, RequestValidationInputFailed,
+			HandlerNameKey.Field(h.spec.Name),
+			ErrorKey.Field(inputErr.Error()),
+		)
+		return input, nil, writeValidationErrorResponse(ctx, w, inputErr, h.spec.Name), inputErr
+	}
+	return input, provided, 0, nil
+}
@@ -0,0 +1,265 @@
+package rocco
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type testUploadInput struct {
+	Title       string        `json:"title" validate:"required"`
+	Count       int           `json:"count"`
+	Avatar      *FileUpload   `json:"avatar" validate:"required"`
+	Attachments []*FileUpload `json:"attachments"`
+}
+
+// newMultipartRequest builds a multipart/form-data POST from form values and named file contents.
+func newMultipartRequest(t *testing.T, values map[string]string, files map[string][]string) *http.Request {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for name, value := range values {
+		if err := mw.WriteField(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, contents := range files {
+		for _, content := range contents {
+			part, err := mw.CreateFormFile(name, name+".txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := part.Write([]byte(content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("POST", "/upload", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func newUploadHandler(fn func(*Request[testUploadInput]) error) *Handler[testUploadInput, testOutput] {
+	return NewHandler[testUploadInput, testOutput](
+		"upload",
+		"POST",
+		"/upload",
+		func(req *Request[testUploadInput]) (testOutput, error) {
+			return testOutput{Message: "ok"}, fn(req)
+		},
+	)
+}
+
+func TestHandler_MultipartUpload(t *testing.T) {
+	var title, avatar string
+	var count, attachments int
+	var filename string
+	handler := newUploadHandler(func(req *Request[testUploadInput]) error {
+		title, count, attachments = req.Body.Title, req.Body.Count, len(req.Body.Attachments)
+		filename = req.Body.Avatar.Filename
+		f, err := req.Body.Avatar.Open()
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		data, err := io.ReadAll(f)
+		avatar = string(data)
+		return err
+	})
+
+	req := newMultipartRequest(t,
+		map[string]string{"title": "report", "count": "3"},
+		map[string][]string{"avatar": {"image-bytes"}, "attachments": {"a", "b"}},
+	)
+	w := httptest.NewRecorder()
+	if _, err := handler.Process(context.Background(), req, w); err != nil {
+		t.Fatalf("unexpected error: %v (%s)", err, w.Body.String())
+	}
+
+	if title != "report" || count != 3 {
+		t.Errorf("unexpected form values: title=%q count=%d", title, count)
+	}
+	if avatar != "image-bytes" || filename != "avatar.txt" {
+		t.Errorf("unexpected avatar: %q (%q)", avatar, filename)
+	}
+	if attachments != 2 {
+		t.Errorf("expected 2 attachments, got %d", attachments)
+	}
+}
+
+func TestHandler_MultipartUpload_Validation(t *testing.T) {
+	handler := newUploadHandler(func(*Request[testUploadInput]) error { return nil })
+
+	w := httptest.NewRecorder()
+	status, _ := handler.Process(context.Background(), newMultipartRequest(t, map[string]string{"title": "report"}, nil), w)
+	if status != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for missing file, got %d", status)
+	}
+
+	w = httptest.NewRecorder()
+	req := newMultipartRequest(t,
+		map[string]string{"title": "report", "count": "many"},
+		map[string][]string{"avatar": {"x"}},
+	)
+	status, _ = handler.Process(context.Background(), req, w)
+	if status != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for unparseable field, got %d", status)
+	}
+	var resp struct {
+		Details ValidationDetails `json:"details"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Details.Fields) != 1 || resp.Details.Fields[0].Field != "count" || resp.Details.Fields[0].Tag != "type" {
+		t.Errorf("unexpected violations: %+v", resp.Details.Fields)
+	}
+}
+
+func TestHandler_MultipartUpload_TooLarge(t *testing.T) {
+	handler := newUploadHandler(func(*Request[testUploadInput]) error { return nil }).WithMaxBodySize(64)
+
+	req := newMultipartRequest(t,
+		map[string]string{"title": "report"},
+		map[string][]string{"avatar": {string(bytes.Repeat([]byte("x"), 1024))}},
+	)
+	w := httptest.NewRecorder()
+	status, _ := handler.Process(context.Background(), req, w)
+	if status != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413, got %d", status)
+	}
+}
+
+func TestHandler_MultipartUpload_RejectsJSON(t *testing.T) {
+	handler := newUploadHandler(func(*Request[testUploadInput]) error { return nil })
+
+	req := httptest.NewRequest("POST", "/upload", bytes.NewBufferString(`{"title":"report"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	status, _ := handler.Process(context.Background(), req, w)
+	if status != http.StatusUnsupportedMediaType {
+		t.Errorf("expected 415, got %d", status)
+	}
+}
+
+func TestHandler_Multipart_RequiresOptIn(t *testing.T) {
+	var called bool
+	newHandler := func() *Handler[testInput, testOutput] {
+		return NewHandler[testInput, testOutput]("create", "POST", "/upload", func(req *Request[testInput]) (testOutput, error) {
+			called = true
+			return testOutput{Message: req.Body.Name}, nil
+		})
+	}
+
+	// A JSON input must not be reachable through a form, which skips JSON-only checks.
+	req := newMultipartRequest(t, map[string]string{"name": "widget"}, nil)
+	w := httptest.NewRecorder()
+	status, _ := newHandler().Process(context.Background(), req, w)
+	if status != http.StatusUnsupportedMediaType || called {
+		t.Errorf("expected 415 without running the handler, got %d", status)
+	}
+
+	req = newMultipartRequest(t, map[string]string{"name": "widget"}, nil)
+	w = httptest.NewRecorder()
+	status, _ = newHandler().WithRequestMediaType(MultipartFormMediaType).Process(context.Background(), req, w)
+	if status != http.StatusOK || !called {
+		t.Errorf("expected an opted-in handler to accept the form, got %d: %s", status, w.Body.String())
+	}
+}
+
+func TestGenerateOpenAPI_MultipartUpload(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(newUploadHandler(func(*Request[testUploadInput]) error { return nil }))
+
+	spec := engine.GenerateOpenAPI(nil)
+	content := spec.Paths["/upload"].Post.RequestBody.Content
+	if _, ok := content[MultipartFormMediaType]; !ok {
+		t.Fatalf("expected %s request body, got %v", MultipartFormMediaType, content)
+	}
+
+	schema := spec.Components.Schemas["testUploadInput"]
+	if schema == nil {
+		t.Fatal("expected testUploadInput component schema")
+	}
+	avatar := schema.Properties["avatar"]
+	if avatar == nil || avatar.Type.String() != "string" || avatar.Format != "binary" {
+		t.Errorf("expected avatar to be a binary string, got %+v", avatar)
+	}
+	attachments := schema.Properties["attachments"]
+	if attachments == nil || attachments.Items == nil || attachments.Items.Format != "binary" {
+		t.Errorf("expected attachments to be an array of binary strings, got %+v", attachments)
+	}
+	if _, ok := spec.Components.Schemas["FileUpload"]; ok {
+		t.Error("FileUpload should not be emitted as a component schema")
+	}
+}
+
+func TestHandler_MultipartUpload_TimeoutKeepsFiles(t *testing.T) {
+	defer func(size int64) { multipartMaxMemory = size }(multipartMaxMemory)
+	multipartMaxMemory = 1 // Spill every file to disk.
+
+	var header *multipart.FileHeader
+	release := make(chan struct{})
+	done := make(chan string, 1)
+	handler := newUploadHandler(func(req *Request[testUploadInput]) error {
+		header = req.Body.Avatar.header
+		<-release
+		f, err := req.Body.Avatar.Open()
+		if err != nil {
+			done <- "open: " + err.Error()
+			return err
+		}
+		defer f.Close()
+		data, err := io.ReadAll(f)
+		if err != nil {
+			done <- "read: " + err.Error()
+			return err
+		}
+		done <- string(data)
+		return nil
+	}).WithTimeout(10 * time.Millisecond)
+
+	req := newMultipartRequest(t,
+		map[string]string{"title": "report"},
+		map[string][]string{"avatar": {"slow-upload"}},
+	)
+	w := httptest.NewRecorder()
+	status, _ := handler.Process(context.Background(), req, w)
+	if status != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d", status)
+	}
+
+	// The handler is still running, so its upload must still be on disk.
+	close(release)
+	select {
+	case got := <-done:
+		if got != "slow-upload" {
+			t.Fatalf("abandoned handler could not read its upload: %s", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler did not finish")
+	}
+
+	// Once it returns, the temporary file is removed.
+	deadline := time.Now().Add(time.Second)
+	for {
+		f, err := header.Open()
+		if err != nil {
+			break
+		}
+		f.Close()
+		if time.Now().After(deadline) {
+			t.Fatal("expected the temporary upload to be removed after the handler returned")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,129 @@
+package rocco
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// MergePatchMediaType is the media type for JSON merge patch documents (RFC 7386).
+const MergePatchMediaType = "application/merge-patch+json"
+
+// Patch holds a decoded JSON merge patch (RFC 7386) for a resource of type T.
+// Value is a typed view of the patch; Has and IsNull report which fields were
+// actually present so handlers can distinguish "set to null" from "absent".
+type Patch[T any] struct {
+	Value T `validate:"-"` // Typed view of the patch (absent fields are zero-valued)
+
+	raw    json.RawMessage
+	fields map[string]json.RawMessage
+}
+
+// UnmarshalJSON decodes the patch, recording which top-level fields were present.
+func (p *Patch[T]) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("merge patch must be a JSON object: %w", err)
+	}
+
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	p.Value = value
+	p.raw = append(json.RawMessage(nil), data...)
+	p.fields = fields
+	return nil
+}
+
+// Has reports whether the field (by JSON name) was present in the patch, including explicit nulls.
+func (p Patch[T]) Has(field string) bool {
+	_, ok := p.fields[field]
+	return ok
+}
+
+// IsNull reports whether the field was present in the patch with an explicit null value.
+func (p Patch[T]) IsNull(field string) bool {
+	raw, ok := p.fields[field]
+	return ok && string(raw) == "null"
+}
+
+// Fields returns the JSON names of all fields present in the patch, sorted.
+func (p Patch[T]) Fields() []string {
+	fields := make([]string, 0, len(p.fields))
+	for field := range p.fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Apply merges the patch onto target following RFC 7386 and returns the result.
+// Null values remove fields (resetting them to their zero value) and nested objects merge recursively.
+func (p Patch[T]) Apply(target T) (T, error) {
+	var result T
+
+	if len(p.raw) == 0 {
+		return target, nil
+	}
+
+	targetJSON, err := json.Marshal(target)
+	if err != nil {
+		return result, fmt.Errorf("failed to marshal patch target: %w", err)
+	}
+
+	var targetDoc, patchDoc any
+	if err := json.Unmarshal(targetJSON, &targetDoc); err != nil {
+		return result, fmt.Errorf("failed to decode patch target: %w", err)
+	}
+	if err := json.Unmarshal(p.raw, &patchDoc); err != nil {
+		return result, fmt.Errorf("failed to decode patch: %w", err)
+	}
+
+	merged, err := json.Marshal(mergePatch(targetDoc, patchDoc))
+	if err != nil {
+		return result, fmt.Errorf("failed to marshal patched document: %w", err)
+	}
+	if err := json.Unmarshal(merged, &result); err != nil {
+		return result, fmt.Errorf("failed to decode patched document: %w", err)
+	}
+	return result, nil
+}
+
+// mergePatch implements the RFC 7386 MergePatch algorithm on decoded JSON values.
+func mergePatch(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = make(map[string]any)
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergePatch(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// NewPatchHandler creates a PATCH handler whose body is decoded as a JSON merge patch for In.
+// The handler typically fetches the existing resource and calls req.Body.Apply on it.
+// The request media type is application/merge-patch+json and OpenAPI documents In as the body schema.
+func NewPatchHandler[In, Out any](name, path string, fn func(*Request[Patch[In]]) (Out, error)) *Handler[Patch[In], Out] {
+	h := NewHandler[Patch[In], Out](name, http.MethodPatch, path, fn)
+	inputMeta, err := scanType[In]()
+	if err != nil {
+		h.scanErrors = append(h.scanErrors, err)
+	}
+	h.spec.InputTypeName = inputMeta.TypeName
+	h.spec.RequestMediaType = MergePatchMediaType
+	return h
+}
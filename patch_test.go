@@ -0,0 +1,123 @@
+package rocco
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type patchAddress struct {
+	City string `json:"city,omitempty"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type patchResource struct {
+	Name    string        `json:"name" validate:"required"`
+	Email   string        `json:"email,omitempty"`
+	Address *patchAddress `json:"address,omitempty"`
+}
+
+func TestPatch_PresenceTracking(t *testing.T) {
+	var p Patch[patchResource]
+	if err := json.Unmarshal([]byte(`{"name":"Bob","email":null}`), &p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p.Value.Name != "Bob" {
+		t.Errorf("expected name 'Bob', got %q", p.Value.Name)
+	}
+	if !p.Has("name") || !p.Has("email") {
+		t.Error("expected name and email to be present")
+	}
+	if p.Has("address") {
+		t.Error("expected address to be absent")
+	}
+	if !p.IsNull("email") {
+		t.Error("expected email to be explicitly null")
+	}
+	if p.IsNull("name") {
+		t.Error("expected name to not be null")
+	}
+	if got := p.Fields(); len(got) != 2 || got[0] != "email" || got[1] != "name" {
+		t.Errorf("expected sorted fields [email name], got %v", got)
+	}
+}
+
+func TestPatch_UnmarshalNonObject(t *testing.T) {
+	var p Patch[patchResource]
+	if err := json.Unmarshal([]byte(`[1,2]`), &p); err == nil {
+		t.Error("expected error for non-object patch")
+	}
+}
+
+func TestPatch_Apply(t *testing.T) {
+	existing := patchResource{
+		Name:    "Alice",
+		Email:   "alice@example.com",
+		Address: &patchAddress{City: "Paris", Zip: "75001"},
+	}
+
+	var p Patch[patchResource]
+	if err := json.Unmarshal([]byte(`{"email":null,"address":{"zip":"75002"}}`), &p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := p.Apply(existing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Name != "Alice" {
+		t.Errorf("expected absent name to be unchanged, got %q", result.Name)
+	}
+	if result.Email != "" {
+		t.Errorf("expected null email to be cleared, got %q", result.Email)
+	}
+	if result.Address == nil || result.Address.City != "Paris" || result.Address.Zip != "75002" {
+		t.Errorf("expected nested merge, got %+v", result.Address)
+	}
+}
+
+func TestNewPatchHandler(t *testing.T) {
+	existing := patchResource{Name: "Alice", Email: "alice@example.com"}
+
+	handler := NewPatchHandler[patchResource, patchResource](
+		"patch-user",
+		"/users/{id}",
+		func(req *Request[Patch[patchResource]]) (patchResource, error) {
+			return req.Body.Apply(existing)
+		},
+	)
+
+	spec := handler.Spec()
+	if spec.Method != http.MethodPatch {
+		t.Errorf("expected method PATCH, got %q", spec.Method)
+	}
+	if spec.InputTypeName != "patchResource" {
+		t.Errorf("expected input type 'patchResource', got %q", spec.InputTypeName)
+	}
+	if spec.RequestMediaType != MergePatchMediaType {
+		t.Errorf("expected merge patch media type, got %q", spec.RequestMediaType)
+	}
+
+	// Patch omits the required name field; validation must not reject it.
+	req := httptest.NewRequest("PATCH", "/users/1", strings.NewReader(`{"email":"new@example.com"}`))
+	req.Header.Set("Content-Type", MergePatchMediaType)
+	w := httptest.NewRecorder()
+
+	status, err := handler.Process(context.Background(), req, w)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", status, w.Body.String())
+	}
+
+	var result patchResource
+	json.Unmarshal(w.Body.Bytes(), &result)
+	if result.Name != "Alice" || result.Email != "new@example.com" {
+		t.Errorf("unexpected patched result: %+v", result)
+	}
+}
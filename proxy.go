@@ -0,0 +1,103 @@
+package rocco
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Proxy headers consulted when a request arrives from a trusted proxy.
+const (
+	ForwardedForHeader   = "X-Forwarded-For"
+	ForwardedProtoHeader = "X-Forwarded-Proto"
+)
+
+// parseTrustedProxy parses a CIDR or a bare IP address into a network.
+func parseTrustedProxy(value string) (*net.IPNet, error) {
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, &net.ParseError{Type: "IP address", Text: value}
+		}
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 8 * net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, network, err := net.ParseCIDR(value)
+	return network, err
+}
+
+// ClientIP returns the client IP address for r.
+// When the engine has trusted proxies configured, RemoteAddr has already been
+// rewritten from X-Forwarded-For, so this reflects the real client behind the proxy.
+func ClientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// isTrustedProxy reports whether ip falls within any trusted proxy network.
+func (e *Engine) isTrustedProxy(ip net.IP) bool {
+	for _, network := range e.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedClientIP walks X-Forwarded-For from right to left, skipping trusted proxies,
+// and returns the first untrusted address. If every hop is trusted, the leftmost is returned.
+func (e *Engine) forwardedClientIP(header string) string {
+	hops := strings.Split(header, ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			// Unparseable hop: stop rather than trust anything further left.
+			break
+		}
+		if !e.isTrustedProxy(ip) || i == 0 {
+			return ip.String()
+		}
+	}
+	return ""
+}
+
+// resolveProxyHeaders rewrites RemoteAddr and URL scheme from proxy headers when the
+// immediate peer is a trusted proxy. Requests from untrusted peers are left untouched,
+// so clients cannot spoof their address by sending the headers directly.
+func (e *Engine) resolveProxyHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(e.trustedProxies) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		peer := net.ParseIP(ClientIP(r))
+		if peer == nil || !e.isTrustedProxy(peer) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		forwarded := *r
+		url := *r.URL
+		forwarded.URL = &url
+
+		if header := r.Header.Get(ForwardedForHeader); header != "" {
+			if clientIP := e.forwardedClientIP(header); clientIP != "" {
+				forwarded.RemoteAddr = clientIP
+			}
+		}
+
+		switch proto := strings.ToLower(strings.TrimSpace(r.Header.Get(ForwardedProtoHeader))); proto {
+		case "http", "https":
+			forwarded.URL.Scheme = proto
+		}
+
+		next.ServeHTTP(w, &forwarded)
+	})
+}
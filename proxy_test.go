@@ -0,0 +1,125 @@
+package rocco
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zoobzio/capitan"
+)
+
+func TestParseTrustedProxy(t *testing.T) {
+	tests := []struct {
+		input    string
+		contains string
+		excludes string
+	}{
+		{"10.0.0.0/8", "10.1.2.3", "11.0.0.1"},
+		{"192.168.1.5", "192.168.1.5", "192.168.1.6"},
+		{"fd00::/8", "fd00::1", "fe80::1"},
+		{"::1", "::1", "::2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			network, err := parseTrustedProxy(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			engine := &Engine{trustedProxies: []*net.IPNet{network}}
+			if !engine.isTrustedProxy(net.ParseIP(tt.contains)) {
+				t.Errorf("expected %s to be trusted", tt.contains)
+			}
+			if engine.isTrustedProxy(net.ParseIP(tt.excludes)) {
+				t.Errorf("expected %s to be untrusted", tt.excludes)
+			}
+		})
+	}
+
+	if _, err := parseTrustedProxy("not-an-ip"); err == nil {
+		t.Error("expected error for invalid address")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "203.0.113.7:54321"
+	if got := ClientIP(r); got != "203.0.113.7" {
+		t.Errorf("expected 203.0.113.7, got %q", got)
+	}
+
+	r.RemoteAddr = "203.0.113.8"
+	if got := ClientIP(r); got != "203.0.113.8" {
+		t.Errorf("expected bare address to be returned, got %q", got)
+	}
+}
+
+func TestEngine_TrustedProxies(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		proto      string
+		wantIP     string
+		wantScheme string
+	}{
+		{"trusted proxy", "10.0.0.1:1234", "203.0.113.7", "https", "203.0.113.7", "https"},
+		{"chain of trusted proxies", "10.0.0.1:1234", "203.0.113.7, 10.0.0.2", "", "203.0.113.7", ""},
+		{"spoofed leftmost entry", "10.0.0.1:1234", "1.1.1.1, 203.0.113.7", "", "203.0.113.7", ""},
+		{"untrusted peer", "198.51.100.1:1234", "203.0.113.7", "https", "198.51.100.1", ""},
+		{"invalid proto ignored", "10.0.0.1:1234", "", "gopher", "10.0.0.1", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotIP, gotScheme string
+			engine := newTestEngine().WithTrustedProxies("10.0.0.0/8")
+			handler := NewHandler[NoBody, testOutput](
+				"proxy",
+				"GET",
+				"/proxy",
+				func(req *Request[NoBody]) (testOutput, error) {
+					gotIP = ClientIP(req.Request)
+					gotScheme = req.URL.Scheme
+					return testOutput{}, nil
+				},
+			)
+			engine.WithHandlers(handler)
+
+			req := httptest.NewRequest("GET", "/proxy", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set(ForwardedForHeader, tt.forwarded)
+			}
+			if tt.proto != "" {
+				req.Header.Set(ForwardedProtoHeader, tt.proto)
+			}
+			engine.Router().ServeHTTP(httptest.NewRecorder(), req)
+
+			if gotIP != tt.wantIP {
+				t.Errorf("expected client IP %q, got %q", tt.wantIP, gotIP)
+			}
+			if gotScheme != tt.wantScheme {
+				t.Errorf("expected scheme %q, got %q", tt.wantScheme, gotScheme)
+			}
+		})
+	}
+}
+
+func TestEngine_WithTrustedProxies_Invalid(t *testing.T) {
+	var address string
+	listener := capitan.Hook(TrustedProxyInvalid, func(_ context.Context, e *capitan.Event) {
+		address, _ = AddressKey.From(e)
+	})
+	defer listener.Close()
+
+	engine := newTestEngine().WithTrustedProxies("bogus", "10.0.0.0/8")
+
+	if address != "bogus" {
+		t.Errorf("expected invalid entry to be reported, got %q", address)
+	}
+	if len(engine.trustedProxies) != 1 {
+		t.Errorf("expected 1 valid proxy, got %d", len(engine.trustedProxies))
+	}
+}
@@ -0,0 +1,235 @@
+package rocco
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/zoobzio/openapi"
+	"github.com/zoobzio/sentinel"
+)
+
+// queryField describes a struct field bound from a query parameter.
+type queryField struct {
+	index  []int           // Reflect field index
+	name   string          // Query parameter name from the query tag
+	schema *openapi.Schema // Typed parameter schema for OpenAPI
+	field  sentinel.FieldMetadata
+}
+
+// queryBinding decodes query parameters into the struct type given to WithQueryStruct.
+type queryBinding struct {
+	typ       reflect.Type
+	fields    []queryField
+	validator *validator.Validate // Reports errors by query parameter name
+}
+
+// newQueryBinding builds a binding for the query-tagged fields of typ.
+// Fields must be scalars (string, bool, integer, float), pointers to scalars for
+// optional parameters, or slices of scalars for repeatable parameters.
+func newQueryBinding(meta sentinel.Metadata, typ reflect.Type) (*queryBinding, error) {
+	binding := &queryBinding{typ: typ, validator: validator.New()}
+	binding.validator.RegisterTagNameFunc(func(f reflect.StructField) string {
+		if name := f.Tag.Get("query"); name != "" && name != "-" {
+			return name
+		}
+		return f.Name
+	})
+	for _, field := range meta.Fields {
+		name := field.Tags["query"]
+		if name == "" || name == "-" {
+			continue
+		}
+		fieldType := typ.FieldByIndex(field.Index).Type
+		if !isQueryBindable(fieldType) {
+			return nil, fmt.Errorf("query field %s: unsupported type %s", field.Name, fieldType)
+		}
+
+		schema := goTypeToSchema(field.Type)
+		applyOpenAPITags(schema, field)
+		binding.fields = append(binding.fields, queryField{
+			index:  field.Index,
+			name:   name,
+			schema: schema,
+			field:  field,
+		})
+	}
+	return binding, nil
+}
+
+// names returns the bound query parameter names in field order.
+func (b *queryBinding) names() []string {
+	names := make([]string, len(b.fields))
+	for i, f := range b.fields {
+		names[i] = f.name
+	}
+	return names
+}
+
+// schemas returns the typed OpenAPI schema of each bound parameter.
+func (b *queryBinding) schemas() map[string]*openapi.Schema {
+	schemas := make(map[string]*openapi.Schema, len(b.fields))
+	for _, f := range b.fields {
+		schemas[f.name] = f.schema
+	}
+	return schemas
+}
+
+// required returns the parameters marked required by their validate tag.
+// Pointer fields are optional by construction and never required.
+func (b *queryBinding) required() []string {
+	var required []string
+	for _, f := range b.fields {
+		if strings.HasPrefix(f.field.Type, "*") {
+			continue
+		}
+		if slices.Contains(strings.Split(f.field.Tags["validate"], ","), "required") {
+			required = append(required, f.name)
+		}
+	}
+	return required
+}
+
+// decode converts query values into a new struct value, reporting each parameter
+// that cannot be coerced to its field type. Absent parameters keep their zero value.
+func (b *queryBinding) decode(values url.Values) (reflect.Value, []ValidationFieldError) {
+	target := reflect.New(b.typ).Elem()
+	var violations []ValidationFieldError
+	for _, f := range b.fields {
+		raw := values[f.name]
+		if len(raw) == 0 {
+			continue
+		}
+		if err := setQueryValue(target.FieldByIndex(f.index), raw); err != nil {
+			violations = append(violations, ValidationFieldError{
+				Field: f.name,
+				Tag:   "type",
+				Value: strings.Join(raw, ","),
+			})
+		}
+	}
+	return target, violations
+}
+
+// isQueryBindable reports whether t can be decoded from query strings.
+func isQueryBindable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice:
+		return isQueryScalar(t.Elem())
+	default:
+		return isQueryScalar(t)
+	}
+}
+
+// isQueryScalar reports whether t is a scalar kind parsed from a single query value.
+func isQueryScalar(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// setQueryValue stores raw into dst: the first value for scalars and pointers,
+// every value for slices.
+func setQueryValue(dst reflect.Value, raw []string) error {
+	switch dst.Kind() {
+	case reflect.Pointer:
+		elem := reflect.New(dst.Type().Elem())
+		if err := setQueryScalar(elem.Elem(), raw[0]); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	case reflect.Slice:
+		slice := reflect.MakeSlice(dst.Type(), len(raw), len(raw))
+		for i, value := range raw {
+			if err := setQueryScalar(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+		dst.Set(slice)
+		return nil
+	default:
+		return setQueryScalar(dst, raw[0])
+	}
+}
+
+// setQueryScalar parses value into a scalar dst according to its kind.
+func setQueryScalar(dst reflect.Value, value string) error {
+	switch dst.Kind() {
+	case reflect.String:
+		dst.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported kind %s", dst.Kind())
+	}
+	return nil
+}
+
+// WithQueryStruct binds the handler's query parameters into Q on every request.
+// Fields of Q are bound by their query tag (e.g. `query:"page"`) and checked with
+// validate tags; coercion or validation failures return 422 before the handler runs.
+// Use pointer fields for optional parameters and slices for repeatable ones
+// (?tag=a&tag=b). The bound value is read with Query, and each field is documented
+// as a typed query parameter. Unsupported field types are reported by ScanErrors.
+//
+// Go methods cannot declare type parameters, so this is a function:
+//
+//	rocco.WithQueryStruct[ListParams](handler)
+func WithQueryStruct[Q, In, Out any](h *Handler[In, Out]) *Handler[In, Out] {
+	meta, err := scanType[Q]()
+	if err != nil {
+		h.scanErrors = append(h.scanErrors, err)
+		return h
+	}
+	binding, err := newQueryBinding(meta, reflect.TypeFor[Q]())
+	if err != nil {
+		h.scanErrors = append(h.scanErrors, err)
+		return h
+	}
+
+	h.queryBinding = binding
+	h.spec.QueryParams = appendUnique(h.spec.QueryParams, binding.names()...)
+	h.spec.QueryParamSchemas = binding.schemas()
+	h.spec.RequiredQueryParams = binding.required()
+	return h
+}
+
+// Query returns the query parameters bound by WithQueryStruct.
+// It returns the zero Q if the handler binds no query struct or binds a different type.
+func Query[Q, In any](req *Request[In]) Q {
+	q, _ := req.query.(Q)
+	return q
+}
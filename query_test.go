@@ -0,0 +1,179 @@
+package rocco
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testListQuery struct {
+	Page   int      `query:"page" validate:"required,min=1" description:"Page number"`
+	Limit  *int     `query:"limit" validate:"omitempty,max=100" default:"20"`
+	Active bool     `query:"active"`
+	Score  float64  `query:"score"`
+	Tags   []string `query:"tag"`
+	Sort   string   `query:"sort" validate:"omitempty,oneof=asc desc" default:"asc"`
+	Ignore string
+}
+
+func newQueryHandler(fn func(testListQuery)) *Handler[NoBody, testOutput] {
+	return WithQueryStruct[testListQuery](NewHandler[NoBody, testOutput](
+		"list",
+		"GET",
+		"/items",
+		func(req *Request[NoBody]) (testOutput, error) {
+			fn(Query[testListQuery](req))
+			return testOutput{Message: "ok"}, nil
+		},
+	))
+}
+
+func TestWithQueryStruct_Binding(t *testing.T) {
+	var got testListQuery
+	handler := newQueryHandler(func(q testListQuery) { got = q })
+
+	req := httptest.NewRequest("GET", "/items?page=2&limit=50&active=true&score=1.5&tag=a&tag=b&sort=desc&Ignore=x", nil)
+	w := httptest.NewRecorder()
+	if _, err := handler.Process(context.Background(), req, w); err != nil {
+		t.Fatalf("unexpected error: %v (%s)", err, w.Body.String())
+	}
+
+	if got.Page != 2 || got.Limit == nil || *got.Limit != 50 || !got.Active || got.Score != 1.5 || got.Sort != "desc" {
+		t.Errorf("unexpected binding: %+v", got)
+	}
+	if len(got.Tags) != 2 || got.Tags[0] != "a" || got.Tags[1] != "b" {
+		t.Errorf("expected repeated tag values, got %v", got.Tags)
+	}
+	if got.Ignore != "" {
+		t.Error("fields without a query tag should not be bound")
+	}
+}
+
+func TestWithQueryStruct_OptionalPointer(t *testing.T) {
+	var got testListQuery
+	handler := newQueryHandler(func(q testListQuery) { got = q })
+
+	w := httptest.NewRecorder()
+	if _, err := handler.Process(context.Background(), httptest.NewRequest("GET", "/items?page=1", nil), w); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Limit != nil {
+		t.Errorf("expected nil pointer for absent parameter, got %v", *got.Limit)
+	}
+}
+
+func TestWithQueryStruct_Failures(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    string
+		wantField string
+		wantTag   string
+	}{
+		{"coercion", "/items?page=abc", "page", "type"},
+		{"pointer coercion", "/items?page=1&limit=x", "limit", "type"},
+		{"missing required", "/items", "page", "required"},
+		{"validation", "/items?page=1&limit=500", "limit", "max"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			handler := newQueryHandler(func(testListQuery) { called = true })
+
+			w := httptest.NewRecorder()
+			status, err := handler.Process(context.Background(), httptest.NewRequest("GET", tt.target, nil), w)
+			if err == nil || status != http.StatusUnprocessableEntity {
+				t.Fatalf("expected 422 with error, got %d (%v)", status, err)
+			}
+			if called {
+				t.Error("handler should not run")
+			}
+
+			var resp struct {
+				Details ValidationDetails `json:"details"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(resp.Details.Fields) != 1 || resp.Details.Fields[0].Field != tt.wantField || resp.Details.Fields[0].Tag != tt.wantTag {
+				t.Errorf("expected %s/%s, got %+v", tt.wantField, tt.wantTag, resp.Details.Fields)
+			}
+		})
+	}
+}
+
+func TestWithQueryStruct_UnsupportedType(t *testing.T) {
+	type badQuery struct {
+		Filter map[string]string `query:"filter"`
+	}
+	handler := WithQueryStruct[badQuery](NewHandler[NoBody, testOutput]("bad", "GET", "/bad", func(_ *Request[NoBody]) (testOutput, error) {
+		return testOutput{}, nil
+	}))
+
+	if len(handler.ScanErrors()) != 1 || !strings.Contains(handler.ScanErrors()[0].Error(), "Filter") {
+		t.Errorf("expected unsupported type scan error, got %v", handler.ScanErrors())
+	}
+	if handler.queryBinding != nil {
+		t.Error("binding should not be installed")
+	}
+}
+
+func TestQuery_Unbound(t *testing.T) {
+	req := &Request[NoBody]{}
+	if q := Query[testListQuery](req); q.Page != 0 {
+		t.Errorf("expected zero value, got %+v", q)
+	}
+}
+
+func TestGenerateOpenAPI_QueryStruct(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(newQueryHandler(func(testListQuery) {}))
+
+	params := make(map[string]*struct {
+		typ      string
+		required bool
+		desc     string
+		def      any
+	})
+	for _, param := range engine.GenerateOpenAPI(nil).Paths["/items"].Get.Parameters {
+		if param.In != "query" {
+			continue
+		}
+		params[param.Name] = &struct {
+			typ      string
+			required bool
+			desc     string
+			def      any
+		}{param.Schema.Type.String(), param.Required, param.Description, param.Schema.Default}
+	}
+
+	want := map[string]string{"page": "integer", "limit": "integer", "active": "boolean", "score": "number", "tag": "array", "sort": "string"}
+	for name, typ := range want {
+		p, ok := params[name]
+		if !ok {
+			t.Errorf("missing query parameter %q", name)
+			continue
+		}
+		if p.typ != typ {
+			t.Errorf("%s: expected type %s, got %s", name, typ, p.typ)
+		}
+	}
+	if _, ok := params["Ignore"]; ok {
+		t.Error("untagged field should not be documented")
+	}
+	if p := params["page"]; p == nil || !p.required || p.desc != "Page number" {
+		t.Errorf("expected required, described page parameter, got %+v", p)
+	}
+	if p := params["limit"]; p == nil || p.required {
+		t.Errorf("expected optional limit parameter, got %+v", p)
+	}
+	if p := params["limit"]; p == nil || p.def != 20 {
+		t.Errorf("expected limit default 20, got %+v", p)
+	}
+	if p := params["sort"]; p == nil || p.def != "asc" {
+		t.Errorf("expected sort default asc, got %+v", p)
+	}
+}
@@ -0,0 +1,71 @@
+package rocco
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/zoobzio/capitan"
+)
+
+// PanicMode selects how the engine treats panics raised by handlers and their middleware.
+type PanicMode int
+
+const (
+	// PanicRecover converts panics into a HandlerPanicked event and an ErrInternalServer
+	// response. This is the default.
+	PanicRecover PanicMode = iota
+	// PanicPropagate re-raises panics to the caller of the engine's handler: an external
+	// recovery layer, or net/http, which logs the panic and drops the connection.
+	PanicPropagate
+)
+
+// handlerPanic carries a panic raised on a handler goroutine (see callWithAbort) to
+// the serving goroutine, preserving the stack of the original panic.
+type handlerPanic struct {
+	value any
+	stack []byte
+}
+
+// Error describes the original panic value.
+func (p *handlerPanic) Error() string {
+	return fmt.Sprintf("handler panic: %v", p.value)
+}
+
+// recoverPanics wraps next so a panic becomes a HandlerPanicked event and a 500 response (PanicRecover).
+// The panic value and stack are only reported through the event, never written to the client.
+// If the response was already committed it cannot be replaced, so the connection is aborted
+// instead, preventing a truncated body from looking complete.
+func recoverPanics(next http.Handler, handlerName string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			value, stack := rec, debug.Stack()
+			if hp, ok := rec.(*handlerPanic); ok {
+				value, stack = hp.value, hp.stack
+			}
+
+			ctx := r.Context()
+			capitan.Error(ctx, HandlerPanicked,
+				HandlerNameKey.Field(handlerName),
+				MethodKey.Field(r.Method),
+				PathKey.Field(r.URL.Path),
+				ErrorKey.Field(fmt.Sprint(value)),
+				StackKey.Field(string(stack)),
+			)
+
+			if _, committed := committedStatus(w); committed {
+				panic(http.ErrAbortHandler)
+			}
+			writeError(ctx, w, ErrInternalServer, handlerName)
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
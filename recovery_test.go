@@ -0,0 +1,137 @@
+package rocco
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zoobzio/capitan"
+)
+
+func newPanickingEngine(configure func(*Handler[NoBody, testOutput]) *Handler[NoBody, testOutput], fn func(*Request[NoBody]) (testOutput, error)) *Engine {
+	engine := newTestEngine().WithRecovery()
+	handler := NewHandler[NoBody, testOutput]("explode", "GET", "/explode", fn)
+	if configure != nil {
+		handler = configure(handler)
+	}
+	engine.WithHandlers(handler)
+	return engine
+}
+
+func TestEngine_WithRecovery(t *testing.T) {
+	setupSyncMode(t)
+
+	var handlerName, panicValue, stack string
+	listener := capitan.Hook(HandlerPanicked, func(_ context.Context, e *capitan.Event) {
+		handlerName, _ = HandlerNameKey.From(e)
+		panicValue, _ = ErrorKey.From(e)
+		stack, _ = StackKey.From(e)
+	})
+	defer listener.Close()
+
+	engine := newPanickingEngine(nil, func(*Request[NoBody]) (testOutput, error) {
+		panic("secret database password")
+	})
+
+	w := httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, httptest.NewRequest("GET", "/explode", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+	var resp errorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("expected JSON error body: %v", err)
+	}
+	if resp.Code != ErrInternalServer.Code() {
+		t.Errorf("expected %s, got %s", ErrInternalServer.Code(), resp.Code)
+	}
+	if strings.Contains(w.Body.String(), "secret") {
+		t.Error("panic value leaked into the response body")
+	}
+
+	if handlerName != "explode" || panicValue != "secret database password" {
+		t.Errorf("unexpected event fields: handler=%q value=%q", handlerName, panicValue)
+	}
+	if !strings.Contains(stack, "TestEngine_WithRecovery") {
+		t.Errorf("expected stack trace of the panicking handler, got %q", stack)
+	}
+}
+
+func TestEngine_WithRecovery_AbortOnDisconnect(t *testing.T) {
+	setupSyncMode(t)
+
+	var stack string
+	listener := capitan.Hook(HandlerPanicked, func(_ context.Context, e *capitan.Event) {
+		stack, _ = StackKey.From(e)
+	})
+	defer listener.Close()
+
+	engine := newPanickingEngine(
+		func(h *Handler[NoBody, testOutput]) *Handler[NoBody, testOutput] { return h.WithAbortOnDisconnect() },
+		func(*Request[NoBody]) (testOutput, error) { panic("from handler goroutine") },
+	)
+
+	w := httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, httptest.NewRequest("GET", "/explode", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", w.Code)
+	}
+	if !strings.Contains(stack, "TestEngine_WithRecovery_AbortOnDisconnect") {
+		t.Errorf("expected the handler goroutine's stack, got %q", stack)
+	}
+}
+
+func TestEngine_WithRecovery_ResponseCommitted(t *testing.T) {
+	engine := newTestEngine().WithRecovery()
+	engine.WithHandlers(NewHandler[NoBody, testOutput]("explode", "GET", "/explode", func(*Request[NoBody]) (testOutput, error) {
+		return testOutput{}, nil
+	}).WithMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			panic("after the response was written")
+		})
+	}))
+
+	w := httptest.NewRecorder()
+	defer func() {
+		if rec := recover(); rec != http.ErrAbortHandler {
+			t.Errorf("expected http.ErrAbortHandler, got %v", rec)
+		}
+		if w.Code != http.StatusOK {
+			t.Errorf("expected the committed status to be kept, got %d", w.Code)
+		}
+	}()
+	engine.mux.ServeHTTP(w, httptest.NewRequest("GET", "/explode", nil))
+}
+
+func TestEngine_RecoveryByDefault(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(NewHandler[NoBody, testOutput]("explode", "GET", "/explode", func(*Request[NoBody]) (testOutput, error) {
+		panic("boom")
+	}))
+
+	w := httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, httptest.NewRequest("GET", "/explode", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", w.Code)
+	}
+}
+
+func TestEngine_WithPanicHandling_Propagate(t *testing.T) {
+	engine := newTestEngine().WithPanicHandling(PanicPropagate)
+	engine.WithHandlers(NewHandler[NoBody, testOutput]("explode", "GET", "/explode", func(*Request[NoBody]) (testOutput, error) {
+		panic("boom")
+	}))
+
+	defer func() {
+		if rec := recover(); rec != "boom" {
+			t.Errorf("expected panic to propagate, got %v", rec)
+		}
+	}()
+	engine.mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/explode", nil))
+}
@@ -2,10 +2,19 @@ package rocco
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"regexp"
+	"slices"
 )
 
+// StatusClientClosedRequest is the non-standard status recorded when a client
+// disconnects before a response is written (nginx convention).
+const StatusClientClosedRequest = 499
+
 // noBodyTypeName is the sentinel type name for handlers without a request body.
 const noBodyTypeName = "NoBody"
 
@@ -16,48 +25,169 @@ const noBodyTypeName = "NoBody"
 // recommended as changes won't be reflected in OpenAPI documentation or
 // handler configuration. Use Handler builder methods (WithResponseHeaders,
 // WithSuccessStatus) for documented behavior.
+//
+// Requests are pooled per handler and cleared once the handler returns, so a
+// handler must not retain the *Request (e.g. in a goroutine that outlives it).
+// Copy Body, Params, or Identity out first if they are needed later.
 type Request[In any] struct {
 	context.Context // Embedded for deadline, cancellation, values
 	*http.Request   // Embedded for direct access when needed (use sparingly)
 	Params          *Params
 	Body            In
 	Identity        Identity // Authenticated identity (nil/NoIdentity for public endpoints)
+
+	provided map[string]json.RawMessage // Top-level body fields present in the request
+	query    any                        // Query struct bound by WithQueryStruct (read via Query)
+}
+
+// WasProvided reports whether the named top-level field (by JSON name) was present
+// in the request body, including explicit nulls. Use it to distinguish "clear the
+// field" from "leave unchanged" in update handlers.
+func (r *Request[In]) WasProvided(field string) bool {
+	_, ok := r.provided[field]
+	return ok
+}
+
+// readBody reads the request body. When the declared Content-Length fits within
+// limit the buffer is sized up front, avoiding the repeated growth of io.ReadAll.
+// Without a limit a client-supplied length is not trusted for allocation.
+func readBody(r *http.Request, limit int64) ([]byte, error) {
+	if r.ContentLength <= 0 || limit <= 0 || r.ContentLength > limit {
+		return io.ReadAll(r.Body)
+	}
+
+	// One spare byte lets the final read observe io.EOF without growing the buffer.
+	buf := make([]byte, 0, r.ContentLength+1)
+	for {
+		n, err := r.Body.Read(buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		if errors.Is(err, io.EOF) {
+			return buf, nil
+		}
+		if err != nil {
+			return buf, err
+		}
+		if len(buf) == cap(buf) {
+			// Body is longer than declared; fall back to append growth.
+			buf = append(buf, 0)[:len(buf)]
+		}
+	}
 }
 
 // Params holds extracted request parameters.
 type Params struct {
-	Path  map[string]string // Path parameters (e.g., /users/{id})
-	Query map[string]string // Query parameters (e.g., ?page=1)
+	Path       map[string]string   // Path parameters (e.g., /users/{id})
+	Query      map[string]string   // Query parameters (e.g., ?page=1); the first value if repeated
+	QueryMulti map[string][]string // Every value of WithQueryParamsMulti parameters (e.g., ?id=1&id=2)
+	Header     map[string]string   // Declared request headers, keyed by declared name (e.g., X-Request-ID)
+	Cookie     map[string]string   // Declared request cookies, keyed by name (e.g., session)
 }
 
 // NoBody represents an empty input for handlers that don't expect a request body.
 // Used for GET, HEAD, DELETE requests.
 type NoBody struct{}
 
-// extractParams extracts and validates required parameters from the request.
-func extractParams(_ context.Context, r *http.Request, pathParams, queryParams []string) (*Params, error) {
+// appendUnique appends names not already present in list.
+func appendUnique(list []string, names ...string) []string {
+	for _, name := range names {
+		if !slices.Contains(list, name) {
+			list = append(list, name)
+		}
+	}
+	return list
+}
+
+// ExtractParams extracts path and query parameters the way rocco handlers do, for custom
+// routes registered through Router or custom Endpoint implementations. Every declared path
+// parameter is required, except a trailing catch-all ("{path...}") in the ServeMux pattern
+// (r.Pattern), which may be empty. Declared query parameters are optional and hold their
+// first value. A missing path parameter returns ErrUnprocessableEntity with message
+// "invalid parameters", the error handlers answer with, and the missing parameter as its cause.
+func ExtractParams(ctx context.Context, r *http.Request, pathParams, queryParams []string) (*Params, error) {
+	params, err := extractParams(ctx, r, &HandlerSpec{
+		Path:        r.Pattern,
+		PathParams:  pathParams,
+		QueryParams: queryParams,
+	}, nil)
+	if err != nil {
+		return nil, ErrUnprocessableEntity.WithMessage("invalid parameters").WithCause(err)
+	}
+	return params, nil
+}
+
+// errPathParamMismatch marks a path parameter that fails its WithPathParamPattern pattern.
+// Handlers answer it with 404: the route does not exist for that value.
+var errPathParamMismatch = errors.New("does not match its pattern")
+
+// extractParams extracts and validates the parameters spec declares from the request,
+// enforcing pathPatterns compiled by WithPathParamPattern. Header and multi-value query
+// maps, like cookie maps, are only allocated for handlers that declare such parameters.
+func extractParams(_ context.Context, r *http.Request, spec *HandlerSpec, pathPatterns map[string]*regexp.Regexp) (*Params, error) {
 	params := &Params{
 		Path:  make(map[string]string),
 		Query: make(map[string]string),
 	}
 
-	// Extract path params using Go 1.22+ PathValue.
-	for _, param := range pathParams {
-		if val := r.PathValue(param); val != "" {
+	// Extract path params using Go 1.22+ PathValue. A catch-all wildcard
+	// ("{path...}") also matches an empty remainder, so only it may be empty.
+	catchAll := catchAllParam(spec.Path)
+	for _, param := range spec.PathParams {
+		if val := r.PathValue(param); val != "" || param == catchAll {
 			params.Path[param] = val
 		} else {
 			return nil, fmt.Errorf("path parameter %q", param)
 		}
 	}
 
-	// Extract only declared query params.
-	if len(queryParams) > 0 {
+	// Enforce path parameter patterns, which http.ServeMux cannot express.
+	for param, pattern := range pathPatterns {
+		if !pattern.MatchString(r.PathValue(param)) {
+			return nil, fmt.Errorf("path parameter %q: %w", param, errPathParamMismatch)
+		}
+	}
+
+	// Extract only declared query params; repeatable ones also keep every value.
+	if len(spec.QueryParams) > 0 || len(spec.MultiQueryParams) > 0 {
 		query := r.URL.Query()
-		for _, declaredParam := range queryParams {
+		for _, declaredParam := range spec.QueryParams {
 			if values := query[declaredParam]; len(values) > 0 {
 				params.Query[declaredParam] = values[0]
 			}
 		}
+		if len(spec.MultiQueryParams) > 0 {
+			params.QueryMulti = make(map[string][]string, len(spec.MultiQueryParams))
+			for _, declaredParam := range spec.MultiQueryParams {
+				if values := query[declaredParam]; len(values) > 0 {
+					params.Query[declaredParam] = values[0]
+					params.QueryMulti[declaredParam] = values
+				}
+			}
+		}
+	}
+
+	// Extract only declared headers; absent optional headers read as empty.
+	if len(spec.HeaderParams) > 0 {
+		params.Header = make(map[string]string, len(spec.HeaderParams))
+		for _, declaredHeader := range spec.HeaderParams {
+			if values := r.Header.Values(declaredHeader); len(values) > 0 {
+				params.Header[declaredHeader] = values[0]
+			}
+		}
+		for _, requiredHeader := range spec.RequiredHeaderParams {
+			if _, ok := params.Header[requiredHeader]; !ok {
+				return nil, fmt.Errorf("header parameter %q", requiredHeader)
+			}
+		}
+	}
+
+	// Extract only declared cookies; absent cookies read as empty.
+	if len(spec.CookieParams) > 0 {
+		params.Cookie = make(map[string]string, len(spec.CookieParams))
+		for _, declaredCookie := range spec.CookieParams {
+			if cookie, err := r.Cookie(declaredCookie); err == nil {
+				params.Cookie[declaredCookie] = cookie.Value
+			}
+		}
 	}
 
 	return params, nil
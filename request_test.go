@@ -2,7 +2,10 @@ package rocco
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"unsafe"
 )
@@ -101,6 +104,34 @@ func TestParams(t *testing.T) {
 	}
 }
 
+func TestExtractParams(t *testing.T) {
+	mux := http.NewServeMux()
+	var got *Params
+	var gotErr error
+	mux.HandleFunc("GET /raw/{id}", func(_ http.ResponseWriter, r *http.Request) {
+		got, gotErr = ExtractParams(r.Context(), r, []string{"id"}, []string{"page", "sort"})
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/raw/42?page=2&page=3", nil))
+
+	if gotErr != nil {
+		t.Fatalf("unexpected error: %v", gotErr)
+	}
+	if got.Path["id"] != "42" || got.Query["page"] != "2" {
+		t.Errorf("unexpected params: %+v", got)
+	}
+	if _, ok := got.Query["sort"]; ok {
+		t.Error("expected absent query param to be left out")
+	}
+
+	_, err := ExtractParams(context.Background(), httptest.NewRequest("GET", "/raw/", nil), []string{"id"}, nil)
+	if !errors.Is(err, ErrUnprocessableEntity) {
+		t.Fatalf("expected ErrUnprocessableEntity, got %v", err)
+	}
+	if !strings.Contains(errorChain(err), `path parameter "id"`) {
+		t.Errorf("expected the missing parameter as cause, got %q", errorChain(err))
+	}
+}
+
 func TestNoBody_IsZeroSized(t *testing.T) {
 	var nb NoBody
 
@@ -109,3 +140,76 @@ func TestNoBody_IsZeroSized(t *testing.T) {
 		t.Errorf("expected NoBody to be zero-sized, got size %d", size)
 	}
 }
+
+func TestRequest_WasProvided(t *testing.T) {
+	var name, count, missing bool
+	handler := NewHandler[testInput, testOutput](
+		"update",
+		"PUT",
+		"/items",
+		func(req *Request[testInput]) (testOutput, error) {
+			name = req.WasProvided("name")
+			count = req.WasProvided("count")
+			missing = req.WasProvided("missing")
+			return testOutput{}, nil
+		},
+	)
+
+	req := httptest.NewRequest("PUT", "/items", strings.NewReader(`{"name":null,"count":0}`))
+	w := httptest.NewRecorder()
+
+	if _, err := handler.Process(context.Background(), req, w); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !name {
+		t.Error("expected explicit null name to be reported as provided")
+	}
+	if !count {
+		t.Error("expected zero-valued count to be reported as provided")
+	}
+	if missing {
+		t.Error("expected absent field to not be reported as provided")
+	}
+}
+
+func TestRequest_WasProvided_NoBody(t *testing.T) {
+	req := &Request[NoBody]{}
+	if req.WasProvided("anything") {
+		t.Error("expected no fields to be provided without a body")
+	}
+}
+
+func TestReadBody(t *testing.T) {
+	payload := strings.Repeat("x", 1000)
+
+	tests := []struct {
+		name          string
+		contentLength int64
+		limit         int64
+		wantPresized  bool
+	}{
+		{"declared length", 1000, 2000, true},
+		{"unknown length", -1, 2000, false},
+		{"no limit", 1000, 0, false},
+		{"length over limit", 1000, 500, false},
+		{"understated length", 10, 2000, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", strings.NewReader(payload))
+			req.ContentLength = tt.contentLength
+
+			body, err := readBody(req, tt.limit)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(body) != payload {
+				t.Fatalf("expected %d bytes, got %d", len(payload), len(body))
+			}
+			if tt.wantPresized && tt.contentLength >= int64(len(payload)) && cap(body) != len(payload)+1 {
+				t.Errorf("expected buffer sized to Content-Length, got cap %d", cap(body))
+			}
+		})
+	}
+}
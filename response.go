@@ -0,0 +1,182 @@
+package rocco
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// errResponseCommitted is returned by Process when an earlier layer already wrote the response.
+var errResponseCommitted = errors.New("response already committed")
+
+// responseState wraps an http.ResponseWriter to record whether the response has been committed.
+// Every call still reaches the underlying writer, so duplicate WriteHeader calls are reported
+// by net/http as usual. It also carries request timing, since every layer down to Process
+// sees the writer.
+type responseState struct {
+	http.ResponseWriter
+	status    int
+	committed bool
+	start     time.Time     // When the request entered the middleware chain
+	fnTime    time.Duration // Time spent in the handler function, recorded by Process
+}
+
+// responseStatePool recycles states across requests; net/http forbids using a writer once
+// its handler returns, so trackResponses can reclaim the state then.
+var responseStatePool = sync.Pool{
+	New: func() any { return new(responseState) },
+}
+
+// WriteHeader records the first status and commits the response.
+func (s *responseState) WriteHeader(code int) {
+	if !s.committed {
+		s.status = code
+		s.committed = true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// Write commits the response with an implicit 200 if no status was written.
+func (s *responseState) Write(b []byte) (int, error) {
+	if !s.committed {
+		s.status = http.StatusOK
+		s.committed = true
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (s *responseState) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// tracked returns the state itself, so trackedResponse can find it behind wrappers.
+func (s *responseState) tracked() *responseState {
+	return s
+}
+
+// flush commits the response and flushes buffered data to the client.
+func (s *responseState) flush() {
+	if !s.committed {
+		s.status = http.StatusOK
+		s.committed = true
+	}
+	s.ResponseWriter.(http.Flusher).Flush()
+}
+
+// hijack takes over the connection. Nothing may be written through the server afterwards,
+// so the response counts as committed; hijacked connections are protocol upgrades in practice.
+func (s *responseState) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := s.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil && !s.committed {
+		s.status = http.StatusSwitchingProtocols
+		s.committed = true
+	}
+	return conn, rw, err
+}
+
+// The wrappers below advertise http.Flusher and http.Hijacker only when the underlying
+// writer supports them, so middleware type assertions behave as they would on w itself.
+
+// flushingResponseState is a responseState whose underlying writer supports streaming.
+type flushingResponseState struct {
+	*responseState
+}
+
+// Flush implements http.Flusher.
+func (s flushingResponseState) Flush() {
+	s.flush()
+}
+
+// hijackingResponseState is a responseState whose underlying writer supports hijacking.
+type hijackingResponseState struct {
+	*responseState
+}
+
+// Hijack implements http.Hijacker.
+func (s hijackingResponseState) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return s.hijack()
+}
+
+// flushingHijackingResponseState is a responseState whose underlying writer supports both,
+// as net/http's HTTP/1.x writer does.
+type flushingHijackingResponseState struct {
+	*responseState
+}
+
+// Flush implements http.Flusher.
+func (s flushingHijackingResponseState) Flush() {
+	s.flush()
+}
+
+// Hijack implements http.Hijacker.
+func (s flushingHijackingResponseState) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return s.hijack()
+}
+
+// trackResponse wraps w so later layers can detect an already-committed response.
+func trackResponse(w http.ResponseWriter) http.ResponseWriter {
+	state := responseStatePool.Get().(*responseState)
+	*state = responseState{ResponseWriter: w, start: time.Now()}
+
+	_, canFlush := w.(http.Flusher)
+	_, canHijack := w.(http.Hijacker)
+	switch {
+	case canFlush && canHijack:
+		return flushingHijackingResponseState{state}
+	case canFlush:
+		return flushingResponseState{state}
+	case canHijack:
+		return hijackingResponseState{state}
+	default:
+		return state
+	}
+}
+
+// trackedResponse walks the writer chain to the state installed by trackResponse.
+// It returns nil if there is none, or a wrapper in between does not implement Unwrap.
+func trackedResponse(w http.ResponseWriter) *responseState {
+	for w != nil {
+		if tracker, ok := w.(interface{ tracked() *responseState }); ok {
+			return tracker.tracked()
+		}
+		unwrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil
+		}
+		w = unwrapper.Unwrap()
+	}
+	return nil
+}
+
+// committedStatus walks the writer chain and reports whether a response was already committed.
+func committedStatus(w http.ResponseWriter) (int, bool) {
+	if state := trackedResponse(w); state != nil {
+		return state.status, state.committed
+	}
+	return 0, false
+}
+
+// recordHandlerTime records the time spent in the handler function since start,
+// reported as HandlerDurationMsKey on the request completion event.
+func recordHandlerTime(w http.ResponseWriter, start time.Time) {
+	if state := trackedResponse(w); state != nil {
+		state.fnTime = time.Since(start)
+	}
+}
+
+// trackResponses wraps every response writer passed to next with write-state tracking.
+// The state goes back to the pool once next returns; after a panic it is left to the GC.
+func trackResponses(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tracked := trackResponse(w)
+		next.ServeHTTP(tracked, r)
+
+		state := trackedResponse(tracked)
+		*state = responseState{}
+		responseStatePool.Put(state)
+	})
+}
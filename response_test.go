@@ -0,0 +1,180 @@
+package rocco
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// wrappingWriter simulates third-party middleware that wraps the response writer.
+type wrappingWriter struct {
+	http.ResponseWriter
+}
+
+func (w *wrappingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// hijackWriter is a ResponseWriter that supports hijacking, as net/http's HTTP/1.x writer does.
+type hijackWriter struct {
+	*minimalResponseWriter
+	hijacked bool
+}
+
+func (h *hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h.hijacked = true
+	return nil, nil, nil
+}
+
+type flushHijackWriter struct {
+	*flushRecorder
+}
+
+func (flushHijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, nil
+}
+
+func TestTrackResponse_OptionalInterfaces(t *testing.T) {
+	tests := []struct {
+		name      string
+		w         http.ResponseWriter
+		canFlush  bool
+		canHijack bool
+	}{
+		{"plain", newMinimalResponseWriter(), false, false},
+		{"flusher", newFlushRecorder(), true, false},
+		{"hijacker", &hijackWriter{minimalResponseWriter: newMinimalResponseWriter()}, false, true},
+		{"both", &flushHijackWriter{flushRecorder: newFlushRecorder()}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := trackResponse(tt.w)
+			if _, ok := w.(http.Flusher); ok != tt.canFlush {
+				t.Errorf("expected http.Flusher %v, got %v", tt.canFlush, ok)
+			}
+			if _, ok := w.(http.Hijacker); ok != tt.canHijack {
+				t.Errorf("expected http.Hijacker %v, got %v", tt.canHijack, ok)
+			}
+		})
+	}
+}
+
+func TestTrackResponse_Hijack(t *testing.T) {
+	hw := &hijackWriter{minimalResponseWriter: newMinimalResponseWriter()}
+	w := trackResponse(hw)
+
+	// Middleware upgrading a connection (e.g. to a WebSocket) asserts http.Hijacker directly.
+	if _, _, err := w.(http.Hijacker).Hijack(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !hw.hijacked {
+		t.Error("expected Hijack to reach the underlying writer")
+	}
+	if status, committed := committedStatus(w); !committed || status != http.StatusSwitchingProtocols {
+		t.Errorf("expected a hijacked response to count as committed, got %d %v", status, committed)
+	}
+}
+
+func TestTrackResponse_Committed(t *testing.T) {
+	mw := newMinimalResponseWriter()
+	w := trackResponse(mw)
+
+	if _, committed := committedStatus(w); committed {
+		t.Fatal("expected fresh response to be uncommitted")
+	}
+
+	w.WriteHeader(http.StatusForbidden)
+	w.WriteHeader(http.StatusOK)
+
+	status, committed := committedStatus(&wrappingWriter{ResponseWriter: w})
+	if !committed {
+		t.Fatal("expected response to be committed through wrapper")
+	}
+	if status != http.StatusForbidden {
+		t.Errorf("expected recorded status 403, got %d", status)
+	}
+	// net/http reports the superfluous call itself, so it must not be swallowed.
+	if mw.code != http.StatusOK {
+		t.Errorf("expected the duplicate WriteHeader to reach the underlying writer, got %d", mw.code)
+	}
+}
+
+func TestTrackResponse_WriteCommits(t *testing.T) {
+	w := trackResponse(httptest.NewRecorder())
+	w.Write([]byte("ok"))
+
+	status, committed := committedStatus(w)
+	if !committed || status != http.StatusOK {
+		t.Errorf("expected implicit 200 commit, got %d %v", status, committed)
+	}
+}
+
+func TestCommittedStatus_Untracked(t *testing.T) {
+	if _, committed := committedStatus(httptest.NewRecorder()); committed {
+		t.Error("expected untracked writer to report uncommitted")
+	}
+}
+
+func TestHandler_Process_ResponseAlreadyCommitted(t *testing.T) {
+	var called bool
+	handler := NewHandler[NoBody, testOutput](
+		"guarded",
+		"GET",
+		"/guarded",
+		func(_ *Request[NoBody]) (testOutput, error) {
+			called = true
+			return testOutput{Message: "should not be written"}, nil
+		},
+	)
+
+	engine := newTestEngine()
+	handler.WithMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Buggy middleware: responds but still calls next.
+			writeError(r.Context(), w, ErrForbidden, "guarded")
+			next.ServeHTTP(w, r)
+		})
+	})
+	engine.WithHandlers(handler)
+
+	req := httptest.NewRequest("GET", "/guarded", nil)
+	w := httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, req)
+
+	if called {
+		t.Error("expected handler function to not be called")
+	}
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status 403, got %d", w.Code)
+	}
+	if body := w.Body.String(); body == "" || strings.Contains(body, "should not be written") {
+		t.Errorf("expected only the middleware response, got %q", body)
+	}
+}
+
+func TestStreamHandler_Process_ResponseAlreadyCommitted(t *testing.T) {
+	handler := NewStreamHandler[NoBody, streamEvent](
+		"guarded-stream",
+		"GET",
+		"/events",
+		func(_ *Request[NoBody], _ Stream[streamEvent]) error {
+			t.Error("expected stream function to not be called")
+			return nil
+		},
+	)
+
+	w := trackResponse(newFlushRecorder())
+	w.WriteHeader(http.StatusUnauthorized)
+
+	status, err := handler.Process(context.Background(), httptest.NewRequest("GET", "/events", nil), w)
+	if err != errResponseCommitted {
+		t.Errorf("expected errResponseCommitted, got %v", err)
+	}
+	if status != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", status)
+	}
+}
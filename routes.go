@@ -0,0 +1,224 @@
+package rocco
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// routeKey normalizes a "METHOD /path" pattern so patterns that differ only in
+// wildcard names (e.g. {id} and {userID}) compare equal, as http.ServeMux treats them.
+func routeKey(pattern string) string {
+	var b strings.Builder
+	b.Grow(len(pattern))
+	for {
+		open := strings.IndexByte(pattern, '{')
+		if open < 0 {
+			b.WriteString(pattern)
+			return b.String()
+		}
+		end := strings.IndexByte(pattern[open:], '}')
+		if end < 0 {
+			b.WriteString(pattern)
+			return b.String()
+		}
+		b.WriteString(pattern[:open])
+		switch wildcard := pattern[open : open+end+1]; {
+		case wildcard == "{$}":
+			b.WriteString(wildcard)
+		case strings.HasSuffix(wildcard, "...}"):
+			b.WriteString("{...}")
+		default:
+			b.WriteString("{}")
+		}
+		pattern = pattern[open+end+1:]
+	}
+}
+
+// catchAllParam returns the name of the trailing "{name...}" wildcard of a path or
+// "METHOD /path" pattern, or "" if it has none. It captures the rest of the path.
+func catchAllParam(pattern string) string {
+	if !strings.HasSuffix(pattern, "...}") {
+		return ""
+	}
+	open := strings.LastIndexByte(pattern, '{')
+	if open < 0 {
+		return ""
+	}
+	return pattern[open+1 : len(pattern)-len("...}")]
+}
+
+// openAPIPath converts a declared path to an OpenAPI path template: a catch-all
+// "{name...}" becomes "{name}" and the "{$}" end anchor is dropped.
+func openAPIPath(path string) string {
+	if name := catchAllParam(path); name != "" {
+		path = path[:len(path)-len(name)-len("{...}")] + "{" + name + "}"
+	}
+	return strings.TrimSuffix(path, "{$}")
+}
+
+// Router dispatches requests to the engine's handlers. The default wraps http.ServeMux;
+// Engine.WithRouter swaps in another implementation.
+//
+// Handle receives the method and the path exactly as declared on the handler, in the
+// OpenAPI syntax the spec documents ("/users/{id}", "/files/{path...}"); routers with a
+// different syntax must translate it. Path parameters must be exposed through
+// http.Request.SetPathValue, which is where handlers read them. Handle may panic on a
+// conflicting route; the engine reports the panic as a registration error. If routes are
+// added while serving with Engine.AddHandler, Handle must be safe alongside ServeHTTP.
+type Router interface {
+	Handle(method, path string, handler http.Handler)
+	http.Handler
+}
+
+// HostRouter is a Router that can scope routes to a Host header, as used by handlers
+// declared with WithHost. Host-scoped handlers cannot be registered on a router that
+// does not implement it.
+type HostRouter interface {
+	Router
+	HandleHost(host, method, path string, handler http.Handler)
+}
+
+// serveMuxRouter adapts http.ServeMux to Router using "METHOD /path" patterns.
+// Unmatched requests get the mux's plain-text 404 and 405 unless a fallback is set.
+type serveMuxRouter struct {
+	*http.ServeMux
+	notFound         http.Handler // Answers requests matching no route (nil = ServeMux's 404)
+	methodNotAllowed http.Handler // Answers requests matching a route's path only (nil = ServeMux's 405)
+}
+
+// Handle registers handler for method and path.
+func (m serveMuxRouter) Handle(method, path string, handler http.Handler) {
+	m.ServeMux.Handle(method+" "+path, handler)
+}
+
+// HandleHost registers handler for method and path on requests for host. ServeMux
+// prefers host patterns over host-less ones and ignores the port when matching.
+func (m serveMuxRouter) HandleHost(host, method, path string, handler http.Handler) {
+	m.ServeMux.Handle(method+" "+host+path, handler)
+}
+
+// ServeHTTP dispatches r, passing unmatched requests to the configured fallbacks.
+// ServeMux reports a 404 and a 405 alike, as a handler with no pattern, so the
+// handler it would use is run against a probe to tell them apart.
+func (m serveMuxRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if m.notFound == nil && m.methodNotAllowed == nil {
+		m.ServeMux.ServeHTTP(w, r)
+		return
+	}
+	handler, pattern := m.ServeMux.Handler(r)
+	if pattern != "" {
+		m.ServeMux.ServeHTTP(w, r)
+		return
+	}
+
+	probe := &routeProbe{header: make(http.Header)}
+	handler.ServeHTTP(probe, r)
+	switch {
+	case probe.status == http.StatusMethodNotAllowed && m.methodNotAllowed != nil:
+		w.Header().Set("Allow", probe.header.Get("Allow"))
+		m.methodNotAllowed.ServeHTTP(w, r)
+	case probe.status == http.StatusNotFound && m.notFound != nil:
+		m.notFound.ServeHTTP(w, r)
+	default:
+		handler.ServeHTTP(w, r)
+	}
+}
+
+// routeProbe records the status and headers ServeMux's fallback handlers write, discarding the body.
+type routeProbe struct {
+	header http.Header
+	status int
+}
+
+func (p *routeProbe) Header() http.Header { return p.header }
+
+func (p *routeProbe) Write(b []byte) (int, error) { return len(b), nil }
+
+func (p *routeProbe) WriteHeader(status int) { p.status = status }
+
+// writeNotFound answers a request matching no route with ErrNotFound.
+func writeNotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(r.Context(), w, ErrNotFound, "")
+}
+
+// writeMethodNotAllowed answers a request whose method no route accepts with
+// ErrMethodNotAllowed, listing the methods from the Allow header in its details.
+func writeMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	details := MethodNotAllowedDetails{}
+	if allow := w.Header().Get("Allow"); allow != "" {
+		details.Allowed = strings.Split(allow, ", ")
+	}
+	writeError(r.Context(), w, ErrMethodNotAllowed.WithDetails(details), "")
+}
+
+// setRouteFallback applies set to the default router. Custom routers handle unmatched
+// requests themselves, so the change is refused and reported by Validate.
+func (e *Engine) setRouteFallback(set func(*serveMuxRouter)) *Engine {
+	e.handlersMu.Lock()
+	defer e.handlersMu.Unlock()
+	m, ok := e.router.(serveMuxRouter)
+	if !ok {
+		e.routeErrors = append(e.routeErrors, errors.New("not found and method not allowed handlers apply to the default router only; configure them on your Router"))
+		return e
+	}
+	set(&m)
+	e.router = m
+	e.server.Handler = m
+	return e
+}
+
+// registerRoute adds handler to the router for method and path, scoped to host unless
+// it is empty, on behalf of the named handler. Conflicts the router would panic on are
+// returned as errors instead: the same route registered twice names both handlers,
+// anything else carries the router's reason.
+func (e *Engine) registerRoute(name, method, host, path string, handler http.Handler) (err error) {
+	pattern := method + " " + host + path
+	key := routeKey(pattern)
+	if existing, ok := e.routes[key]; ok {
+		return fmt.Errorf("route %q for handler %q is already registered by handler %q", pattern, name, existing)
+	}
+	hostRouter, ok := e.router.(HostRouter)
+	if host != "" && !ok {
+		return fmt.Errorf("route %q for handler %q is scoped to a host, which router %T does not support", pattern, name, e.router)
+	}
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("route %q for handler %q conflicts with an existing route: %v", pattern, name, rec)
+		}
+	}()
+	if host != "" {
+		hostRouter.HandleHost(host, method, path, handler)
+	} else {
+		e.router.Handle(method, path, handler)
+	}
+
+	if e.routes == nil {
+		e.routes = make(map[string]string)
+	}
+	e.routes[key] = name
+	return nil
+}
+
+// Validate checks the registered handler set for problems that leave routes unserved,
+// such as two handlers registered for the same method and path. Start and Serve refuse
+// to run while Validate fails. Use ValidateSpec to check the OpenAPI specification.
+func (e *Engine) Validate() error {
+	e.handlersMu.RLock()
+	defer e.handlersMu.RUnlock()
+	if len(e.routeErrors) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid handler registration: %w", errors.Join(e.routeErrors...))
+}
+
+// endpoints returns the registered handlers. The snapshot stays valid while AddHandler
+// registers more, since registration only ever appends.
+func (e *Engine) endpoints() []Endpoint {
+	e.handlersMu.RLock()
+	defer e.handlersMu.RUnlock()
+	return slices.Clip(e.handlers)
+}
@@ -0,0 +1,435 @@
+package rocco
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zoobzio/capitan"
+)
+
+func newRouteHandler(name, method, path string) *Handler[NoBody, testOutput] {
+	return NewHandler[NoBody, testOutput](name, method, path, func(*Request[NoBody]) (testOutput, error) {
+		return testOutput{Message: name}, nil
+	})
+}
+
+func TestRouteKey(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"GET /users", "GET /users"},
+		{"GET /users/{id}", "GET /users/{}"},
+		{"GET /users/{userID}/posts/{postID}", "GET /users/{}/posts/{}"},
+		{"GET /files/{path...}", "GET /files/{...}"},
+		{"GET /users/{$}", "GET /users/{$}"},
+	}
+	for _, tt := range tests {
+		if got := routeKey(tt.pattern); got != tt.want {
+			t.Errorf("routeKey(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		want     string
+		catchAll string
+	}{
+		{"/users/{id}", "/users/{id}", ""},
+		{"/files/{path...}", "/files/{path}", "path"},
+		{"/buckets/{bucket}/{key...}", "/buckets/{bucket}/{key}", "key"},
+		{"/users/{$}", "/users/", ""},
+	}
+	for _, tt := range tests {
+		if got := openAPIPath(tt.path); got != tt.want {
+			t.Errorf("openAPIPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+		if got := catchAllParam(tt.path); got != tt.catchAll {
+			t.Errorf("catchAllParam(%q) = %q, want %q", tt.path, got, tt.catchAll)
+		}
+	}
+}
+
+func TestEngine_CatchAllRoute(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(NewHandler[NoBody, testOutput]("get-file", "GET", "/files/{path...}", func(req *Request[NoBody]) (testOutput, error) {
+		return testOutput{Message: req.Params.Path["path"]}, nil
+	}).WithPathParams("path"))
+
+	for path, want := range map[string]string{
+		"/files/docs/guide/intro.md": "docs/guide/intro.md",
+		"/files/":                    "",
+	} {
+		w := httptest.NewRecorder()
+		engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), fmt.Sprintf(`"message":%q`, want)) {
+			t.Errorf("GET %s: expected 200 with remainder %q, got %d: %s", path, want, w.Code, w.Body.String())
+		}
+	}
+
+	item, ok := engine.GenerateOpenAPI(nil).Paths["/files/{path}"]
+	if !ok {
+		t.Fatal("expected the catch-all documented as /files/{path}")
+	}
+	if params := item.Get.Parameters; len(params) != 1 || params[0].Name != "path" || params[0].Description == "" {
+		t.Errorf("expected a described path parameter, got %+v", params)
+	}
+}
+
+func TestEngine_DuplicateRoute(t *testing.T) {
+	setupSyncMode(t)
+
+	var names []string
+	listener := capitan.Hook(DuplicateRoute, func(_ context.Context, e *capitan.Event) {
+		name, _ := HandlerNameKey.From(e)
+		names = append(names, name)
+	})
+	defer listener.Close()
+
+	engine := newTestEngine()
+	engine.WithHandlers(
+		newRouteHandler("get-user", "GET", "/users/{id}"),
+		newRouteHandler("get-user-again", "GET", "/users/{userID}"),
+		newRouteHandler("update-user", "PUT", "/users/{id}"),
+	)
+
+	if len(names) != 1 || names[0] != "get-user-again" {
+		t.Fatalf("expected one DuplicateRoute event for get-user-again, got %v", names)
+	}
+
+	err := engine.Validate()
+	if err == nil {
+		t.Fatal("expected Validate to report the duplicate route")
+	}
+	if !strings.Contains(err.Error(), `"get-user"`) || !strings.Contains(err.Error(), `"get-user-again"`) {
+		t.Errorf("expected both handler names in error, got %v", err)
+	}
+
+	if len(engine.handlers) != 2 {
+		t.Errorf("expected the conflicting handler to be left out, got %d handlers", len(engine.handlers))
+	}
+	if _, ok := engine.GenerateOpenAPI(nil).Paths["/users/{userID}"]; ok {
+		t.Error("conflicting handler should not appear in the OpenAPI spec")
+	}
+}
+
+func TestEngine_ConflictingRoute(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(
+		newRouteHandler("by-owner", "GET", "/{owner}/settings"),
+		newRouteHandler("by-section", "GET", "/users/{section}"),
+		newRouteHandler("docs", "GET", "/docs"),
+	)
+
+	err := engine.Validate()
+	if err == nil {
+		t.Fatal("expected Validate to report conflicting routes")
+	}
+	for _, name := range []string{`"by-section"`, `"docs"`} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("expected %s in error, got %v", name, err)
+		}
+	}
+}
+
+func TestEngine_Validate_Clean(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(
+		newRouteHandler("list-users", "GET", "/users"),
+		newRouteHandler("get-user", "GET", "/users/{id}"),
+		newRouteHandler("create-user", "POST", "/users"),
+	)
+
+	if err := engine.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestEngine_Start_RejectsDuplicateRoutes(t *testing.T) {
+	engine := NewEngine("127.0.0.1", 0, nil)
+	engine.WithHandlers(
+		newRouteHandler("first", "GET", "/same"),
+		newRouteHandler("second", "GET", "/same"),
+	)
+
+	if err := engine.Start(); err == nil {
+		t.Error("expected Start to fail")
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Serve(l); err == nil {
+		t.Error("expected Serve to fail")
+	}
+}
+
+func TestEngine_AddHandler_WhileServing(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(newRouteHandler("static", "GET", "/static"))
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				for _, path := range []string{"/static", "/openapi", "/plugins/0"} {
+					engine.mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 10; i++ {
+		path := fmt.Sprintf("/plugins/%d", i)
+		if err := engine.AddHandler(newRouteHandler(fmt.Sprintf("plugin-%d", i), "GET", path)); err != nil {
+			t.Errorf("AddHandler(%s): %v", path, err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	w := httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, httptest.NewRequest("GET", "/plugins/9", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected added route to serve 200, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	engine.mux.ServeHTTP(w, httptest.NewRequest("GET", "/openapi", nil))
+	if !strings.Contains(w.Body.String(), "/plugins/9") {
+		t.Error("expected added route in the served spec")
+	}
+}
+
+func TestEngine_AddHandler_Conflict(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(newRouteHandler("first", "GET", "/same"))
+
+	err := engine.AddHandler(newRouteHandler("second", "GET", "/same"))
+	if err == nil || !strings.Contains(err.Error(), `"first"`) {
+		t.Fatalf("expected conflict naming the existing handler, got %v", err)
+	}
+	if err := engine.Validate(); err != nil {
+		t.Errorf("expected a rejected AddHandler not to fail Validate, got %v", err)
+	}
+	if len(engine.handlers) != 1 {
+		t.Errorf("expected the conflicting handler to be left out, got %d handlers", len(engine.handlers))
+	}
+}
+
+// prefixRouter mounts routes under a prefix, standing in for a third-party router.
+type prefixRouter struct {
+	prefix string
+	mux    *http.ServeMux
+}
+
+func (p *prefixRouter) Handle(method, path string, handler http.Handler) {
+	p.mux.Handle(method+" "+p.prefix+path, handler)
+}
+
+func (p *prefixRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mux.ServeHTTP(w, r)
+}
+
+func TestEngine_WithRouter(t *testing.T) {
+	router := &prefixRouter{prefix: "/api", mux: http.NewServeMux()}
+	engine := newTestEngine()
+	engine.WithRouter(router).WithHandlers(
+		NewHandler[NoBody, testOutput]("get-user", "GET", "/users/{id}", func(req *Request[NoBody]) (testOutput, error) {
+			return testOutput{Message: req.Params.Path["id"]}, nil
+		}).WithPathParams("id"),
+	)
+
+	if engine.Handler() != router {
+		t.Fatal("expected Handler to return the configured router")
+	}
+
+	w := httptest.NewRecorder()
+	engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/users/42", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"42"`) {
+		t.Errorf("expected 200 with the path param, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/openapi", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"/users/{id}"`) {
+		t.Errorf("expected the spec under the router with declared paths, got %d", w.Code)
+	}
+
+	if err := engine.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestEngine_WithRouter_AfterHandlers(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(newRouteHandler("first", "GET", "/first"))
+	engine.WithRouter(&prefixRouter{mux: http.NewServeMux()})
+
+	if _, ok := engine.Handler().(serveMuxRouter); !ok {
+		t.Error("expected the router to be left unchanged")
+	}
+	if err := engine.Validate(); err == nil {
+		t.Error("expected Validate to report the late WithRouter")
+	}
+}
+
+func TestEngine_RouteFallbacks(t *testing.T) {
+	engine := newTestEngine().WithNotFoundHandler(nil).WithMethodNotAllowedHandler(nil)
+	engine.WithHandlers(newRouteHandler("get-item", "GET", "/items/{id}").WithPathParams("id"))
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{"GET", "/items/1", http.StatusOK, `"message"`},
+		{"GET", "/missing", http.StatusNotFound, `{"code":"NOT_FOUND","message":"not found"`},
+		{"DELETE", "/items/1", http.StatusMethodNotAllowed, `{"code":"METHOD_NOT_ALLOWED","message":"method not allowed","details":{"allowed":["GET","HEAD"]}}`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.Handler().ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("%s %s: expected %d with %s, got %d: %s", tt.method, tt.path, tt.status, tt.body, w.Code, w.Body.String())
+		}
+		if tt.status != http.StatusOK && w.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("%s %s: expected a JSON error, got %q", tt.method, tt.path, w.Header().Get("Content-Type"))
+		}
+	}
+
+	w := httptest.NewRecorder()
+	engine.Handler().ServeHTTP(w, httptest.NewRequest("DELETE", "/items/1", nil))
+	if allow := w.Header().Get("Allow"); allow != "GET, HEAD" {
+		t.Errorf("expected the Allow header to be kept, got %q", allow)
+	}
+}
+
+func TestEngine_RouteFallbacks_Custom(t *testing.T) {
+	engine := newTestEngine().WithNotFoundHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	engine.WithHandlers(newRouteHandler("get-item", "GET", "/items"))
+
+	w := httptest.NewRecorder()
+	engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/missing", nil))
+	if w.Code != http.StatusTeapot {
+		t.Errorf("expected the custom not found handler, got %d", w.Code)
+	}
+
+	// Without a method not allowed handler, ServeMux still answers 405 itself.
+	w = httptest.NewRecorder()
+	engine.Handler().ServeHTTP(w, httptest.NewRequest("POST", "/items", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Content-Type") == "application/json" {
+		t.Errorf("expected the ServeMux 405, got %d (%s)", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	custom := newTestEngine().WithRouter(&prefixRouter{mux: http.NewServeMux()}).WithNotFoundHandler(nil)
+	if err := custom.Validate(); err == nil {
+		t.Error("expected Validate to report fallbacks on a custom router")
+	}
+}
+
+func TestEngine_PathParamPattern(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(
+		newRouteHandler("get-user", "GET", "/users/{id}").WithPathParams("id").WithPathParamPattern("id", "[0-9]+"),
+		newRouteHandler("get-file", "GET", "/files/{name}").WithPathParamPattern("name", `^\w+\.txt$`),
+	)
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/users/42", http.StatusOK},
+		{"/users/abc", http.StatusNotFound},
+		{"/users/42x", http.StatusNotFound},
+		{"/files/notes.txt", http.StatusOK},
+		{"/files/notes.md", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("GET %s: expected %d, got %d", tt.path, tt.want, w.Code)
+		}
+		if tt.want == http.StatusNotFound && !strings.Contains(w.Body.String(), `"NOT_FOUND"`) {
+			t.Errorf("GET %s: expected a NOT_FOUND error body, got %s", tt.path, w.Body.String())
+		}
+	}
+}
+
+func TestEngine_WithHost(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(
+		newRouteHandler("tenant-a", "GET", "/dashboard").WithHost("a.example.com"),
+		newRouteHandler("tenant-b", "GET", "/dashboard").WithHost("b.example.com"),
+		newRouteHandler("shared", "GET", "/status"),
+	)
+	if err := engine.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		host   string
+		path   string
+		status int
+		want   string
+	}{
+		{"a.example.com", "/dashboard", http.StatusOK, "tenant-a"},
+		{"b.example.com:8080", "/dashboard", http.StatusOK, "tenant-b"},
+		{"c.example.com", "/dashboard", http.StatusNotFound, ""},
+		{"a.example.com", "/status", http.StatusOK, "shared"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req.Host = tt.host
+		w := httptest.NewRecorder()
+		engine.Handler().ServeHTTP(w, req)
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%s%s: expected %d with %q, got %d: %s", tt.host, tt.path, tt.status, tt.want, w.Code, w.Body.String())
+		}
+	}
+
+	spec := engine.GenerateOpenAPI(nil)
+	op := spec.Paths["/status"].Get
+	if op == nil || len(op.Servers) != 0 {
+		t.Errorf("expected a host-less operation to use the engine servers, got %+v", op)
+	}
+	op = spec.Paths["/dashboard"].Get
+	if op == nil || len(op.Servers) != 1 || !strings.HasSuffix(op.Servers[0].URL, ".example.com") {
+		t.Errorf("expected the operation to document its host as a server, got %+v", op)
+	}
+	if err := engine.ValidateSpec(); err == nil || !strings.Contains(err.Error(), "already documented") {
+		t.Errorf("expected ValidateSpec to report the shared operation, got %v", err)
+	}
+}
+
+func TestEngine_WithHost_UnsupportedRouter(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithRouter(&prefixRouter{mux: http.NewServeMux()}).WithHandlers(
+		newRouteHandler("tenant", "GET", "/dashboard").WithHost("a.example.com"),
+	)
+
+	if err := engine.Validate(); err == nil || !strings.Contains(err.Error(), "does not support") {
+		t.Errorf("expected Validate to report the unsupported host route, got %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package rocco
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/zoobzio/sentinel"
+)
+
+// scanErrorReporter is implemented by endpoints that record type introspection failures.
+type scanErrorReporter interface {
+	ScanErrors() []error
+}
+
+// scanType introspects T with sentinel, returning an error instead of panicking
+// when T cannot be modeled (non-struct kinds, interfaces).
+// On failure the metadata carries only a descriptive TypeName so the handler can
+// still be constructed and the problem surfaced at registration.
+func scanType[T any]() (sentinel.Metadata, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() == reflect.Interface {
+		return sentinel.Metadata{TypeName: t.String()}, fmt.Errorf("cannot introspect interface type %s", t)
+	}
+
+	meta, err := sentinel.TryScan[T]()
+	if err != nil {
+		return sentinel.Metadata{TypeName: t.String()}, fmt.Errorf("cannot introspect %s: %w", t, err)
+	}
+	return meta, nil
+}
+
+// collectScanErrors returns the non-nil errors from a handler's type scans.
+func collectScanErrors(errs ...error) []error {
+	var result []error
+	for _, err := range errs {
+		if err != nil {
+			result = append(result, err)
+		}
+	}
+	return result
+}
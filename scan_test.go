@@ -0,0 +1,71 @@
+package rocco
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScanType(t *testing.T) {
+	meta, err := scanType[testInput]()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if meta.TypeName != "testInput" {
+		t.Errorf("expected type name 'testInput', got %q", meta.TypeName)
+	}
+
+	meta, err = scanType[*testInput]()
+	if err != nil {
+		t.Fatalf("unexpected error for pointer to struct: %v", err)
+	}
+	if meta.TypeName != "testInput" {
+		t.Errorf("expected type name 'testInput', got %q", meta.TypeName)
+	}
+}
+
+func TestScanType_Unsupported(t *testing.T) {
+	tests := []struct {
+		name     string
+		scan     func() (string, error)
+		typeName string
+	}{
+		{"string", func() (string, error) { m, err := scanType[string](); return m.TypeName, err }, "string"},
+		{"slice", func() (string, error) { m, err := scanType[[]testOutput](); return m.TypeName, err }, "[]rocco.testOutput"},
+		{"interface", func() (string, error) { m, err := scanType[any](); return m.TypeName, err }, "interface {}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typeName, err := tt.scan()
+			if err == nil {
+				t.Fatal("expected scan error")
+			}
+			if !strings.Contains(err.Error(), "cannot introspect") {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if typeName != tt.typeName {
+				t.Errorf("expected type name %q, got %q", tt.typeName, typeName)
+			}
+		})
+	}
+}
+
+func TestHandler_ScanErrors(t *testing.T) {
+	valid := NewHandler[testInput, testOutput]("valid", "POST", "/valid",
+		func(_ *Request[testInput]) (testOutput, error) { return testOutput{}, nil })
+	if errs := valid.ScanErrors(); len(errs) != 0 {
+		t.Errorf("expected no scan errors, got %v", errs)
+	}
+
+	scalar := NewHandler[NoBody, string]("scalar", "GET", "/scalar",
+		func(_ *Request[NoBody]) (string, error) { return "ok", nil })
+	if errs := scalar.ScanErrors(); len(errs) != 1 {
+		t.Errorf("expected 1 scan error, got %v", errs)
+	}
+
+	stream := NewStreamHandler[NoBody, []streamEvent]("stream", "GET", "/stream",
+		func(_ *Request[NoBody], _ Stream[[]streamEvent]) error { return nil })
+	if errs := stream.ScanErrors(); len(errs) != 1 {
+		t.Errorf("expected 1 scan error, got %v", errs)
+	}
+}
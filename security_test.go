@@ -0,0 +1,86 @@
+package rocco
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSecurityHeaders_Defaults(t *testing.T) {
+	handler := SecurityHeaders(nil)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "https://api.example.com/", nil))
+
+	want := map[string]string{
+		"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+		"Content-Security-Policy":   "default-src 'none'; frame-ancestors 'none'",
+		"X-Content-Type-Options":    "nosniff",
+		"Referrer-Policy":           "no-referrer",
+		"Permissions-Policy":        "camera=(), geolocation=(), microphone=()",
+	}
+	for name, value := range want {
+		if got := w.Header().Get(name); got != value {
+			t.Errorf("expected %s %q, got %q", name, value, got)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "http://api.example.com/", nil))
+	if got := w.Header().Get("Strict-Transport-Security"); got != "" {
+		t.Errorf("expected no HSTS over plain HTTP, got %q", got)
+	}
+}
+
+func TestSecurityHeaders_Overrides(t *testing.T) {
+	config := DefaultSecurityHeadersConfig()
+	config.HSTSMaxAge = 24 * time.Hour
+	config.HSTSIncludeSubdomains = false
+	config.HSTSPreload = true
+	config.PermissionsPolicy = ""
+	handler := SecurityHeaders(config)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Referrer-Policy", "same-origin")
+		w.Write([]byte("ok"))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "https://api.example.com/", nil))
+
+	if got := w.Header().Get("Strict-Transport-Security"); got != "max-age=86400; preload" {
+		t.Errorf("unexpected HSTS header %q", got)
+	}
+	if _, ok := w.Header()["Permissions-Policy"]; ok {
+		t.Error("expected an empty field to omit its header")
+	}
+	if got := w.Header().Get("Referrer-Policy"); got != "same-origin" {
+		t.Errorf("expected the handler's header to win, got %q", got)
+	}
+}
+
+func TestSecurityHeaders_Engine(t *testing.T) {
+	engine := newTestEngine().WithMiddleware(SecurityHeaders(nil))
+	engine.WithHandlers(NewHandler[testInput, testOutput](
+		"create",
+		"POST",
+		"/items",
+		func(_ *Request[testInput]) (testOutput, error) {
+			return testOutput{}, nil
+		},
+	))
+
+	// Error responses written by the engine carry the headers too.
+	w := httptest.NewRecorder()
+	engine.Handler().ServeHTTP(w, httptest.NewRequest("POST", "/items", nil))
+	if w.Code < 400 || w.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("expected an error response with security headers, got %d %v", w.Code, w.Header())
+	}
+
+	w = httptest.NewRecorder()
+	engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/docs", nil))
+	if got := w.Header().Get("Content-Security-Policy"); got != "" {
+		t.Errorf("expected the docs page to be left alone, got CSP %q", got)
+	}
+}
@@ -10,24 +10,60 @@ type HandlerSpec struct {
 	Name   string `json:"name" yaml:"name"`
 	Method string `json:"method" yaml:"method"`
 	Path   string `json:"path" yaml:"path"`
+	Host   string `json:"host,omitempty" yaml:"host,omitempty"` // Host header the route is scoped to ("" = any)
 
 	// Documentation
 	Summary     string   `json:"summary,omitempty" yaml:"summary,omitempty"`
 	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
 	Tags        []string `json:"tags,omitempty" yaml:"tags,omitempty"`
 
+	// Deprecated operations are marked in the spec and emit HandlerDeprecated when invoked
+	Deprecated bool `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+
+	// Servers overriding EngineSpec.Servers for this operation
+	Servers []openapi.Server `json:"servers,omitempty" yaml:"servers,omitempty"`
+
 	// Request/Response
-	PathParams     []string `json:"pathParams,omitempty" yaml:"pathParams,omitempty"`
-	QueryParams    []string `json:"queryParams,omitempty" yaml:"queryParams,omitempty"`
-	InputTypeName  string   `json:"inputTypeName" yaml:"inputTypeName"`
-	OutputTypeName string   `json:"outputTypeName" yaml:"outputTypeName"`
-	SuccessStatus  int      `json:"successStatus" yaml:"successStatus"`
-	ErrorCodes     []int    `json:"errorCodes,omitempty" yaml:"errorCodes,omitempty"`
+	PathParams           []string                   `json:"pathParams,omitempty" yaml:"pathParams,omitempty"`
+	PathParamStyles      map[string]string          `json:"pathParamStyles,omitempty" yaml:"pathParamStyles,omitempty"`     // Non-default serialization styles by name
+	PathParamExplode     map[string]bool            `json:"pathParamExplode,omitempty" yaml:"pathParamExplode,omitempty"`   // Explicit explode settings by name
+	PathParamPatterns    map[string]string          `json:"pathParamPatterns,omitempty" yaml:"pathParamPatterns,omitempty"` // Anchored value patterns by name
+	QueryParams          []string                   `json:"queryParams,omitempty" yaml:"queryParams,omitempty"`
+	QueryParamSchemas    map[string]*openapi.Schema `json:"queryParamSchemas,omitempty" yaml:"queryParamSchemas,omitempty"` // Typed schemas from WithQueryStruct
+	MultiQueryParams     []string                   `json:"multiQueryParams,omitempty" yaml:"multiQueryParams,omitempty"`   // Repeatable query parameters
+	RequiredQueryParams  []string                   `json:"requiredQueryParams,omitempty" yaml:"requiredQueryParams,omitempty"`
+	HeaderParams         []string                   `json:"headerParams,omitempty" yaml:"headerParams,omitempty"`
+	CookieParams         []string                   `json:"cookieParams,omitempty" yaml:"cookieParams,omitempty"`
+	RequiredHeaderParams []string                   `json:"requiredHeaderParams,omitempty" yaml:"requiredHeaderParams,omitempty"`
+	InputTypeName        string                     `json:"inputTypeName" yaml:"inputTypeName"`
+	RequestMediaType     string                     `json:"requestMediaType,omitempty" yaml:"requestMediaType,omitempty"`         // Defaults to application/json
+	ResponseContentTypes []string                   `json:"responseContentTypes,omitempty" yaml:"responseContentTypes,omitempty"` // Non-JSON success content, documented as binary
+	RequestSchema        *openapi.Schema            `json:"requestSchema,omitempty" yaml:"requestSchema,omitempty"`               // Inline JSON Schema from WithJSONSchema
+	OutputTypeName       string                     `json:"outputTypeName" yaml:"outputTypeName"`
+	SuccessStatus        int                        `json:"successStatus" yaml:"successStatus"`
+	ErrorCodes           []int                      `json:"errorCodes,omitempty" yaml:"errorCodes,omitempty"`
+	SparseFields         bool                       `json:"sparseFields,omitempty" yaml:"sparseFields,omitempty"`             // Supports ?fields= filtering
+	LastModified         bool                       `json:"lastModified,omitempty" yaml:"lastModified,omitempty"`             // Sets Last-Modified, may answer 304
+	MaxBodySize          int64                      `json:"maxBodySize,omitempty" yaml:"maxBodySize,omitempty"`               // Request body limit in bytes (0 = unlimited)
+	ResponseHeaders      map[string]string          `json:"responseHeaders,omitempty" yaml:"responseHeaders,omitempty"`       // Static headers set on success responses
+	ResponseHeaderDocs   map[string]string          `json:"responseHeaderDocs,omitempty" yaml:"responseHeaderDocs,omitempty"` // Header descriptions, including dynamic headers
+
+	// Named examples for the request body (e.g., minimal vs full payloads)
+	RequestExamples map[string]*openapi.Example `json:"requestExamples,omitempty" yaml:"requestExamples,omitempty"`
+
+	// Named examples for the success response (e.g., authenticated vs anonymous variants)
+	ResponseExamples map[string]*openapi.Example `json:"responseExamples,omitempty" yaml:"responseExamples,omitempty"`
+
+	// Whole-body examples: the request body, and response bodies keyed by status code
+	RequestExample           any         `json:"requestExample,omitempty" yaml:"requestExample,omitempty"`
+	ResponseExamplesByStatus map[int]any `json:"responseExamplesByStatus,omitempty" yaml:"responseExamplesByStatus,omitempty"`
 
 	// Authentication & Authorization
-	RequiresAuth bool       `json:"requiresAuth" yaml:"requiresAuth"`
-	ScopeGroups  [][]string `json:"scopeGroups,omitempty" yaml:"scopeGroups,omitempty"` // OR within group, AND across groups
-	RoleGroups   [][]string `json:"roleGroups,omitempty" yaml:"roleGroups,omitempty"`   // OR within group, AND across groups
+	RequiresAuth   bool       `json:"requiresAuth" yaml:"requiresAuth"`
+	OptionalAuth   bool       `json:"optionalAuth,omitempty" yaml:"optionalAuth,omitempty"`     // Identity extracted if present, not required
+	SecurityScheme string     `json:"securityScheme,omitempty" yaml:"securityScheme,omitempty"` // Named scheme in the OpenAPI spec (default: bearerAuth)
+	ScopeGroups    [][]string `json:"scopeGroups,omitempty" yaml:"scopeGroups,omitempty"`       // OR within group, AND across groups
+	RoleGroups     [][]string `json:"roleGroups,omitempty" yaml:"roleGroups,omitempty"`         // OR within group, AND across groups
 
 	// Rate Limiting
 	UsageLimits []UsageLimit `json:"usageLimits,omitempty" yaml:"usageLimits,omitempty"`
@@ -46,6 +82,12 @@ type EngineSpec struct {
 	// Global Tags with descriptions
 	Tags []openapi.Tag `json:"tags,omitempty" yaml:"tags,omitempty"`
 
+	// Tag groups for hierarchical navigation, emitted as x-tagGroups
+	TagGroups []TagGroup `json:"tagGroups,omitempty" yaml:"tagGroups,omitempty"`
+
+	// Tags applied to operations that declare none
+	DefaultTags []string `json:"defaultTags,omitempty" yaml:"defaultTags,omitempty"`
+
 	// Servers
 	Servers []openapi.Server `json:"servers,omitempty" yaml:"servers,omitempty"`
 
@@ -54,6 +96,20 @@ type EngineSpec struct {
 
 	// Global Security (optional, for APIs that require auth on all endpoints)
 	Security []openapi.SecurityRequirement `json:"security,omitempty" yaml:"security,omitempty"`
+
+	// Named security schemes referenced by handlers
+	SecuritySchemes map[string]*openapi.SecurityScheme `json:"securitySchemes,omitempty" yaml:"securitySchemes,omitempty"`
+}
+
+// DefaultSecurityScheme names the HTTP bearer scheme documented for authenticated
+// handlers that do not select another scheme with WithSecurityScheme.
+const DefaultSecurityScheme = "bearerAuth"
+
+// TagGroup collects tags under a top-level section in documentation UIs such as
+// Redoc and Scalar, which read the x-tagGroups extension.
+type TagGroup struct {
+	Name string   `json:"name" yaml:"name"`
+	Tags []string `json:"tags" yaml:"tags"`
 }
 
 // DefaultEngineSpec returns an EngineSpec with sensible defaults.
@@ -1,16 +1,23 @@
 package rocco
 
 import (
+	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/zoobzio/capitan"
+	"github.com/zoobzio/openapi"
 	"github.com/zoobzio/sentinel"
 )
 
@@ -20,19 +27,58 @@ type Stream[T any] interface {
 	Send(data T) error
 	// SendEvent sends a named event with data.
 	SendEvent(event string, data T) error
+	// SendEventWithID sends a named event with data and an id clients resume from.
+	SendEventWithID(id, event string, data T) error
+	// SendError sends err as a named event (default "error") carrying the standard
+	// error body, without ending the stream.
+	SendError(event string, err error) error
+	// SendRaw sends one event built from the given SSE fields, written as-is.
+	SendRaw(fields map[string]string) error
 	// SendComment sends a comment (useful for keep-alive).
 	SendComment(comment string) error
 	// Done returns a channel closed when client disconnects.
 	Done() <-chan struct{}
+	// LastEventID returns the Last-Event-ID sent by a reconnecting client, or "".
+	LastEventID() string
 }
 
-// sseStream implements Stream[T] for Server-Sent Events.
+// LastEventIDHeader is the header a reconnecting SSE client sends with the id of the
+// last event it received.
+const LastEventIDHeader = "Last-Event-ID"
+
+// errStreamShutdown is the cause of a stream context cancelled by engine shutdown.
+var errStreamShutdown = errors.New("engine shutting down")
+
+// sseStream implements Stream[T] for Server-Sent Events, or for newline-delimited
+// JSON when ndjson is set.
 type sseStream[T any] struct {
 	w       http.ResponseWriter
 	flusher http.Flusher
 	done    <-chan struct{}
 	mu      sync.Mutex
 	closed  bool
+
+	// Validates event data before writing (nil = disabled, see WithEventValidation).
+	validator *validator.Validate
+
+	// How much of an error SendError exposes.
+	detailMode ErrorDetailMode
+
+	// Last-Event-ID sent by the client when resuming.
+	lastEventID string
+
+	// Writes string and []byte data as-is instead of as JSON (see WithTextEncoding).
+	text bool
+
+	// Writes each event as one JSON line instead of an SSE frame (see NewNDJSONHandler).
+	ndjson bool
+
+	// Bounds each send when set (nil = no deadline, see WithWriteTimeout).
+	controller   *http.ResponseController
+	writeTimeout time.Duration
+
+	// Time fields in T with custom formats (nil = none).
+	times *timeFormats
 }
 
 // Send sends a data-only event.
@@ -42,6 +88,13 @@ func (s *sseStream[T]) Send(data T) error {
 
 // SendEvent sends a named event with data.
 func (s *sseStream[T]) SendEvent(event string, data T) error {
+	return s.SendEventWithID("", event, data)
+}
+
+// SendEventWithID sends a named event with data, preceded by an id line. A reconnecting
+// client sends the last id it received as Last-Event-ID (see LastEventID), so the
+// handler can skip events already delivered. An empty id or event name is omitted.
+func (s *sseStream[T]) SendEventWithID(id, event string, data T) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -57,12 +110,74 @@ func (s *sseStream[T]) SendEvent(event string, data T) error {
 	default:
 	}
 
-	// Marshal data
-	jsonData, err := json.Marshal(data)
+	// Validate data; a malformed event ends the stream instead of reaching the client.
+	if s.validator != nil {
+		var invalid *validator.InvalidValidationError
+		if err := s.validator.Struct(data); err != nil && !errors.As(err, &invalid) {
+			s.closed = true
+			return fmt.Errorf("event validation failed: %w", err)
+		}
+	}
+
+	return s.writeEvent(id, event, data)
+}
+
+// SendError sends err as a named event carrying the same body as an error response,
+// e.g. to report a failed item while the stream continues. Errors that are not rocco
+// errors are sent as ErrInternalServer. An empty event name sends an "error" event.
+func (s *sseStream[T]) SendError(event string, err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.New("stream closed")
+	}
+
+	select {
+	case <-s.done:
+		s.closed = true
+		return errors.New("client disconnected")
+	default:
+	}
+
+	if event == "" {
+		event = "error"
+	}
+	errDef := getRoccoError(err)
+	if errDef == nil {
+		errDef = ErrInternalServer.WithCause(err)
+	}
+	return s.writeEvent("", event, newErrorResponse(errDef, s.detailMode, false))
+}
+
+// writeEvent marshals data and writes it as one event. The caller must hold s.mu.
+func (s *sseStream[T]) writeEvent(id, event string, data any) error {
+	// A line break would end the field early and let the rest inject new fields.
+	if strings.ContainsAny(event, "\r\n") {
+		return fmt.Errorf("invalid event name %q: contains a line break", event)
+	}
+	// Clients also ignore ids containing NUL, which would silently break resumption.
+	if strings.ContainsAny(id, "\r\n\x00") {
+		return fmt.Errorf("invalid event id %q: contains a line break or NUL", id)
+	}
+
+	// Encode data
+	payload, err := s.encode(data)
 	if err != nil {
 		return fmt.Errorf("failed to marshal event data: %w", err)
 	}
 
+	s.setWriteDeadline()
+
+	// NDJSON records carry only the data; compact JSON never spans lines
+	if s.ndjson {
+		if _, err := s.w.Write(append(payload, '\n')); err != nil {
+			s.closed = true
+			return fmt.Errorf("failed to write event data: %w", err)
+		}
+		return s.flush()
+	}
+
 	// Write event name if provided
 	if event != "" {
 		if _, err := fmt.Fprintf(s.w, "event: %s\n", event); err != nil {
@@ -71,17 +186,166 @@ func (s *sseStream[T]) SendEvent(event string, data T) error {
 		}
 	}
 
-	// Write data
-	if _, err := fmt.Fprintf(s.w, "data: %s\n\n", jsonData); err != nil {
+	// Write event id if provided
+	if id != "" {
+		if _, err := fmt.Fprintf(s.w, "id: %s\n", id); err != nil {
+			s.closed = true
+			return fmt.Errorf("failed to write event id: %w", err)
+		}
+	}
+
+	// Write data, one line per line of payload so clients rejoin multi-line text
+	var frame bytes.Buffer
+	for _, line := range strings.Split(sseLineBreaks.Replace(string(payload)), "\n") {
+		frame.WriteString("data: " + line + "\n")
+	}
+	frame.WriteString("\n")
+	if _, err := s.w.Write(frame.Bytes()); err != nil {
 		s.closed = true
 		return fmt.Errorf("failed to write event data: %w", err)
 	}
 
-	s.flusher.Flush()
+	return s.flush()
+}
+
+// setWriteDeadline bounds the write and flush that follow by the stream's write timeout.
+// Writers that do not support deadlines are written to without one.
+func (s *sseStream[T]) setWriteDeadline() {
+	if s.controller == nil {
+		return
+	}
+	if err := s.controller.SetWriteDeadline(time.Now().Add(s.writeTimeout)); err != nil {
+		s.controller = nil
+	}
+}
+
+// flush sends buffered output to the client. Under a write deadline, a client that
+// stopped reading fails the flush with os.ErrDeadlineExceeded and closes the stream.
+func (s *sseStream[T]) flush() error {
+	if s.controller == nil {
+		s.flusher.Flush()
+		return nil
+	}
+	if err := s.controller.Flush(); err != nil {
+		s.closed = true
+		return fmt.Errorf("failed to flush event: %w", err)
+	}
 	return nil
 }
 
-// SendComment sends a comment (useful for keep-alive).
+// encode renders event data: JSON, or the value itself for text streams. Error bodies
+// from SendError and NDJSON records are always JSON.
+func (s *sseStream[T]) encode(data any) ([]byte, error) {
+	if s.text && !s.ndjson {
+		switch v := data.(type) {
+		case string:
+			return []byte(v), nil
+		case []byte:
+			return v, nil
+		}
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	// Error bodies from SendError are not T and have no timeformat fields.
+	if _, ok := data.(T); ok && s.times != nil {
+		return s.times.format(payload)
+	}
+	return payload, nil
+}
+
+// SendRaw sends one event built from fields, for frames Send and SendEvent cannot
+// express (e.g. "id" or "retry", or fields a consumer defines). Values are written
+// verbatim: "event", "id" and "retry" come first, then other fields by name, then
+// "data", which is split into one data line per line of its value. Invalid fields
+// are rejected without writing anything, and the stream stays open. NDJSON streams
+// have no fields to write and return an error.
+func (s *sseStream[T]) SendRaw(fields map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.New("stream closed")
+	}
+	if s.ndjson {
+		return errors.New("raw SSE fields cannot be sent on an NDJSON stream")
+	}
+
+	select {
+	case <-s.done:
+		s.closed = true
+		return errors.New("client disconnected")
+	default:
+	}
+
+	frame, err := sseFrame(fields)
+	if err != nil {
+		return err
+	}
+
+	s.setWriteDeadline()
+	if _, err := s.w.Write(frame); err != nil {
+		s.closed = true
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+
+	return s.flush()
+}
+
+// sseLineBreaks normalizes line endings to LF; any of CRLF, CR and LF ends a line in SSE.
+var sseLineBreaks = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// sseFieldOrder ranks the fields SendRaw writes first; others follow by name, then data.
+var sseFieldOrder = map[string]int{"event": 1, "id": 2, "retry": 3}
+
+// sseFrame renders fields as one event frame in a deterministic order.
+func sseFrame(fields map[string]string) ([]byte, error) {
+	if len(fields) == 0 {
+		return nil, errors.New("event has no fields")
+	}
+
+	names := make([]string, 0, len(fields))
+	for name, value := range fields {
+		if name == "" || strings.ContainsAny(name, ":\r\n") {
+			return nil, fmt.Errorf("invalid field name %q", name)
+		}
+		if name != "data" && strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("invalid %s field: contains a line break", name)
+		}
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return cmp.Or(cmp.Compare(sseFieldRank(a), sseFieldRank(b)), cmp.Compare(a, b))
+	})
+
+	var frame bytes.Buffer
+	for _, name := range names {
+		if name == "data" {
+			for _, line := range strings.Split(sseLineBreaks.Replace(fields[name]), "\n") {
+				frame.WriteString("data: " + line + "\n")
+			}
+			continue
+		}
+		frame.WriteString(name + ": " + fields[name] + "\n")
+	}
+	frame.WriteString("\n")
+	return frame.Bytes(), nil
+}
+
+// sseFieldRank orders a field for sseFrame: known fields first, data last.
+func sseFieldRank(name string) int {
+	if name == "data" {
+		return len(sseFieldOrder) + 2
+	}
+	if rank, ok := sseFieldOrder[name]; ok {
+		return rank
+	}
+	return len(sseFieldOrder) + 1
+}
+
+// SendComment sends a comment (useful for keep-alive). NDJSON has no comments, so an
+// NDJSON stream writes an empty line instead, which most readers skip.
 func (s *sseStream[T]) SendComment(comment string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -97,13 +361,37 @@ func (s *sseStream[T]) SendComment(comment string) error {
 	default:
 	}
 
-	if _, err := fmt.Fprintf(s.w, ": %s\n\n", comment); err != nil {
+	frame := ": " + comment + "\n\n"
+	if s.ndjson {
+		frame = "\n"
+	}
+	s.setWriteDeadline()
+	if _, err := s.w.Write([]byte(frame)); err != nil {
 		s.closed = true
 		return fmt.Errorf("failed to write comment: %w", err)
 	}
 
-	s.flusher.Flush()
-	return nil
+	return s.flush()
+}
+
+// keepAlive sends a keep-alive comment every interval until stop closes or a send fails,
+// e.g. because the client disconnected. It closes stopped when it returns.
+func (s *sseStream[T]) keepAlive(interval time.Duration, stop <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.SendComment("keep-alive"); err != nil {
+				return
+			}
+		case <-stop:
+			return
+		case <-s.done:
+			return
+		}
+	}
 }
 
 // Done returns a channel closed when client disconnects.
@@ -111,6 +399,12 @@ func (s *sseStream[T]) Done() <-chan struct{} {
 	return s.done
 }
 
+// LastEventID returns the Last-Event-ID header the client connected with, or "" for a
+// fresh connection.
+func (s *sseStream[T]) LastEventID() string {
+	return s.lastEventID
+}
+
 // StreamHandler wraps a typed streaming handler function with metadata.
 // It implements Endpoint interface for SSE (Server-Sent Events) responses.
 type StreamHandler[In, Out any] struct {
@@ -123,15 +417,42 @@ type StreamHandler[In, Out any] struct {
 	// Type metadata from sentinel.
 	InputMeta  sentinel.Metadata
 	OutputMeta sentinel.Metadata
+	scanErrors []error // Introspection failures for In/Out (schemas will be empty).
 
 	// Error definitions with schemas for OpenAPI generation.
 	errorDefs []ErrorDefinition
 
 	// Validation.
-	validator *validator.Validate
+	validator    *validator.Validate
+	pathPatterns map[string]*regexp.Regexp // Compiled WithPathParamPattern patterns by parameter name.
 
 	// Middleware.
 	middleware []func(http.Handler) http.Handler
+
+	// Maximum stream lifetime (0 = none).
+	timeout time.Duration
+
+	// Maximum initial request body size in bytes (0 = unlimited, default: 10MB).
+	maxBodySize int64
+
+	// Whether to validate event data before sending (disabled by default).
+	validateEvents bool
+
+	// Interval between keep-alive comments (0 = none).
+	keepAlive time.Duration
+
+	// Whether events carry text rather than JSON (see WithTextEncoding).
+	textEvents bool
+
+	// Whether events are written as newline-delimited JSON rather than SSE.
+	ndjson bool
+
+	// Maximum time a single send may block writing to the client (0 = unbounded).
+	writeTimeout time.Duration
+
+	// Time fields with custom formats in the request body and events (nil = none).
+	inputTimes  *timeFormats
+	outputTimes *timeFormats
 }
 
 // Process implements Endpoint.
@@ -141,6 +462,15 @@ func (h *StreamHandler[In, Out]) Process(ctx context.Context, r *http.Request, w
 		HandlerNameKey.Field(h.spec.Name),
 	)
 
+	// An earlier layer (e.g. middleware) already responded; writing again would corrupt the response.
+	if status, committed := committedStatus(w); committed {
+		capitan.Warn(ctx, HandlerResponseCommitted,
+			HandlerNameKey.Field(h.spec.Name),
+			StatusCodeKey.Field(status),
+		)
+		return status, errResponseCommitted
+	}
+
 	// Verify streaming support
 	flusher, ok := w.(http.Flusher)
 	if !ok {
@@ -152,77 +482,37 @@ func (h *StreamHandler[In, Out]) Process(ctx context.Context, r *http.Request, w
 		return http.StatusInternalServerError, errors.New("streaming not supported")
 	}
 
-	// Extract and validate parameters.
-	params, err := extractParams(ctx, r, h.spec.PathParams, h.spec.QueryParams)
-	if err != nil {
-		capitan.Error(ctx, RequestParamsInvalid,
-			HandlerNameKey.Field(h.spec.Name),
-			ErrorKey.Field(err.Error()),
-		)
-		writeError(ctx, w, ErrUnprocessableEntity.WithMessage("invalid parameters").WithCause(err), h.spec.Name)
-		return http.StatusUnprocessableEntity, err
+	// Bound the whole stream's lifetime; the stream's Done channel closes at the deadline.
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
 	}
 
-	// Parse request body (for POST/PUT streams with initial payload).
-	var input In
-	if h.InputMeta.TypeName != noBodyTypeName && r.Body != nil {
-		body, readErr := io.ReadAll(r.Body)
-		if readErr != nil {
-			capitan.Error(ctx, RequestBodyReadError,
-				HandlerNameKey.Field(h.spec.Name),
-				ErrorKey.Field(readErr.Error()),
-			)
-			writeError(ctx, w, ErrBadRequest.WithMessage("failed to read request body").WithCause(readErr), h.spec.Name)
-			return http.StatusBadRequest, readErr
-		}
-		if err := r.Body.Close(); err != nil {
-			capitan.Warn(ctx, RequestBodyCloseError,
-				HandlerNameKey.Field(h.spec.Name),
-				ErrorKey.Field(err.Error()),
-			)
-		}
-
-		if len(body) > 0 {
-			if unmarshalErr := json.Unmarshal(body, &input); unmarshalErr != nil {
-				capitan.Error(ctx, RequestBodyParseError,
-					HandlerNameKey.Field(h.spec.Name),
-					ErrorKey.Field(unmarshalErr.Error()),
-				)
-				writeError(ctx, w, ErrUnprocessableEntity.WithMessage("invalid request body").WithCause(unmarshalErr), h.spec.Name)
-				return http.StatusUnprocessableEntity, unmarshalErr
-			}
-
-			// Validate input.
-			if inputErr := h.validator.Struct(input); inputErr != nil {
-				capitan.Warn(ctx, RequestValidationInputFailed,
-					HandlerNameKey.Field(h.spec.Name),
-					ErrorKey.Field(inputErr.Error()),
-				)
-				writeValidationErrorResponse(ctx, w, inputErr, h.spec.Name)
-				return http.StatusUnprocessableEntity, inputErr
-			}
-		}
+	// End the stream when the engine shuts down: Done closes and the handler can return.
+	if e := engineFromContext(ctx); e != nil && e.streamsCtx != nil {
+		var cancel context.CancelCauseFunc
+		ctx, cancel = context.WithCancelCause(ctx)
+		defer cancel(nil)
+		stop := context.AfterFunc(e.streamsCtx, func() { cancel(errStreamShutdown) })
+		defer stop()
+		r = r.WithContext(ctx)
 	}
 
-	// Extract identity from context if present
-	var identity Identity = NoIdentity{}
-	if val := ctx.Value(identityContextKey); val != nil {
-		if id, ok := val.(Identity); ok {
-			identity = id
-		}
+	// All request validation must complete before SSE headers are written;
+	// once the stream starts, errors can no longer be reported with a status code.
+	req, status, err := h.prepare(ctx, r, w)
+	if err != nil {
+		return status, err
 	}
 
-	// Create Request for callback.
-	req := &Request[In]{
-		Context:  ctx,
-		Request:  r,
-		Params:   params,
-		Body:     input,
-		Identity: identity,
+	// Set streaming headers
+	if h.ndjson {
+		w.Header().Set("Content-Type", ndjsonContentType)
+	} else {
+		w.Header().Set("Content-Type", "text/event-stream")
 	}
-
-	// Set SSE headers
-	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
@@ -234,19 +524,77 @@ func (h *StreamHandler[In, Out]) Process(ctx context.Context, r *http.Request, w
 	)
 
 	// Create stream
+	detailMode := errorDetailModeFrom(ctx)
 	stream := &sseStream[Out]{
-		w:       w,
-		flusher: flusher,
-		done:    ctx.Done(),
+		w:           w,
+		flusher:     flusher,
+		done:        ctx.Done(),
+		detailMode:  detailMode,
+		lastEventID: r.Header.Get(LastEventIDHeader),
+		text:        h.textEvents,
+		ndjson:      h.ndjson,
+		times:       h.outputTimes,
+	}
+	// A Stream kept past the handler's return must not write to a finished response.
+	defer func() {
+		stream.mu.Lock()
+		stream.closed = true
+		stream.mu.Unlock()
+	}()
+	if h.writeTimeout > 0 {
+		stream.controller = http.NewResponseController(w)
+		stream.writeTimeout = h.writeTimeout
+		// Lift the last send's deadline so the response can still be completed.
+		defer func() {
+			if stream.controller != nil {
+				_ = stream.controller.SetWriteDeadline(time.Time{})
+			}
+		}()
+	}
+	if h.validateEvents {
+		stream.validator = h.validator
+	}
+
+	// Keep idle connections open; stopped before Process returns so nothing writes afterwards.
+	if h.keepAlive > 0 {
+		stop := make(chan struct{})
+		stopped := make(chan struct{})
+		go stream.keepAlive(h.keepAlive, stop, stopped)
+		defer func() {
+			close(stop)
+			<-stopped
+		}()
 	}
 
 	// Call user handler (blocks until stream ends)
-	if err := h.fn(req, stream); err != nil {
+	fnStart := time.Now()
+	err = h.fn(req, stream)
+	recordHandlerTime(w, fnStart)
+
+	// The stream outlived its timeout; returning ends the response cleanly.
+	if h.timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		capitan.Warn(context.WithoutCancel(ctx), HandlerTimeout,
+			HandlerNameKey.Field(h.spec.Name),
+			ErrorKey.Field(ctx.Err().Error()),
+		)
+		return http.StatusOK, nil
+	}
+
+	// The engine is shutting down; whatever the handler returned, the stream ended cleanly.
+	// capitan drops events on cancelled contexts, so emit on one that stays live.
+	if errors.Is(context.Cause(ctx), errStreamShutdown) {
+		capitan.Info(context.WithoutCancel(ctx), StreamShutdown,
+			HandlerNameKey.Field(h.spec.Name),
+		)
+		return http.StatusOK, nil
+	}
+
+	if err != nil {
 		// Check if this is a rocco Error.
 		if e := getRoccoError(err); e != nil {
 			capitan.Warn(ctx, StreamError,
 				HandlerNameKey.Field(h.spec.Name),
-				ErrorKey.Field(err.Error()),
+				ErrorKey.Field(errorChain(err)),
 			)
 			// Cannot write error response after headers sent, just log
 			return http.StatusOK, err
@@ -263,7 +611,7 @@ func (h *StreamHandler[In, Out]) Process(ctx context.Context, r *http.Request, w
 		// Unexpected error
 		capitan.Error(ctx, StreamError,
 			HandlerNameKey.Field(h.spec.Name),
-			ErrorKey.Field(err.Error()),
+			ErrorKey.Field(errorChain(err)),
 		)
 		return http.StatusOK, err
 	}
@@ -276,6 +624,74 @@ func (h *StreamHandler[In, Out]) Process(ctx context.Context, r *http.Request, w
 	return http.StatusOK, nil
 }
 
+// prepare extracts parameters, parses and validates the body, and resolves identity.
+// It writes an error response and returns a non-nil error if the request is invalid.
+func (h *StreamHandler[In, Out]) prepare(ctx context.Context, r *http.Request, w http.ResponseWriter) (*Request[In], int, error) {
+	// Extract and validate parameters.
+	params, err := extractParams(ctx, r, &h.spec, h.pathPatterns)
+	if err != nil {
+		capitan.Error(ctx, RequestParamsInvalid,
+			HandlerNameKey.Field(h.spec.Name),
+			ErrorKey.Field(err.Error()),
+		)
+		if errors.Is(err, errPathParamMismatch) {
+			writeError(ctx, w, ErrNotFound.WithCause(err), h.spec.Name)
+			return nil, http.StatusNotFound, err
+		}
+		writeError(ctx, w, ErrUnprocessableEntity.WithMessage("invalid parameters").WithCause(err), h.spec.Name)
+		return nil, http.StatusUnprocessableEntity, err
+	}
+
+	// Parse request body (for POST/PUT streams with initial payload).
+	var input In
+	var provided map[string]json.RawMessage
+	if h.InputMeta.TypeName != noBodyTypeName {
+		if r.Body == nil {
+			r.Body = http.NoBody
+		}
+		// Enforce the limit before reading, so an oversized payload is refused before the stream starts.
+		if h.maxBodySize > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, h.maxBodySize)
+		}
+		var status int
+		input, provided, status, err = decodeJSONInput[In](ctx, r, w, jsonInput{
+			handlerName: h.spec.Name,
+			maxBodySize: h.maxBodySize,
+			inputTimes:  h.inputTimes,
+		})
+		if err != nil {
+			return nil, status, err
+		}
+
+		// Validate input.
+		if inputErr := h.validator.Struct(input); inputErr != nil {
+			capitan.Warn(ctx, RequestValidationInputFailed,
+				HandlerNameKey.Field(h.spec.Name),
+				ErrorKey.Field(inputErr.Error()),
+			)
+			return nil, writeValidationErrorResponse(ctx, w, inputErr, h.spec.Name), inputErr
+		}
+	}
+
+	// Extract identity from context if present
+	var identity Identity = NoIdentity{}
+	if val := ctx.Value(identityContextKey); val != nil {
+		if id, ok := val.(Identity); ok {
+			identity = id
+		}
+	}
+
+	// Create Request for callback.
+	return &Request[In]{
+		Context:  ctx,
+		Request:  r,
+		Params:   params,
+		Body:     input,
+		Identity: identity,
+		provided: provided,
+	}, http.StatusOK, nil
+}
+
 // Spec implements Endpoint.
 func (h *StreamHandler[In, Out]) Spec() HandlerSpec {
 	return h.spec
@@ -286,11 +702,27 @@ func (h *StreamHandler[In, Out]) ErrorDefs() []ErrorDefinition {
 	return h.errorDefs
 }
 
+// InputMetadata implements Endpoint.
+func (h *StreamHandler[In, Out]) InputMetadata() sentinel.Metadata {
+	return h.InputMeta
+}
+
+// OutputMetadata implements Endpoint.
+func (h *StreamHandler[In, Out]) OutputMetadata() sentinel.Metadata {
+	return h.OutputMeta
+}
+
 // Middleware implements Endpoint.
 func (h *StreamHandler[In, Out]) Middleware() []func(http.Handler) http.Handler {
 	return h.middleware
 }
 
+// ScanErrors returns any failures sentinel hit introspecting the input and output types.
+// A non-empty result means the OpenAPI schema for those types will be empty.
+func (h *StreamHandler[In, Out]) ScanErrors() []error {
+	return h.scanErrors
+}
+
 // Close implements Endpoint.
 func (*StreamHandler[In, Out]) Close() error {
 	return nil
@@ -298,8 +730,8 @@ func (*StreamHandler[In, Out]) Close() error {
 
 // NewStreamHandler creates a new typed streaming handler with sentinel metadata.
 func NewStreamHandler[In, Out any](name string, method, path string, fn func(*Request[In], Stream[Out]) error) *StreamHandler[In, Out] {
-	inputMeta := sentinel.Scan[In]()
-	outputMeta := sentinel.Scan[Out]()
+	inputMeta, inputErr := scanType[In]()
+	outputMeta, outputErr := scanType[Out]()
 
 	return &StreamHandler[In, Out]{
 		fn: fn,
@@ -319,14 +751,34 @@ func NewStreamHandler[In, Out any](name string, method, path string, fn func(*Re
 			UsageLimits:    []UsageLimit{},
 			Tags:           []string{},
 			IsStream:       true,
+			MaxBodySize:    defaultMaxBodySize,
 		},
-		InputMeta:  inputMeta,
-		OutputMeta: outputMeta,
-		validator:  validator.New(),
-		middleware: make([]func(http.Handler) http.Handler, 0),
+		InputMeta:   inputMeta,
+		OutputMeta:  outputMeta,
+		scanErrors:  collectScanErrors(inputErr, outputErr),
+		validator:   validator.New(),
+		middleware:  make([]func(http.Handler) http.Handler, 0),
+		maxBodySize: defaultMaxBodySize,
+		inputTimes:  timeFormatsFor[In](),
+		outputTimes: timeFormatsFor[Out](),
 	}
 }
 
+// ndjsonContentType is the media type of NDJSON stream responses.
+const ndjsonContentType = "application/x-ndjson"
+
+// NewNDJSONHandler creates a streaming handler that writes newline-delimited JSON
+// instead of SSE, for clients that read a plain HTTP response line by line. Each event
+// is one compact JSON line, flushed as it is sent. Handler code is portable between
+// the two: the Stream is the same, but event names and ids are dropped, SendError
+// writes the error body as a line, SendComment writes an empty line and SendRaw fails.
+func NewNDJSONHandler[In, Out any](name string, method, path string, fn func(*Request[In], Stream[Out]) error) *StreamHandler[In, Out] {
+	h := NewStreamHandler(name, method, path, fn)
+	h.ndjson = true
+	h.spec.ResponseContentTypes = []string{ndjsonContentType}
+	return h
+}
+
 // WithSummary sets the OpenAPI summary.
 func (h *StreamHandler[In, Out]) WithSummary(summary string) *StreamHandler[In, Out] {
 	h.spec.Summary = summary
@@ -339,24 +791,114 @@ func (h *StreamHandler[In, Out]) WithDescription(desc string) *StreamHandler[In,
 	return h
 }
 
+// WithDescriptionMarkdown sets the OpenAPI description from a markdown block,
+// typically an indented raw string literal. Common indentation and surrounding
+// blank lines are removed so the markdown renders as written.
+func (h *StreamHandler[In, Out]) WithDescriptionMarkdown(markdown string) *StreamHandler[In, Out] {
+	h.spec.Description = dedentMarkdown(markdown)
+	return h
+}
+
+// WithMaxBodySize sets the maximum size in bytes of the initial request body. Larger
+// payloads are refused with 413 before the stream starts. Default: 10MB; 0 means
+// unlimited (not recommended for production).
+func (h *StreamHandler[In, Out]) WithMaxBodySize(size int64) *StreamHandler[In, Out] {
+	h.maxBodySize = size
+	h.spec.MaxBodySize = size
+	return h
+}
+
 // WithTags sets the OpenAPI tags.
 func (h *StreamHandler[In, Out]) WithTags(tags ...string) *StreamHandler[In, Out] {
 	h.spec.Tags = tags
 	return h
 }
 
+// WithDeprecated marks the operation as deprecated and warns on each request.
+func (h *StreamHandler[In, Out]) WithDeprecated() *StreamHandler[In, Out] {
+	h.spec.Deprecated = true
+	return h
+}
+
+// WithServers sets the OpenAPI servers for this operation, overriding the engine-level servers.
+func (h *StreamHandler[In, Out]) WithServers(servers ...openapi.Server) *StreamHandler[In, Out] {
+	h.spec.Servers = servers
+	return h
+}
+
+// WithHost scopes the route to requests whose Host header is host. See Handler.WithHost.
+func (h *StreamHandler[In, Out]) WithHost(host string) *StreamHandler[In, Out] {
+	h.spec.Host = host
+	return h
+}
+
 // WithPathParams specifies required path parameters.
 func (h *StreamHandler[In, Out]) WithPathParams(params ...string) *StreamHandler[In, Out] {
 	h.spec.PathParams = params
 	return h
 }
 
+// WithPathParamStyle documents the OpenAPI serialization style of a path parameter:
+// "simple" (the default), "label" or "matrix". An unsupported style is reported by ScanErrors.
+func (h *StreamHandler[In, Out]) WithPathParamStyle(name, style string) *StreamHandler[In, Out] {
+	if err := setPathParamStyle(&h.spec, name, style); err != nil {
+		h.scanErrors = append(h.scanErrors, err)
+	}
+	return h
+}
+
+// WithPathParamExplode documents whether array and object values of a path parameter are
+// exploded. It only affects the generated spec.
+func (h *StreamHandler[In, Out]) WithPathParamExplode(name string, explode bool) *StreamHandler[In, Out] {
+	setPathParamExplode(&h.spec, name, explode)
+	return h
+}
+
+// WithPathParamPattern constrains a path parameter to values matching the regular expression
+// pattern. Other values answer 404 before the stream starts. The pattern is documented on the
+// parameter's schema; an invalid pattern is reported by ScanErrors.
+func (h *StreamHandler[In, Out]) WithPathParamPattern(name, pattern string) *StreamHandler[In, Out] {
+	if err := setPathParamPattern(&h.spec, &h.pathPatterns, name, pattern); err != nil {
+		h.scanErrors = append(h.scanErrors, err)
+	}
+	return h
+}
+
 // WithQueryParams specifies required query parameters.
 func (h *StreamHandler[In, Out]) WithQueryParams(params ...string) *StreamHandler[In, Out] {
 	h.spec.QueryParams = params
 	return h
 }
 
+// WithQueryParamsMulti declares repeatable query parameters (e.g., ?id=1&id=2&id=3),
+// available as Params.QueryMulti[name] and documented as exploded arrays.
+func (h *StreamHandler[In, Out]) WithQueryParamsMulti(params ...string) *StreamHandler[In, Out] {
+	h.spec.MultiQueryParams = appendUnique(h.spec.MultiQueryParams, params...)
+	return h
+}
+
+// WithHeaderParams declares optional request headers, available as Params.Header
+// and documented as header parameters. Absent headers read as empty strings.
+func (h *StreamHandler[In, Out]) WithHeaderParams(names ...string) *StreamHandler[In, Out] {
+	h.spec.HeaderParams = appendUnique(h.spec.HeaderParams, names...)
+	return h
+}
+
+// WithCookieParams declares request cookies, available as Params.Cookie and documented
+// as cookie parameters. Absent cookies read as empty strings.
+func (h *StreamHandler[In, Out]) WithCookieParams(names ...string) *StreamHandler[In, Out] {
+	h.spec.CookieParams = appendUnique(h.spec.CookieParams, names...)
+	return h
+}
+
+// WithRequiredHeaderParams declares request headers that must be present.
+// Requests missing any of them are rejected with 422 before the handler runs.
+func (h *StreamHandler[In, Out]) WithRequiredHeaderParams(names ...string) *StreamHandler[In, Out] {
+	h.spec.HeaderParams = appendUnique(h.spec.HeaderParams, names...)
+	h.spec.RequiredHeaderParams = appendUnique(h.spec.RequiredHeaderParams, names...)
+	return h
+}
+
 // WithErrors declares which errors this handler may return.
 // Note: Errors can only be returned before the stream starts.
 func (h *StreamHandler[In, Out]) WithErrors(errs ...ErrorDefinition) *StreamHandler[In, Out] {
@@ -367,6 +909,66 @@ func (h *StreamHandler[In, Out]) WithErrors(errs ...ErrorDefinition) *StreamHand
 	return h
 }
 
+// WithTimeout bounds the lifetime of each stream by d. When it passes, the stream's Done
+// channel closes, sends fail, and the response ends cleanly once the handler returns.
+// A zero duration means no timeout, the default.
+func (h *StreamHandler[In, Out]) WithTimeout(d time.Duration) *StreamHandler[In, Out] {
+	h.timeout = d
+	return h
+}
+
+// WithKeepAlive sends a ": keep-alive" comment every interval so intermediaries do not
+// drop idle connections. Comments stop when the handler returns or the client disconnects,
+// and are serialized with the handler's own sends. A zero interval disables them, the default.
+func (h *StreamHandler[In, Out]) WithKeepAlive(interval time.Duration) *StreamHandler[In, Out] {
+	h.keepAlive = interval
+	return h
+}
+
+// WithWriteTimeout bounds how long a single send may block writing to the client, e.g.
+// when a client stops reading and its TCP buffers fill. A send that misses the deadline
+// returns an error wrapping os.ErrDeadlineExceeded and closes the stream, so the handler
+// can return. Writers that do not support deadlines are written to without one. A zero
+// duration disables the deadline, the default.
+func (h *StreamHandler[In, Out]) WithWriteTimeout(d time.Duration) *StreamHandler[In, Out] {
+	h.writeTimeout = d
+	return h
+}
+
+// WithEventValidation enables validation of event data against its struct tags before
+// each send. A failing event is not written: the send returns an error and the stream
+// is closed. Disabled by default; enable in development to catch malformed events.
+func (h *StreamHandler[In, Out]) WithEventValidation() *StreamHandler[In, Out] {
+	h.validateEvents = true
+	return h
+}
+
+// WithTextEncoding writes event data as-is instead of as JSON, e.g. for log tailing.
+// Out must be string or []byte. Data spanning several lines is sent as one data line
+// per line, which clients join back with newlines. SendError events remain JSON.
+// SSE streams only; NDJSON handlers report a scan error.
+func (h *StreamHandler[In, Out]) WithTextEncoding() *StreamHandler[In, Out] {
+	if h.ndjson {
+		h.scanErrors = append(h.scanErrors, errors.New("text encoding: NDJSON records are always JSON"))
+		return h
+	}
+	outputType := reflect.TypeFor[Out]()
+	if outputType != reflect.TypeFor[string]() && outputType != reflect.TypeFor[[]byte]() {
+		h.scanErrors = append(h.scanErrors, fmt.Errorf("text encoding: output type %s must be string or []byte", outputType))
+		return h
+	}
+	h.textEvents = true
+
+	// The output is not a schema type, so sentinel's failure to introspect it is expected.
+	h.spec.OutputTypeName = ""
+	if _, outputErr := scanType[Out](); outputErr != nil {
+		h.scanErrors = slices.DeleteFunc(h.scanErrors, func(err error) bool {
+			return err.Error() == outputErr.Error()
+		})
+	}
+	return h
+}
+
 // WithMiddleware adds middleware to this handler.
 func (h *StreamHandler[In, Out]) WithMiddleware(middleware ...func(http.Handler) http.Handler) *StreamHandler[In, Out] {
 	h.middleware = append(h.middleware, middleware...)
@@ -379,6 +981,18 @@ func (h *StreamHandler[In, Out]) WithAuthentication() *StreamHandler[In, Out] {
 	return h
 }
 
+// WithOptionalAuthentication extracts the caller's identity when present without requiring it.
+func (h *StreamHandler[In, Out]) WithOptionalAuthentication() *StreamHandler[In, Out] {
+	h.spec.OptionalAuth = true
+	return h
+}
+
+// WithSecurityScheme selects the named security scheme documented for this handler.
+func (h *StreamHandler[In, Out]) WithSecurityScheme(name string) *StreamHandler[In, Out] {
+	h.spec.SecurityScheme = name
+	return h
+}
+
 // WithScopes adds a scope requirement group.
 func (h *StreamHandler[In, Out]) WithScopes(scopes ...string) *StreamHandler[In, Out] {
 	if len(scopes) > 0 {
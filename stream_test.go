@@ -6,13 +6,18 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/zoobzio/capitan"
 )
 
 type streamEvent struct {
@@ -381,6 +386,89 @@ func TestStreamHandler_Process_InvalidJSON(t *testing.T) {
 	}
 }
 
+func TestStreamHandler_Process_ProvidedFields(t *testing.T) {
+	var provided, missing bool
+	handler := NewStreamHandler[streamInput, streamEvent](
+		"test-stream",
+		"POST",
+		"/events",
+		func(req *Request[streamInput], _ Stream[streamEvent]) error {
+			provided, missing = req.WasProvided("topic"), req.WasProvided("other")
+			return nil
+		},
+	)
+
+	req := httptest.NewRequest("POST", "/events", strings.NewReader(`{"topic":"news"}`))
+	if status, err := handler.Process(context.Background(), req, newFlushRecorder()); err != nil {
+		t.Fatalf("unexpected error (status %d): %v", status, err)
+	}
+	if !provided || missing {
+		t.Errorf("expected only topic to be provided, got topic=%v other=%v", provided, missing)
+	}
+
+	// Like JSON handlers, data after the document is rejected.
+	req = httptest.NewRequest("POST", "/events", strings.NewReader(`{"topic":"news"} {}`))
+	if status, _ := handler.Process(context.Background(), req, newFlushRecorder()); status != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422 for trailing data, got %d", status)
+	}
+}
+
+func TestStreamHandler_Process_EmptyBody(t *testing.T) {
+	handler := NewStreamHandler[streamInput, streamEvent](
+		"test-stream",
+		"POST",
+		"/events",
+		func(_ *Request[streamInput], _ Stream[streamEvent]) error {
+			t.Error("handler should not be called without a body")
+			return nil
+		},
+	)
+
+	req := httptest.NewRequest("POST", "/events", nil)
+	w := newFlushRecorder()
+
+	status, err := handler.Process(context.Background(), req, w)
+	if err == nil {
+		t.Error("expected missing body error")
+	}
+	if status != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", status)
+	}
+}
+
+func TestStreamHandler_Process_MaxBodySizeExceeded(t *testing.T) {
+	handler := NewStreamHandler[streamInput, streamEvent](
+		"test-stream",
+		"POST",
+		"/events",
+		func(_ *Request[streamInput], _ Stream[streamEvent]) error {
+			t.Error("handler should not be called with an oversized body")
+			return nil
+		},
+	)
+	if handler.Spec().MaxBodySize != defaultMaxBodySize {
+		t.Errorf("expected default limit %d, got %d", defaultMaxBodySize, handler.Spec().MaxBodySize)
+	}
+	handler.WithMaxBodySize(10)
+
+	req := httptest.NewRequest("POST", "/events", strings.NewReader(`{"topic":"a much longer topic"}`))
+	w := newFlushRecorder()
+
+	status, err := handler.Process(context.Background(), req, w)
+	if err == nil {
+		t.Error("expected body size error")
+	}
+	if status != http.StatusRequestEntityTooLarge || w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status 413, got %d (written %d)", status, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); strings.HasPrefix(ct, "text/event-stream") {
+		t.Error("expected the error before the stream started")
+	}
+	if !strings.Contains(w.Body.String(), `"PAYLOAD_TOO_LARGE"`) {
+		t.Errorf("expected PAYLOAD_TOO_LARGE body, got %s", w.Body.String())
+	}
+}
+
 func TestStreamHandler_Process_MissingPathParam(t *testing.T) {
 	handler := NewStreamHandler[NoBody, streamEvent](
 		"test-stream",
@@ -871,3 +959,567 @@ func TestStreamHandler_Process_WithIdentityInContext(t *testing.T) {
 	}
 }
 
+
+func TestStreamHandler_Process_InvalidRequestNoSSEHeaders(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *http.Request
+	}{
+		{"missing path param", httptest.NewRequest("POST", "/events/", strings.NewReader(`{"topic":"a"}`))},
+		{"invalid body", httptest.NewRequest("POST", "/events/", strings.NewReader(`{invalid}`))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewStreamHandler[streamInput, streamEvent](
+				"test-stream",
+				"POST",
+				"/events/{topic}",
+				func(_ *Request[streamInput], _ Stream[streamEvent]) error {
+					t.Error("handler should not be called on invalid request")
+					return nil
+				},
+			)
+			if tt.name == "missing path param" {
+				handler.WithPathParams("topic")
+			}
+
+			w := newFlushRecorder()
+			status, err := handler.Process(context.Background(), tt.req, w)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if status != http.StatusUnprocessableEntity {
+				t.Errorf("expected status 422, got %d", status)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("expected JSON error response before stream start, got Content-Type %q", ct)
+			}
+			if w.flushed > 0 {
+				t.Error("expected no flush before stream start")
+			}
+		})
+	}
+}
+
+func TestStreamHandler_MethodNotAllowed(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(
+		NewStreamHandler[NoBody, streamEvent](
+			"events",
+			"GET",
+			"/events",
+			func(_ *Request[NoBody], _ Stream[streamEvent]) error { return nil },
+		),
+		NewHandler[NoBody, testOutput](
+			"items",
+			"GET",
+			"/items",
+			func(_ *Request[NoBody]) (testOutput, error) { return testOutput{}, nil },
+		),
+	)
+
+	// Stream and regular endpoints share the router, so wrong-method requests are answered identically.
+	responses := make(map[string]*httptest.ResponseRecorder)
+	for _, path := range []string{"/events", "/items"} {
+		w := httptest.NewRecorder()
+		engine.Router().ServeHTTP(w, httptest.NewRequest("DELETE", path, nil))
+		responses[path] = w
+
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s: expected 405, got %d", path, w.Code)
+		}
+		if allow := w.Header().Get("Allow"); !strings.Contains(allow, "GET") {
+			t.Errorf("%s: expected Allow header listing GET, got %q", path, allow)
+		}
+	}
+
+	if a, b := responses["/events"].Header().Get("Allow"), responses["/items"].Header().Get("Allow"); a != b {
+		t.Errorf("expected matching Allow headers, got %q (stream) and %q (handler)", a, b)
+	}
+}
+
+func TestStreamHandler_WithTimeout(t *testing.T) {
+	sent := 0
+	handler := NewStreamHandler[NoBody, streamEvent](
+		"ticker",
+		"GET",
+		"/ticker",
+		func(req *Request[NoBody], stream Stream[streamEvent]) error {
+			if _, ok := req.Context.Deadline(); !ok {
+				t.Error("expected request context to carry the stream deadline")
+			}
+			ticker := time.NewTicker(5 * time.Millisecond)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-stream.Done():
+					return nil
+				case <-ticker.C:
+					if err := stream.Send(streamEvent{Count: sent}); err != nil {
+						return err
+					}
+					sent++
+				}
+			}
+		},
+	).WithTimeout(30 * time.Millisecond)
+
+	w := newFlushRecorder()
+	start := time.Now()
+	status, err := handler.Process(context.Background(), httptest.NewRequest("GET", "/ticker", nil), w)
+	if err != nil {
+		t.Fatalf("expected the stream to end cleanly, got %v", err)
+	}
+	if status != http.StatusOK {
+		t.Errorf("expected 200, got %d", status)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("stream was not bounded by its timeout (ran %v)", elapsed)
+	}
+	if got := len(parseSSEEvents(w.Body.String())); got != sent || got == 0 {
+		t.Errorf("expected %d complete events before the deadline, got %d", sent, got)
+	}
+}
+
+func TestStreamHandler_WithEventValidation(t *testing.T) {
+	var sendErr, afterErr error
+	handler := NewStreamHandler[NoBody, streamInput](
+		"validated-stream",
+		"GET",
+		"/events",
+		func(_ *Request[NoBody], stream Stream[streamInput]) error {
+			if err := stream.Send(streamInput{Topic: "ok"}); err != nil {
+				return err
+			}
+			sendErr = stream.Send(streamInput{})
+			afterErr = stream.Send(streamInput{Topic: "later"})
+			return sendErr
+		},
+	).WithEventValidation()
+
+	w := newFlushRecorder()
+	_, err := handler.Process(context.Background(), httptest.NewRequest("GET", "/events", nil), w)
+	if err == nil || !strings.Contains(sendErr.Error(), "event validation failed") {
+		t.Fatalf("expected event validation error, got %v", sendErr)
+	}
+	if afterErr == nil {
+		t.Error("expected sends after a validation failure to fail")
+	}
+	if events := parseSSEEvents(w.Body.String()); len(events) != 1 {
+		t.Errorf("expected only the valid event to be written, got %d", len(events))
+	}
+
+	// Without the option, invalid events are sent as-is.
+	unvalidated := &sseStream[streamInput]{w: newFlushRecorder(), flusher: newFlushRecorder(), done: make(chan struct{})}
+	if err := unvalidated.Send(streamInput{}); err != nil {
+		t.Errorf("expected no validation by default, got %v", err)
+	}
+}
+
+func TestStream_SendError(t *testing.T) {
+	w := newFlushRecorder()
+	done := make(chan struct{})
+	stream := &sseStream[streamEvent]{w: w, flusher: w, done: done, detailMode: DetailsProd}
+
+	if err := stream.SendError("", ErrNotFound.WithMessage("item 3 not found")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := stream.SendError("item-error", errors.New("db timeout")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := stream.Send(streamEvent{Message: "still open"}); err != nil {
+		t.Errorf("expected the stream to continue after SendError, got %v", err)
+	}
+
+	body := w.Body.String()
+	if !strings.Contains(body, "event: error\ndata: {\"code\":\"NOT_FOUND\",\"message\":\"item 3 not found\",\"details\":{}}\n\n") {
+		t.Errorf("expected a default error event with the error body, got %q", body)
+	}
+	if !strings.Contains(body, "event: item-error\ndata: {\"code\":\"INTERNAL_SERVER_ERROR\",\"message\":\"internal server error\"}\n\n") {
+		t.Errorf("expected an unexposed internal error event, got %q", body)
+	}
+
+	close(done)
+	if err := stream.SendError("", ErrNotFound); err == nil {
+		t.Error("expected error after client disconnect")
+	}
+}
+
+func TestStream_SendRaw(t *testing.T) {
+	w := newFlushRecorder()
+	stream := &sseStream[streamEvent]{w: w, flusher: w, done: make(chan struct{})}
+
+	err := stream.SendRaw(map[string]string{
+		"data":  "line one\nline two\r\nline three",
+		"x-tag": "beta",
+		"retry": "5000",
+		"id":    "42",
+		"event": "update",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "event: update\nid: 42\nretry: 5000\nx-tag: beta\ndata: line one\ndata: line two\ndata: line three\n\n"
+	if w.Body.String() != want {
+		t.Errorf("expected frame %q, got %q", want, w.Body.String())
+	}
+	if w.flushed != 1 {
+		t.Errorf("expected 1 flush, got %d", w.flushed)
+	}
+
+	invalid := []map[string]string{
+		{},
+		{"": "x"},
+		{"a:b": "x"},
+		{"id": "1\ndata: injected"},
+	}
+	for _, fields := range invalid {
+		if err := stream.SendRaw(fields); err == nil {
+			t.Errorf("expected error for fields %q", fields)
+		}
+	}
+	if err := stream.SendEvent("bad\nname", streamEvent{}); err == nil {
+		t.Error("expected error for an event name with a line break")
+	}
+	if w.Body.String() != want {
+		t.Errorf("expected invalid frames not to be written, got %q", w.Body.String())
+	}
+	if err := stream.Send(streamEvent{Message: "after"}); err != nil {
+		t.Errorf("expected the stream to stay open, got %v", err)
+	}
+}
+
+func TestStreamHandler_EngineShutdown(t *testing.T) {
+	setupSyncMode(t)
+
+	shutdownEvents := make(chan struct{}, 1)
+	listener := capitan.Hook(StreamShutdown, func(context.Context, *capitan.Event) {
+		shutdownEvents <- struct{}{}
+	})
+	defer listener.Close()
+
+	returned := make(chan struct{})
+	listening := make(chan string, 1)
+	engine := NewEngine("localhost", 0, nil).WithOnListening(func(addr string) { listening <- addr })
+	engine.WithHandlers(NewStreamHandler[NoBody, streamEvent](
+		"events",
+		"GET",
+		"/events",
+		func(_ *Request[NoBody], stream Stream[streamEvent]) error {
+			defer close(returned)
+			if err := stream.Send(streamEvent{Message: "hello"}); err != nil {
+				return err
+			}
+			<-stream.Done()
+			return stream.Send(streamEvent{Message: "too late"})
+		},
+	))
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- engine.Start()
+	}()
+	var addr string
+	select {
+	case addr = <-listening:
+	case err := <-serverErr:
+		t.Fatalf("server failed to start: %v", err)
+	}
+
+	resp, err := http.Get("http://" + addr + "/events")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if _, err := bufio.NewReader(resp.Body).ReadString('\n'); err != nil {
+		t.Fatalf("expected the first event, got %v", err)
+	}
+
+	// The client stays connected; shutdown alone must end the stream.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := engine.Shutdown(ctx); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected shutdown to end the stream promptly, took %v", elapsed)
+	}
+
+	select {
+	case <-returned:
+	default:
+		t.Error("expected the stream handler to have returned")
+	}
+	select {
+	case <-shutdownEvents:
+	default:
+		t.Error("expected a StreamShutdown event")
+	}
+}
+
+func TestStreamHandler_WithKeepAlive(t *testing.T) {
+	handler := NewStreamHandler[NoBody, streamEvent](
+		"idle-stream",
+		"GET",
+		"/events",
+		func(_ *Request[NoBody], stream Stream[streamEvent]) error {
+			time.Sleep(35 * time.Millisecond)
+			if err := stream.Send(streamEvent{Message: "update"}); err != nil {
+				return err
+			}
+			time.Sleep(35 * time.Millisecond)
+			return nil
+		},
+	).WithKeepAlive(10 * time.Millisecond)
+
+	w := newFlushRecorder()
+	if _, err := handler.Process(context.Background(), httptest.NewRequest("GET", "/events", nil), w); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body := w.Body.String()
+	if n := strings.Count(body, ": keep-alive\n\n"); n < 3 {
+		t.Errorf("expected several keep-alive comments, got %d in %q", n, body)
+	}
+	if !strings.Contains(body, "data: {\"message\":\"update\",\"count\":0}\n\n") {
+		t.Errorf("expected the event frame intact between comments, got %q", body)
+	}
+
+	// The ticker stops with the handler; nothing is written after Process returns.
+	time.Sleep(30 * time.Millisecond)
+	if w.Body.String() != body {
+		t.Error("expected no keep-alive comments after the handler returned")
+	}
+}
+
+func TestStream_SendEventWithID(t *testing.T) {
+	w := newFlushRecorder()
+	stream := &sseStream[streamEvent]{w: w, flusher: w, done: make(chan struct{}), lastEventID: "7"}
+
+	if got := stream.LastEventID(); got != "7" {
+		t.Errorf("expected last event id 7, got %q", got)
+	}
+	if err := stream.SendEventWithID("8", "update", streamEvent{Message: "next", Count: 8}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := stream.SendEventWithID("9", "", streamEvent{Message: "unnamed"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "event: update\nid: 8\ndata: {\"message\":\"next\",\"count\":8}\n\n" +
+		"id: 9\ndata: {\"message\":\"unnamed\",\"count\":0}\n\n"
+	if w.Body.String() != want {
+		t.Errorf("expected %q, got %q", want, w.Body.String())
+	}
+
+	for _, id := range []string{"1\ndata: injected", "1\r", "1\x00"} {
+		if err := stream.SendEventWithID(id, "update", streamEvent{}); err == nil {
+			t.Errorf("expected error for id %q", id)
+		}
+	}
+	if w.Body.String() != want {
+		t.Errorf("expected invalid ids not to be written, got %q", w.Body.String())
+	}
+}
+
+func TestStreamHandler_WithTextEncoding(t *testing.T) {
+	engine := newTestEngine()
+	handler := NewStreamHandler[NoBody, string](
+		"logs",
+		"GET",
+		"/logs",
+		func(_ *Request[NoBody], stream Stream[string]) error {
+			if err := stream.Send("started"); err != nil {
+				return err
+			}
+			if err := stream.SendEvent("trace", "panic: boom\r\ngoroutine 1\nmain.go:12"); err != nil {
+				return err
+			}
+			return stream.SendError("", ErrNotFound)
+		},
+	).WithTextEncoding()
+	engine.WithHandlers(handler)
+
+	w := newFlushRecorder()
+	engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/logs", nil))
+
+	want := "data: started\n\n" +
+		"event: trace\ndata: panic: boom\ndata: goroutine 1\ndata: main.go:12\n\n" +
+		"event: error\ndata: {\"code\":\"NOT_FOUND\",\"message\":\"not found\",\"details\":{}}\n\n"
+	if w.Body.String() != want {
+		t.Errorf("expected %q, got %q", want, w.Body.String())
+	}
+	if len(handler.ScanErrors()) != 0 {
+		t.Errorf("expected no scan errors, got %v", handler.ScanErrors())
+	}
+	if err := engine.ValidateSpec(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	bytesOutput := NewStreamHandler[NoBody, []byte]("raw", "GET", "/raw", func(_ *Request[NoBody], stream Stream[[]byte]) error {
+		return stream.Send([]byte("a\nb"))
+	}).WithTextEncoding()
+	w = newFlushRecorder()
+	bytesEngine := newTestEngine()
+	bytesEngine.WithHandlers(bytesOutput)
+	bytesEngine.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/raw", nil))
+	if w.Body.String() != "data: a\ndata: b\n\n" {
+		t.Errorf("expected []byte data to be written as text, got %q", w.Body.String())
+	}
+
+	structOutput := NewStreamHandler[NoBody, streamEvent]("events", "GET", "/events", func(_ *Request[NoBody], _ Stream[streamEvent]) error {
+		return nil
+	}).WithTextEncoding()
+	if len(structOutput.ScanErrors()) != 1 || structOutput.textEvents {
+		t.Errorf("expected a scan error for a struct output type, got %v", structOutput.ScanErrors())
+	}
+}
+
+func TestNDJSONHandler(t *testing.T) {
+	engine := newTestEngine()
+	handler := NewNDJSONHandler[NoBody, streamEvent](
+		"export",
+		"GET",
+		"/export",
+		func(_ *Request[NoBody], stream Stream[streamEvent]) error {
+			if err := stream.Send(streamEvent{Message: "first", Count: 1}); err != nil {
+				return err
+			}
+			if err := stream.SendEventWithID("2", "update", streamEvent{Message: "multi\nline", Count: 2}); err != nil {
+				return err
+			}
+			if err := stream.SendComment("keep-alive"); err != nil {
+				return err
+			}
+			if err := stream.SendRaw(map[string]string{"data": "x"}); err == nil {
+				t.Error("expected SendRaw to fail on an NDJSON stream")
+			}
+			return stream.SendError("", ErrNotFound)
+		},
+	)
+	engine.WithHandlers(handler)
+
+	w := newFlushRecorder()
+	engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/export", nil))
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("expected Content-Type application/x-ndjson, got %q", ct)
+	}
+	want := "{\"message\":\"first\",\"count\":1}\n" +
+		"{\"message\":\"multi\\nline\",\"count\":2}\n" +
+		"\n" +
+		"{\"code\":\"NOT_FOUND\",\"message\":\"not found\",\"details\":{}}\n"
+	if w.Body.String() != want {
+		t.Errorf("expected %q, got %q", want, w.Body.String())
+	}
+	if w.flushed < 4 {
+		t.Errorf("expected a flush per record, got %d", w.flushed)
+	}
+
+	spec := engine.GenerateOpenAPI(nil)
+	response := spec.Paths["/export"].Get.Responses["200"]
+	media, ok := response.Content["application/x-ndjson"]
+	if !ok || len(response.Content) != 1 {
+		t.Fatalf("expected only application/x-ndjson content, got %v", response.Content)
+	}
+	if media.Schema == nil || media.Schema.Ref != "#/components/schemas/streamEvent" {
+		t.Errorf("expected the record schema, got %+v", media.Schema)
+	}
+
+	textOutput := NewNDJSONHandler[NoBody, string]("lines", "GET", "/lines", func(_ *Request[NoBody], _ Stream[string]) error {
+		return nil
+	}).WithTextEncoding()
+	if !strings.Contains(fmt.Sprint(textOutput.ScanErrors()), "NDJSON") || textOutput.textEvents {
+		t.Errorf("expected a scan error for text encoding on NDJSON, got %v", textOutput.ScanErrors())
+	}
+}
+
+func TestStreamHandler_WithWriteTimeout(t *testing.T) {
+	sendErr := make(chan error, 1)
+	engine := newTestEngine()
+	engine.WithHandlers(NewStreamHandler[NoBody, streamEvent](
+		"firehose",
+		"GET",
+		"/firehose",
+		func(_ *Request[NoBody], stream Stream[streamEvent]) error {
+			payload := streamEvent{Message: strings.Repeat("x", 64<<10)}
+			for i := 0; i < 10000; i++ {
+				if err := stream.Send(payload); err != nil {
+					sendErr <- err
+					return err
+				}
+			}
+			sendErr <- nil
+			return nil
+		},
+	).WithWriteTimeout(50 * time.Millisecond))
+
+	server := httptest.NewServer(engine.Handler())
+	defer server.Close()
+
+	// A client that sends the request and never reads the response.
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("GET /firehose HTTP/1.1\r\nHost: localhost\r\n\r\n")); err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+
+	select {
+	case err := <-sendErr:
+		if !errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Errorf("expected a write deadline error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a send to a stalled client to time out")
+	}
+}
+
+func TestStreamHandler_WithWriteTimeout_Unsupported(t *testing.T) {
+	engine := newTestEngine()
+	engine.WithHandlers(NewStreamHandler[NoBody, streamEvent](
+		"events",
+		"GET",
+		"/events",
+		func(_ *Request[NoBody], stream Stream[streamEvent]) error {
+			return stream.Send(streamEvent{Message: "hello"})
+		},
+	).WithWriteTimeout(time.Second))
+
+	w := newFlushRecorder()
+	engine.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/events", nil))
+
+	if !strings.Contains(w.Body.String(), `"hello"`) || w.flushed == 0 {
+		t.Errorf("expected the event to be written and flushed without deadlines, got %q", w.Body.String())
+	}
+}
+
+func TestStreamHandler_Process_SendAfterReturn(t *testing.T) {
+	var kept Stream[streamEvent]
+	handler := NewStreamHandler[NoBody, streamEvent](
+		"kept-stream",
+		"GET",
+		"/events",
+		func(_ *Request[NoBody], stream Stream[streamEvent]) error {
+			kept = stream
+			return nil
+		},
+	)
+
+	w := newFlushRecorder()
+	if _, err := handler.Process(context.Background(), httptest.NewRequest("GET", "/events", nil), w); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	written := w.Body.Len()
+
+	if err := kept.Send(streamEvent{Message: "late"}); err == nil {
+		t.Error("expected a send after the handler returned to fail")
+	}
+	if w.Body.Len() != written {
+		t.Errorf("expected nothing written after the handler returned, got %q", w.Body.String())
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/zoobzio/rocco"
 )
 
@@ -70,6 +72,56 @@ func BenchmarkHandler_NoBody(b *testing.B) {
 	}
 }
 
+// BenchmarkHandler_NoBodyFastPath compares the GET + NoBody fast path with the
+// standard Process path for an otherwise identical handler. Requests go through the
+// engine's full handler chain, so the engine's own layers count towards allocs/op;
+// EngineSettings should stay level with FastPath however many settings are enabled.
+func BenchmarkHandler_NoBodyFastPath(b *testing.B) {
+	cases := []struct {
+		name      string
+		method    string
+		configure func(*rocco.Engine)
+	}{
+		{"FastPath", "GET", nil},
+		{"Standard", "DELETE", nil}, // Not eligible: only GET handlers take the fast path.
+		{"EngineSettings", "GET", func(e *rocco.Engine) {
+			e.WithErrorDetailMode(rocco.DetailsProd).
+				WithValidationErrorFormatter(func(validator.ValidationErrors) rocco.ErrorDefinition {
+					return rocco.ErrValidationFailed
+				})
+		}},
+	}
+
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			engine := newBenchmarkEngine()
+			if tc.configure != nil {
+				tc.configure(engine)
+			}
+			handler := rocco.NewHandler[rocco.NoBody, simpleOutput](
+				"no-body",
+				tc.method,
+				"/test",
+				func(_ *rocco.Request[rocco.NoBody]) (simpleOutput, error) {
+					return simpleOutput{Message: "hello"}, nil
+				},
+			)
+			engine.WithHandlers(handler)
+			server := engine.Handler()
+
+			req := httptest.NewRequest(tc.method, "/test", nil)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				w := httptest.NewRecorder()
+				server.ServeHTTP(w, req)
+			}
+		})
+	}
+}
+
 // BenchmarkHandler_SimpleBody benchmarks handlers with simple JSON body.
 func BenchmarkHandler_SimpleBody(b *testing.B) {
 	engine := newBenchmarkEngine()
@@ -138,6 +190,34 @@ func BenchmarkHandler_ComplexBody(b *testing.B) {
 	}
 }
 
+// BenchmarkHandler_Parallel benchmarks concurrent requests to one handler, where
+// per-handler Request pooling is most effective.
+func BenchmarkHandler_Parallel(b *testing.B) {
+	engine := newBenchmarkEngine()
+	handler := rocco.NewHandler[simpleInput, simpleOutput](
+		"parallel",
+		"POST",
+		"/test",
+		func(req *rocco.Request[simpleInput]) (simpleOutput, error) {
+			return simpleOutput{Message: "hello " + req.Body.Name}, nil
+		},
+	)
+	engine.WithHandlers(handler)
+
+	body, _ := json.Marshal(simpleInput{Name: "test"})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			req := httptest.NewRequest("POST", "/test", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			engine.Router().ServeHTTP(w, req)
+		}
+	})
+}
+
 // BenchmarkHandler_PathParams benchmarks handlers with path parameters.
 func BenchmarkHandler_PathParams(b *testing.B) {
 	engine := newBenchmarkEngine()
@@ -290,6 +370,49 @@ func BenchmarkHandler_BodySizes(b *testing.B) {
 	}
 }
 
+// BenchmarkHandler_BodyContentLength compares body reads with a declared
+// Content-Length (buffer sized up front) against chunked bodies of unknown length.
+func BenchmarkHandler_BodyContentLength(b *testing.B) {
+	engine := newBenchmarkEngine()
+	handler := rocco.NewHandler[complexInput, simpleOutput](
+		"content-length",
+		"POST",
+		"/test",
+		func(_ *rocco.Request[complexInput]) (simpleOutput, error) {
+			return simpleOutput{Message: "ok"}, nil
+		},
+	)
+	engine.WithHandlers(handler)
+
+	input := complexInput{Name: "test", Email: "test@example.com", Age: 30}
+	for i := 0; i < 10; i++ {
+		input.Tags = append(input.Tags, strings.Repeat("t", 1000))
+	}
+	body, _ := json.Marshal(input)
+
+	cases := []struct {
+		name          string
+		contentLength int64
+	}{
+		{"Known", int64(len(body))},
+		{"Unknown", -1},
+	}
+
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				req := httptest.NewRequest("POST", "/test", bytes.NewReader(body))
+				req.ContentLength = tc.contentLength
+				w := httptest.NewRecorder()
+				engine.Router().ServeHTTP(w, req)
+			}
+		})
+	}
+}
+
 // BenchmarkHandler_ValidationComplexity benchmarks validation with varying complexity.
 func BenchmarkHandler_ValidationComplexity(b *testing.B) {
 	b.Run("NoValidation", func(b *testing.B) {
@@ -378,3 +501,66 @@ func BenchmarkHandler_ValidationComplexity(b *testing.B) {
 		}
 	})
 }
+
+// discardResponseWriter drops the body so benchmarks measure the handler's own
+// allocations rather than a recorder's buffer growth.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (d *discardResponseWriter) Header() http.Header         { return d.header }
+func (d *discardResponseWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (d *discardResponseWriter) WriteHeader(int)             {}
+
+// BenchmarkHandler_ResponseSizes measures encoding JSON responses of increasing size.
+func BenchmarkHandler_ResponseSizes(b *testing.B) {
+	type largeOutput struct {
+		Items []complexOutput `json:"items"`
+	}
+
+	sizes := []struct {
+		name  string
+		items int
+	}{
+		{"1", 1},
+		{"10", 10},
+		{"100", 100},
+		{"1000", 1000},
+	}
+
+	for _, size := range sizes {
+		b.Run(size.name, func(b *testing.B) {
+			output := largeOutput{Items: make([]complexOutput, size.items)}
+			for i := range output.Items {
+				output.Items[i] = complexOutput{
+					ID:        "usr_123",
+					Name:      "Jo Example",
+					Email:     "jo@example.com",
+					Tags:      []string{"a", "b"},
+					CreatedAt: "2024-01-15T10:30:00Z",
+				}
+			}
+
+			engine := newBenchmarkEngine()
+			engine.WithHandlers(rocco.NewHandler[rocco.NoBody, largeOutput](
+				"list",
+				"GET",
+				"/items",
+				func(_ *rocco.Request[rocco.NoBody]) (largeOutput, error) {
+					return output, nil
+				},
+			))
+
+			req := httptest.NewRequest("GET", "/items", nil)
+			w := &discardResponseWriter{header: make(http.Header)}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				clear(w.header)
+				engine.Handler().ServeHTTP(w, req)
+			}
+		})
+	}
+}
@@ -127,7 +127,7 @@ func ServeRequest(engine *rocco.Engine, method, path string, body any) *Response
 	req := builder.Build()
 
 	capture := NewResponseCapture()
-	engine.Router().ServeHTTP(capture, req)
+	engine.Handler().ServeHTTP(capture, req)
 	return capture
 }
 
@@ -143,7 +143,7 @@ func ServeRequestWithHeaders(engine *rocco.Engine, method, path string, body any
 	req := builder.Build()
 
 	capture := NewResponseCapture()
-	engine.Router().ServeHTTP(capture, req)
+	engine.Handler().ServeHTTP(capture, req)
 	return capture
 }
 
@@ -425,7 +425,7 @@ func ServeStream(engine *rocco.Engine, method, path string, body any) *StreamCap
 	req := builder.Build()
 
 	capture := NewStreamCapture()
-	engine.Router().ServeHTTP(capture, req)
+	engine.Handler().ServeHTTP(capture, req)
 	return capture
 }
 
@@ -438,7 +438,7 @@ func ServeStreamWithContext(ctx context.Context, engine *rocco.Engine, method, p
 	req := builder.Build()
 
 	capture := NewStreamCapture()
-	engine.Router().ServeHTTP(capture, req)
+	engine.Handler().ServeHTTP(capture, req)
 	return capture
 }
 
@@ -454,7 +454,7 @@ func ServeStreamWithHeaders(engine *rocco.Engine, method, path string, body any,
 	req := builder.Build()
 
 	capture := NewStreamCapture()
-	engine.Router().ServeHTTP(capture, req)
+	engine.Handler().ServeHTTP(capture, req)
 	return capture
 }
 
@@ -669,3 +669,46 @@ func TestRealWorld_QueryParameters(t *testing.T) {
 		})
 	}
 }
+
+type orderProduct struct {
+	SKU  string `json:"sku"`
+	Name string `json:"name"`
+}
+
+type orderLine struct {
+	Product  orderProduct `json:"product"`
+	Quantity int          `json:"quantity"`
+}
+
+type orderOutput struct {
+	ID    string               `json:"id"`
+	Lines []orderLine          `json:"lines"`
+	Gifts map[string]orderLine `json:"gifts,omitempty"`
+}
+
+// TestRealWorld_NestedResponseSchemas tests that nested response types are fully defined in the spec.
+func TestRealWorld_NestedResponseSchemas(t *testing.T) {
+	engine := rocco.NewEngine("localhost", 0, nil)
+	engine.WithHandlers(rocco.NewHandler[rocco.NoBody, orderOutput](
+		"get-order",
+		"GET",
+		"/orders/{id}",
+		func(req *rocco.Request[rocco.NoBody]) (orderOutput, error) {
+			return orderOutput{ID: req.Params.Path["id"]}, nil
+		},
+	).WithPathParams("id"))
+
+	if err := engine.ValidateSpec(); err != nil {
+		t.Fatalf("expected a self-consistent spec, got %v", err)
+	}
+
+	schemas := engine.GenerateOpenAPI(nil).Components.Schemas
+	for _, name := range []string{"orderOutput", "orderLine", "orderProduct"} {
+		if _, ok := schemas[name]; !ok {
+			t.Errorf("expected component schema %q", name)
+		}
+	}
+	if ref := schemas["orderLine"].Properties["product"].Ref; ref != "#/components/schemas/orderProduct" {
+		t.Errorf("expected orderLine.product to reference orderProduct, got %q", ref)
+	}
+}
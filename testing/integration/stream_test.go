@@ -3,6 +3,8 @@ package integration
 import (
 	"context"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -135,6 +137,92 @@ func TestStreamHandler_WithInputBody(t *testing.T) {
 	}
 }
 
+func TestStreamHandler_MethodsWithBody(t *testing.T) {
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch} {
+		t.Run(method, func(t *testing.T) {
+			engine := rtesting.TestEngine()
+
+			handler := rocco.NewStreamHandler[streamInput, streamEvent](
+				"method-stream",
+				method,
+				"/events",
+				func(req *rocco.Request[streamInput], stream rocco.Stream[streamEvent]) error {
+					return stream.Send(streamEvent{Message: req.Body.Topic})
+				},
+			)
+			engine.WithHandlers(handler)
+
+			capture := rtesting.ServeStream(engine, method, "/events", streamInput{Topic: "news"})
+
+			rtesting.AssertSSE(t, capture)
+
+			events := capture.ParseEvents()
+			if len(events) != 1 {
+				t.Fatalf("expected 1 event, got %d", len(events))
+			}
+			var first streamEvent
+			events[0].DecodeJSON(&first)
+			if first.Message != "news" {
+				t.Errorf("expected message 'news', got %q", first.Message)
+			}
+		})
+	}
+}
+
+func TestStreamHandler_MultipleMethods(t *testing.T) {
+	engine := rtesting.TestEngine()
+
+	send := func(stream rocco.Stream[streamEvent], topic string) error {
+		return stream.Send(streamEvent{Message: topic})
+	}
+
+	// One handler per method on the same path; GET takes a query param, POST a body.
+	getHandler := rocco.NewStreamHandler[rocco.NoBody, streamEvent](
+		"events-get",
+		http.MethodGet,
+		"/events",
+		func(req *rocco.Request[rocco.NoBody], stream rocco.Stream[streamEvent]) error {
+			return send(stream, req.Params.Query["topic"])
+		},
+	).WithQueryParams("topic")
+
+	postHandler := rocco.NewStreamHandler[streamInput, streamEvent](
+		"events-post",
+		http.MethodPost,
+		"/events",
+		func(req *rocco.Request[streamInput], stream rocco.Stream[streamEvent]) error {
+			return send(stream, req.Body.Topic)
+		},
+	)
+
+	engine.WithHandlers(getHandler, postHandler)
+
+	getCapture := rtesting.ServeStream(engine, "GET", "/events?topic=sports", nil)
+	rtesting.AssertSSE(t, getCapture)
+
+	postCapture := rtesting.ServeStream(engine, "POST", "/events", streamInput{Topic: "news"})
+	rtesting.AssertSSE(t, postCapture)
+
+	var got streamEvent
+	getCapture.ParseEvents()[0].DecodeJSON(&got)
+	if got.Message != "sports" {
+		t.Errorf("expected GET message 'sports', got %q", got.Message)
+	}
+	postCapture.ParseEvents()[0].DecodeJSON(&got)
+	if got.Message != "news" {
+		t.Errorf("expected POST message 'news', got %q", got.Message)
+	}
+
+	// Methods are matched strictly; unregistered methods are rejected by the router.
+	putCapture := rtesting.ServeStream(engine, "PUT", "/events", streamInput{Topic: "news"})
+	if putCapture.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405 for unregistered method, got %d", putCapture.Code)
+	}
+	if allow := putCapture.Header().Get("Allow"); !strings.Contains(allow, "GET") || !strings.Contains(allow, "POST") {
+		t.Errorf("expected Allow header listing GET and POST, got %q", allow)
+	}
+}
+
 func TestStreamHandler_ConcurrentClients(t *testing.T) {
 	engine := rtesting.TestEngine()
 
@@ -421,3 +509,59 @@ func splitLines(s string) []string {
 	}
 	return lines
 }
+
+func TestStreamHandler_ResumeFromLastEventID(t *testing.T) {
+	engine := rtesting.TestEngine()
+
+	handler := rocco.NewStreamHandler[rocco.NoBody, streamEvent](
+		"resumable",
+		http.MethodGet,
+		"/events",
+		func(_ *rocco.Request[rocco.NoBody], stream rocco.Stream[streamEvent]) error {
+			start := 0
+			if last := stream.LastEventID(); last != "" {
+				n, err := strconv.Atoi(last)
+				if err != nil {
+					return rocco.ErrBadRequest.WithMessage("invalid Last-Event-ID")
+				}
+				start = n + 1
+			}
+			for i := start; i < 5; i++ {
+				if err := stream.SendEventWithID(strconv.Itoa(i), "tick", streamEvent{Message: "tick", Seq: i}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+
+	engine.WithHandlers(handler)
+
+	first := rtesting.ServeStream(engine, "GET", "/events", nil)
+	events := first.ParseEvents()
+	if len(events) != 5 {
+		t.Fatalf("expected 5 events, got %d", len(events))
+	}
+	for i, event := range events {
+		if event.ID != strconv.Itoa(i) || event.Event != "tick" {
+			t.Errorf("event %d: unexpected id %q or name %q", i, event.ID, event.Event)
+		}
+	}
+
+	resumed := rtesting.ServeStreamWithHeaders(engine, "GET", "/events", nil, map[string]string{
+		rocco.LastEventIDHeader: events[2].ID,
+	})
+	events = resumed.ParseEvents()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events after resuming, got %d", len(events))
+	}
+	for i, event := range events {
+		var e streamEvent
+		if err := event.DecodeJSON(&e); err != nil {
+			t.Fatalf("failed to decode event %d: %v", i, err)
+		}
+		if e.Seq != i+3 || event.ID != strconv.Itoa(i+3) {
+			t.Errorf("event %d: expected seq %d, got id %q seq %d", i, i+3, event.ID, e.Seq)
+		}
+	}
+}
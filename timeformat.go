@@ -0,0 +1,285 @@
+package rocco
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Special timeformat tag values that produce numeric timestamps.
+const (
+	timeFormatUnix      = "unix"
+	timeFormatUnixMilli = "unixmilli"
+)
+
+// isTimeType reports whether a sentinel field type string is time.Time or *time.Time.
+func isTimeType(goType string) bool {
+	return goType == "time.Time" || goType == "*time.Time"
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// timeFormats maps where timeformat-tagged fields sit in a type's JSON encoding, at any
+// depth, so documents can be converted between the tagged formats and the RFC 3339
+// strings encoding/json uses for time.Time.
+type timeFormats struct {
+	layout string                  // Go time layout, "unix", or "unixmilli" for a tagged field.
+	fields map[string]*timeFormats // Struct properties by JSON name.
+	elem   *timeFormats            // Slice and array elements, or map values.
+}
+
+// timeFormatsFor returns the timeformat fields reachable from T, or nil if there are none.
+func timeFormatsFor[T any]() *timeFormats {
+	return buildTimeFormats(reflect.TypeOf((*T)(nil)).Elem(), make(map[reflect.Type]*timeFormats))
+}
+
+// buildTimeFormats walks t the way encoding/json would. seen holds structs already
+// visited, so recursive types terminate.
+func buildTimeFormats(t reflect.Type, seen map[reflect.Type]*timeFormats) *timeFormats {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if elem := buildTimeFormats(t.Elem(), seen); elem != nil {
+			return &timeFormats{elem: elem}
+		}
+		return nil
+	case reflect.Struct:
+		if t == timeType {
+			return nil
+		}
+		if node, ok := seen[t]; ok {
+			return node
+		}
+		node := &timeFormats{fields: make(map[string]*timeFormats)}
+		seen[t] = node
+		addTimeFormatFields(node, t, seen)
+		if len(node.fields) == 0 {
+			seen[t] = nil
+			return nil
+		}
+		return node
+	default:
+		return nil
+	}
+}
+
+// addTimeFormatFields adds t's fields to node, promoting those of untagged embedded structs.
+func addTimeFormatFields(node *timeFormats, t reflect.Type, seen map[reflect.Type]*timeFormats) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			if embedded := buildTimeFormats(field.Type, seen); embedded != nil {
+				for key, child := range embedded.fields {
+					if _, exists := node.fields[key]; !exists {
+						node.fields[key] = child
+					}
+				}
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if layout := field.Tag.Get("timeformat"); layout != "" && fieldType == timeType {
+			node.fields[name] = &timeFormats{layout: layout}
+			continue
+		}
+		if child := buildTimeFormats(field.Type, seen); child != nil {
+			node.fields[name] = child
+		}
+	}
+}
+
+// field returns the node for a JSON property, matching names case-insensitively
+// as encoding/json does when decoding.
+func (f *timeFormats) field(key string) *timeFormats {
+	if child, ok := f.fields[key]; ok {
+		return child
+	}
+	for name, child := range f.fields {
+		if strings.EqualFold(name, key) {
+			return child
+		}
+	}
+	return nil
+}
+
+// format rewrites a marshaled document's tagged fields from RFC 3339 to their formats.
+func (f *timeFormats) format(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(len(body))
+	if err := f.rewrite(&buf, body, formatTimeValue); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// parse rewrites a request document's tagged fields from their formats to RFC 3339,
+// ready to be decoded.
+func (f *timeFormats) parse(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(len(body))
+	if err := f.rewrite(&buf, body, parseTimeValue); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// rewrite copies a JSON value into buf, replacing the value of each tagged field with
+// convert's result. Keys keep their order; values without tagged fields are copied as-is.
+func (f *timeFormats) rewrite(buf *bytes.Buffer, value []byte, convert func([]byte, string) ([]byte, error)) error {
+	if f.layout != "" {
+		converted, err := convert(value, f.layout)
+		if err != nil {
+			return err
+		}
+		buf.Write(converted)
+		return nil
+	}
+
+	value = bytes.TrimSpace(value)
+	if len(value) == 0 || (value[0] != '{' && value[0] != '[') {
+		buf.Write(value)
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(value))
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	isObject := value[0] == '{'
+	buf.WriteByte(value[0])
+	for i := 0; dec.More(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		child := f.elem
+		if isObject {
+			token, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			key, _ := token.(string)
+			encodedKey, err := json.Marshal(key)
+			if err != nil {
+				return err
+			}
+			buf.Write(encodedKey)
+			buf.WriteByte(':')
+			if f.fields != nil {
+				child = f.field(key)
+			}
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		if child == nil {
+			buf.Write(raw)
+		} else if err := child.rewrite(buf, raw, convert); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	if isObject {
+		buf.WriteByte('}')
+	} else {
+		buf.WriteByte(']')
+	}
+	return nil
+}
+
+// formatTimeValue converts a marshaled time.Time to layout. null is kept.
+func formatTimeValue(value []byte, layout string) ([]byte, error) {
+	var t *time.Time
+	if err := json.Unmarshal(value, &t); err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return value, nil
+	}
+	return formatTime(*t, layout)
+}
+
+// parseTimeValue converts a value written in layout to a marshaled time.Time. null is kept.
+func parseTimeValue(value []byte, layout string) ([]byte, error) {
+	if string(value) == "null" {
+		return value, nil
+	}
+
+	var t time.Time
+	switch layout {
+	case timeFormatUnix, timeFormatUnixMilli:
+		var n int64
+		if err := json.Unmarshal(value, &n); err != nil {
+			return nil, fmt.Errorf("expected a %s timestamp, got %s", layout, value)
+		}
+		if layout == timeFormatUnix {
+			t = time.Unix(n, 0).UTC()
+		} else {
+			t = time.UnixMilli(n).UTC()
+		}
+	default:
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil {
+			return nil, fmt.Errorf("expected a time in layout %q, got %s", layout, value)
+		}
+		parsed, err := time.Parse(layout, s)
+		if err != nil {
+			return nil, err
+		}
+		t = parsed
+	}
+	return json.Marshal(t)
+}
+
+// formatTime renders t according to layout as a JSON value.
+func formatTime(t time.Time, layout string) ([]byte, error) {
+	switch layout {
+	case timeFormatUnix:
+		return json.Marshal(t.Unix())
+	case timeFormatUnixMilli:
+		return json.Marshal(t.UnixMilli())
+	default:
+		return json.Marshal(t.Format(layout))
+	}
+}
+
+// timeFormatSchema adjusts a time field schema to reflect its configured format.
+func timeFormatSchema(layout string) (schemaType, format string, example any) {
+	ref := time.Date(2024, time.January, 15, 10, 30, 0, 0, time.UTC)
+	switch layout {
+	case timeFormatUnix:
+		return "integer", "unix-time", ref.Unix()
+	case timeFormatUnixMilli:
+		return "integer", "unix-time-millis", ref.UnixMilli()
+	case time.DateOnly:
+		return "string", "date", ref.Format(layout)
+	case time.RFC3339, time.RFC3339Nano:
+		return "string", "date-time", ref.Format(layout)
+	default:
+		return "string", "", ref.Format(layout)
+	}
+}
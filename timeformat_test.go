@@ -0,0 +1,214 @@
+package rocco
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zoobzio/openapi"
+	"github.com/zoobzio/sentinel"
+)
+
+type timeFormatOutput struct {
+	Created time.Time  `json:"created" timeformat:"unix"`
+	Day     time.Time  `json:"day" timeformat:"2006-01-02"`
+	Updated *time.Time `json:"updated,omitempty" timeformat:"unixmilli"`
+	Plain   time.Time  `json:"plain"`
+}
+
+func TestHandler_TimeFormat(t *testing.T) {
+	ts := time.Date(2024, time.March, 5, 12, 0, 0, 0, time.UTC)
+
+	handler := NewHandler[NoBody, timeFormatOutput](
+		"time",
+		"GET",
+		"/time",
+		func(_ *Request[NoBody]) (timeFormatOutput, error) {
+			return timeFormatOutput{Created: ts, Day: ts, Updated: &ts, Plain: ts}, nil
+		},
+	)
+
+	req := httptest.NewRequest("GET", "/time", nil)
+	w := httptest.NewRecorder()
+
+	if _, err := handler.Process(context.Background(), req, w); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var result map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if result["created"] != float64(ts.Unix()) {
+		t.Errorf("expected unix timestamp %d, got %v", ts.Unix(), result["created"])
+	}
+	if result["day"] != "2024-03-05" {
+		t.Errorf("expected day '2024-03-05', got %v", result["day"])
+	}
+	if result["updated"] != float64(ts.UnixMilli()) {
+		t.Errorf("expected unix millis %d, got %v", ts.UnixMilli(), result["updated"])
+	}
+	if result["plain"] != ts.Format(time.RFC3339) {
+		t.Errorf("expected RFC3339 plain time, got %v", result["plain"])
+	}
+}
+
+type timeFormatEntry struct {
+	At   time.Time `json:"at" timeformat:"unix"`
+	Note string    `json:"note"`
+}
+
+type timeFormatNested struct {
+	Zeta    string                     `json:"zeta"`
+	Entries []timeFormatEntry          `json:"entries"`
+	ByName  map[string]timeFormatEntry `json:"by_name"`
+	Alpha   *timeFormatEntry           `json:"alpha"`
+}
+
+func TestHandler_TimeFormat_Nested(t *testing.T) {
+	ts := time.Date(2024, time.March, 5, 12, 0, 0, 0, time.UTC)
+	entry := timeFormatEntry{At: ts, Note: "n"}
+
+	handler := NewHandler[NoBody, timeFormatNested](
+		"time",
+		"GET",
+		"/time",
+		func(_ *Request[NoBody]) (timeFormatNested, error) {
+			return timeFormatNested{
+				Zeta:    "z",
+				Entries: []timeFormatEntry{entry},
+				ByName:  map[string]timeFormatEntry{"a": entry},
+			}, nil
+		},
+	)
+
+	w := httptest.NewRecorder()
+	if _, err := handler.Process(context.Background(), httptest.NewRequest("GET", "/time", nil), w); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Fields keep their declared order rather than being sorted.
+	want := fmt.Sprintf(`{"zeta":"z","entries":[{"at":%[1]d,"note":"n"}],"by_name":{"a":{"at":%[1]d,"note":"n"}},"alpha":null}`, ts.Unix())
+	if got := w.Body.String(); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
+
+func TestHandler_TimeFormat_Input(t *testing.T) {
+	type input struct {
+		Day     time.Time         `json:"day" timeformat:"2006-01-02"`
+		Entries []timeFormatEntry `json:"entries"`
+	}
+
+	var got input
+	handler := NewHandler[input, NoBody](
+		"time",
+		"POST",
+		"/time",
+		func(r *Request[input]) (NoBody, error) {
+			got = r.Body
+			return NoBody{}, nil
+		},
+	)
+
+	body := `{"day":"2024-03-05","entries":[{"at":1709640000,"note":"n"}]}`
+	w := httptest.NewRecorder()
+	if _, err := handler.Process(context.Background(), httptest.NewRequest("POST", "/time", strings.NewReader(body)), w); err != nil {
+		t.Fatalf("unexpected error: %v (%s)", err, w.Body.String())
+	}
+	if !got.Day.Equal(time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected day %v", got.Day)
+	}
+	if len(got.Entries) != 1 || got.Entries[0].At.Unix() != 1709640000 {
+		t.Errorf("unexpected entries %+v", got.Entries)
+	}
+
+	// A value that does not match the declared format is rejected.
+	w = httptest.NewRecorder()
+	status, err := handler.Process(context.Background(), httptest.NewRequest("POST", "/time", strings.NewReader(`{"day":"2024-03-05T00:00:00Z"}`)), w)
+	if err == nil || status != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for a mismatched format, got %d (%v)", status, err)
+	}
+}
+
+func TestStream_TimeFormat(t *testing.T) {
+	ts := time.Date(2024, time.March, 5, 12, 0, 0, 0, time.UTC)
+	w := newFlushRecorder()
+	stream := &sseStream[timeFormatEntry]{w: w, flusher: w, done: make(chan struct{}), times: timeFormatsFor[timeFormatEntry]()}
+
+	if err := stream.Send(timeFormatEntry{At: ts, Note: "n"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := fmt.Sprintf("data: {\"at\":%d,\"note\":\"n\"}\n\n", ts.Unix())
+	if got := w.Body.String(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestTimeFormatsFor(t *testing.T) {
+	type tree struct {
+		At       time.Time `json:"at" timeformat:"unix"`
+		Children []tree    `json:"children"`
+	}
+	type embedded struct {
+		timeFormatEntry
+		Ignored time.Time `json:"-" timeformat:"unix"`
+	}
+
+	if timeFormatsFor[testOutput]() != nil {
+		t.Error("expected nil for a type without timeformat fields")
+	}
+	if timeFormatsFor[string]() != nil {
+		t.Error("expected nil for a non-struct type")
+	}
+
+	node := timeFormatsFor[tree]()
+	if node == nil || node.field("at").layout != "unix" || node.field("children").elem != node {
+		t.Errorf("unexpected formats for a recursive type: %+v", node)
+	}
+
+	node = timeFormatsFor[embedded]()
+	if node == nil || node.field("AT") == nil || node.field("Ignored") != nil {
+		t.Errorf("unexpected formats for an embedded type: %+v", node)
+	}
+}
+
+func TestApplyOpenAPITags_TimeFormat(t *testing.T) {
+	tests := []struct {
+		layout     string
+		wantType   string
+		wantFormat string
+	}{
+		{"unix", "integer", "unix-time"},
+		{"unixmilli", "integer", "unix-time-millis"},
+		{"2006-01-02", "string", "date"},
+		{"Jan 2, 2006", "string", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			field := sentinel.FieldMetadata{
+				Name: "Created",
+				Type: "time.Time",
+				Tags: map[string]string{"timeformat": tt.layout},
+			}
+			schema := &openapi.Schema{Type: openapi.NewSchemaType("string"), Format: "date-time"}
+			applyOpenAPITags(schema, field)
+
+			if schema.Type.String() != tt.wantType {
+				t.Errorf("expected type %q, got %q", tt.wantType, schema.Type.String())
+			}
+			if schema.Format != tt.wantFormat {
+				t.Errorf("expected format %q, got %q", tt.wantFormat, schema.Format)
+			}
+			if schema.Example == nil {
+				t.Error("expected example to be set")
+			}
+		})
+	}
+}